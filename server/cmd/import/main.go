@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// mappingFlag collects repeated -map field=column flags
+type mappingFlag map[string]string
+
+func (m mappingFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for field, column := range m {
+		pairs = append(pairs, field+"="+column)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m mappingFlag) Set(value string) error {
+	field, column, ok := strings.Cut(value, "=")
+	if !ok || field == "" || column == "" {
+		return fmt.Errorf("mapping must look like field=column, got %q", value)
+	}
+	m[field] = column
+	return nil
+}
+
+func main() {
+	mapping := mappingFlag{}
+	kind := flag.String("kind", "tasks", "what the file holds: tasks or users")
+	file := flag.String("file", "", "CSV file to import")
+	managerID := flag.String("manager", "", "ID of the manager the import runs as")
+	dryRun := flag.Bool("dry-run", false, "only validate the rows")
+	errorsPath := flag.String("errors", "", "write the rejected rows to this CSV file")
+	flag.Var(mapping, "map", "read a field from a differently named column, as field=column (repeatable)")
+	flag.Parse()
+
+	if *file == "" || *managerID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := godotenv.Load(".env")
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.DbConnect()
+	if err != nil {
+		log.Fatal("Failed to connect to the database:", err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			log.Println("Failed to close database connection:", err)
+		}
+	}()
+
+	input, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		err := input.Close()
+		if err != nil {
+			log.Println("Failed to close file:", err)
+		}
+	}()
+
+	// Managers are the users who are not linked to a manager themselves
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM users u WHERE u.id = ? AND NOT EXISTS"+
+		" (SELECT 1 FROM managers m WHERE m.technician_id = u.id)", *managerID).Scan(&count)
+	if err != nil {
+		log.Fatal("Failed to look up the manager:", err)
+	}
+	if count == 0 {
+		log.Fatalf("%s is not a manager", *managerID)
+	}
+
+	report, err := models.RunImport(db, models.ImportOptions{
+		Kind:      *kind,
+		Mapping:   mapping,
+		DryRun:    *dryRun,
+		ManagerID: *managerID,
+		RequestID: "cli",
+	}, input)
+	if err != nil {
+		log.Fatal("Import failed: ", err)
+	}
+
+	fmt.Printf("Import %s: %d rows, %d valid, %d imported, %d rejected\n",
+		report.ID, report.Total, report.Valid, report.Imported, report.Rejected)
+	for _, rowError := range report.Errors {
+		fmt.Printf("  line %d: %s\n", rowError.Line, rowError.Message)
+	}
+	if report.Rejected > len(report.Errors) {
+		fmt.Printf("  ... and %d more\n", report.Rejected-len(report.Errors))
+	}
+
+	if *errorsPath != "" && report.Rejected > 0 {
+		err = os.WriteFile(*errorsPath, report.ErrorsCSV, 0o644)
+		if err != nil {
+			log.Fatal("Failed to write the error file:", err)
+		}
+		fmt.Println("Rejected rows written to", *errorsPath)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func main() {
+	err := godotenv.Load(".env")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	topic := flag.String("topic", config.Getenv("KAFKA_DLQ_TOPIC", "task-events-dlq"), "dead-letter topic to replay")
+	group := flag.String("group", "task-app-dlq-replay", "consumer group that remembers what was replayed")
+	limit := flag.Int("limit", 0, "replay at most this many messages (0 for all)")
+	idle := flag.Duration("idle", 10*time.Second, "stop once no message arrived for this long")
+	dryRun := flag.Bool("dry-run", false, "only list the messages, leaving them in the dead-letter topic")
+	flag.Parse()
+
+	brokers := strings.Split(config.Getenv("KAFKA_BROKERS", "localhost:9092"), ",")
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		GroupID:     *group,
+		Topic:       *topic,
+		StartOffset: kafka.FirstOffset,
+	})
+	defer func() {
+		err := reader.Close()
+		if err != nil {
+			log.Println("Failed to close Kafka reader:", err)
+		}
+	}()
+	// The topic of each message is the one it was dead-lettered from
+	writer := &kafka.Writer{
+		Addr:     kafka.TCP(brokers...),
+		Balancer: &kafka.Hash{},
+	}
+	defer func() {
+		err := writer.Close()
+		if err != nil {
+			log.Println("Failed to close Kafka writer:", err)
+		}
+	}()
+
+	replayed, err := services.ReplayDeadLetters(context.Background(), reader, writer, services.ReplayOptions{
+		Limit:  *limit,
+		Idle:   *idle,
+		DryRun: *dryRun,
+		Report: func(deadLetter kafka.Message) {
+			fmt.Printf("offset %d from %s/%s/%s, %s attempts, failed at %s: %s\n", deadLetter.Offset,
+				services.DeadLetterHeader(deadLetter, services.DeadLetterTopic),
+				services.DeadLetterHeader(deadLetter, services.DeadLetterPartition),
+				services.DeadLetterHeader(deadLetter, services.DeadLetterOffset),
+				services.DeadLetterHeader(deadLetter, services.DeadLetterAttempts),
+				services.DeadLetterHeader(deadLetter, services.DeadLetterFailedAt),
+				services.DeadLetterHeader(deadLetter, services.DeadLetterError))
+		},
+	})
+	if *dryRun {
+		fmt.Printf("%d dead letters listed\n", replayed)
+	} else {
+		fmt.Printf("%d dead letters replayed\n", replayed)
+	}
+	if err != nil {
+		log.Fatal("Replay failed: ", err)
+	}
+}
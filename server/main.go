@@ -2,9 +2,14 @@ package main
 
 import (
 	"log"
+	"strings"
+	"time"
+	// Digest schedules use IANA timezones, which the container image does not ship
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
 	"github.com/christianotieno/tasks-traker-app/server/src/handlers"
 )
 
@@ -19,6 +24,32 @@ func main() {
 		log.Fatal(err)
 		return
 	}
+	err = handlers.InitBlobStore()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	err = handlers.InitSearchIndex()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	err = handlers.InitMailer()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	kafkaBrokers := strings.Split(config.Getenv("KAFKA_BROKERS", "localhost:9092"), ",")
+	err = handlers.InitKafkaProducer(kafkaBrokers)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	err = handlers.InitEventBus()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
 	defer func() {
 		err := handlers.CloseDbConnection()
 		if err != nil {
@@ -33,7 +64,29 @@ func main() {
 	}()
 
 	// Start Kafka consumer
-	go handlers.HandleKafkaMessages([]string{"localhost:9092"})
+	go handlers.HandleKafkaMessages(kafkaBrokers)
+
+	// Warn managers about overdue tasks that block other work
+	go handlers.WatchOverdueBlockers(time.Hour)
+
+	// Permanently delete tasks that have been in the trash past the retention period
+	go handlers.PurgeTrash(24 * time.Hour)
+
+	// Forget idempotency keys once their replay window has passed
+	go handlers.PurgeIdempotencyKeys(time.Hour)
+
+	// Keep the embedded search index, when enabled, in step with the tasks
+	go handlers.RefreshSearchIndex(time.Duration(config.GetenvInt("SEARCH_INDEX_REFRESH_SECONDS", 60)) * time.Second)
+
+	// Compile and deliver manager digests once their send time has passed
+	go handlers.SendDigests(time.Duration(config.GetenvInt("DIGEST_CHECK_MINUTES", 5)) * time.Minute)
+
+	// Share task changes, comments and presence with the live task channels
+	go handlers.RunLiveHub(time.Duration(config.GetenvInt("LIVE_PRESENCE_SECONDS", 30)) * time.Second)
+
+	// Queue task events for webhooks and send them, retrying failed deliveries
+	go handlers.QueueWebhooks()
+	go handlers.DeliverWebhooks(time.Duration(config.GetenvInt("WEBHOOK_CHECK_SECONDS", 10)) * time.Second)
 
 	// Keep the main function running
 	select {}
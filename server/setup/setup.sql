@@ -21,3 +21,360 @@ CREATE TABLE managers (
                           FOREIGN KEY (manager_id) REFERENCES users(id),
                           FOREIGN KEY (technician_id) REFERENCES users(id)
 );
+
+CREATE TABLE task_attachments (
+                       id VARCHAR(36) PRIMARY KEY,
+                       task_id VARCHAR(36) NOT NULL,
+                       file_name VARCHAR(255) NOT NULL,
+                       content_type VARCHAR(100) NOT NULL,
+                       size BIGINT NOT NULL,
+                       checksum CHAR(64) NOT NULL,
+                       storage_key VARCHAR(255) NOT NULL,
+                       uploaded_by VARCHAR(36) NOT NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+                       FOREIGN KEY (uploaded_by) REFERENCES users(id)
+);
+
+CREATE TABLE worklogs (
+                       id VARCHAR(36) PRIMARY KEY,
+                       task_id VARCHAR(36) NOT NULL,
+                       user_id VARCHAR(36) NOT NULL,
+                       started_at DATETIME NOT NULL,
+                       ended_at DATETIME NULL,
+                       duration_minutes INT NULL,
+                       notes TEXT,
+                       running_user_id VARCHAR(36) AS (IF(ended_at IS NULL, user_id, NULL)) STORED,
+                       UNIQUE KEY one_running_timer (running_user_id),
+                       INDEX worklogs_user_started (user_id, started_at),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+                       FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE assets (
+                       id VARCHAR(36) PRIMARY KEY,
+                       name VARCHAR(100) NOT NULL,
+                       type VARCHAR(50) NOT NULL,
+                       location VARCHAR(255) NOT NULL DEFAULT '',
+                       serial VARCHAR(100) NOT NULL DEFAULT '',
+                       parent_id VARCHAR(36) NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       FOREIGN KEY (parent_id) REFERENCES assets(id)
+);
+
+ALTER TABLE tasks
+    ADD COLUMN asset_id VARCHAR(36) NULL,
+    ADD FOREIGN KEY (asset_id) REFERENCES assets(id);
+
+CREATE TABLE locations (
+                       id VARCHAR(36) PRIMARY KEY,
+                       name VARCHAR(100) NOT NULL,
+                       kind ENUM('site', 'building', 'floor', 'room') NOT NULL,
+                       parent_id VARCHAR(36) NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       FOREIGN KEY (parent_id) REFERENCES locations(id)
+);
+
+CREATE TABLE manager_sites (
+                       manager_id VARCHAR(36) NOT NULL,
+                       location_id VARCHAR(36) NOT NULL,
+                       PRIMARY KEY (manager_id, location_id),
+                       FOREIGN KEY (manager_id) REFERENCES users(id),
+                       FOREIGN KEY (location_id) REFERENCES locations(id)
+);
+
+ALTER TABLE tasks
+    ADD COLUMN location_id VARCHAR(36) NULL,
+    ADD FOREIGN KEY (location_id) REFERENCES locations(id);
+
+ALTER TABLE assets
+    ADD COLUMN location_id VARCHAR(36) NULL,
+    ADD FOREIGN KEY (location_id) REFERENCES locations(id);
+
+CREATE TABLE task_tags (
+                       task_id VARCHAR(36) NOT NULL,
+                       tag VARCHAR(50) NOT NULL,
+                       PRIMARY KEY (task_id, tag),
+                       INDEX task_tags_tag (tag),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE custom_fields (
+                       id VARCHAR(36) PRIMARY KEY,
+                       name VARCHAR(50) NOT NULL UNIQUE,
+                       label VARCHAR(100) NOT NULL,
+                       type ENUM('text', 'number', 'enum', 'date') NOT NULL,
+                       options JSON,
+                       required BOOLEAN NOT NULL DEFAULT FALSE,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE task_custom_field_values (
+                       task_id VARCHAR(36) NOT NULL,
+                       field_id VARCHAR(36) NOT NULL,
+                       value VARCHAR(255) NOT NULL,
+                       PRIMARY KEY (task_id, field_id),
+                       INDEX task_custom_field_values_lookup (field_id, value),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+                       FOREIGN KEY (field_id) REFERENCES custom_fields(id) ON DELETE CASCADE
+);
+
+ALTER TABLE tasks
+    ADD COLUMN status ENUM('open', 'in_progress', 'done') NOT NULL DEFAULT 'open',
+    ADD COLUMN due_date DATE NULL,
+    ADD COLUMN completed_at DATETIME NULL;
+
+CREATE TABLE task_dependencies (
+                       task_id VARCHAR(36) NOT NULL,
+                       blocked_by_id VARCHAR(36) NOT NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       PRIMARY KEY (task_id, blocked_by_id),
+                       INDEX task_dependencies_blocked_by (blocked_by_id),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+                       FOREIGN KEY (blocked_by_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE overdue_blocker_warnings (
+                       task_id VARCHAR(36) NOT NULL,
+                       due_date DATE NOT NULL,
+                       sent_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       PRIMARY KEY (task_id, due_date),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_templates (
+                       id VARCHAR(36) PRIMARY KEY,
+                       name VARCHAR(100) NOT NULL UNIQUE,
+                       summary VARCHAR(255) NOT NULL,
+                       checklist JSON,
+                       tags JSON,
+                       priority ENUM('low', 'normal', 'high', 'urgent') NOT NULL DEFAULT 'normal',
+                       estimated_minutes INT NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tasks
+    ADD COLUMN priority ENUM('low', 'normal', 'high', 'urgent') NOT NULL DEFAULT 'normal',
+    ADD COLUMN estimated_minutes INT NULL,
+    ADD COLUMN template_id VARCHAR(36) NULL,
+    ADD FOREIGN KEY (template_id) REFERENCES task_templates(id) ON DELETE SET NULL;
+
+CREATE TABLE task_checklist_items (
+                       task_id VARCHAR(36) NOT NULL,
+                       position INT NOT NULL,
+                       text VARCHAR(255) NOT NULL,
+                       done BOOLEAN NOT NULL DEFAULT FALSE,
+                       PRIMARY KEY (task_id, position),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+ALTER TABLE tasks
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD COLUMN deleted_by VARCHAR(36) NULL,
+    ADD INDEX tasks_deleted_at (deleted_at);
+
+CREATE TABLE audit_log (
+                       id BIGINT AUTO_INCREMENT PRIMARY KEY,
+                       actor_id VARCHAR(36) NULL,
+                       action VARCHAR(20) NOT NULL,
+                       entity_type VARCHAR(20) NOT NULL,
+                       entity_id VARCHAR(36) NOT NULL,
+                       before_json JSON,
+                       after_json JSON,
+                       request_id VARCHAR(64) NULL,
+                       ip VARCHAR(45) NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       INDEX audit_log_entity (entity_type, entity_id),
+                       INDEX audit_log_actor (actor_id, created_at),
+                       INDEX audit_log_created (created_at)
+);
+
+-- The audit log is append-only
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TABLE task_revisions (
+                       task_id VARCHAR(36) NOT NULL,
+                       revision INT NOT NULL,
+                       source ENUM('initial', 'create', 'update', 'revert') NOT NULL,
+                       reverted_from INT NULL,
+                       changed_by VARCHAR(36) NOT NULL,
+                       snapshot JSON NOT NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       PRIMARY KEY (task_id, revision),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+ALTER TABLE tasks
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE idempotency_keys (
+                       user_id VARCHAR(36) NOT NULL,
+                       idem_key VARCHAR(255) NOT NULL,
+                       request_hash CHAR(64) NOT NULL,
+                       status_code INT NULL,
+                       headers JSON,
+                       response_body MEDIUMBLOB,
+                       created_at DATETIME NOT NULL,
+                       PRIMARY KEY (user_id, idem_key),
+                       INDEX idempotency_keys_created (created_at)
+);
+
+ALTER TABLE task_revisions
+    MODIFY COLUMN source ENUM('initial', 'create', 'update', 'revert', 'bulk') NOT NULL;
+
+ALTER TABLE tasks
+    ADD FULLTEXT INDEX tasks_summary_fulltext (summary);
+
+CREATE TABLE saved_views (
+                       id VARCHAR(36) PRIMARY KEY,
+                       owner_id VARCHAR(36) NOT NULL,
+                       name VARCHAR(100) NOT NULL,
+                       filters JSON NOT NULL,
+                       shared BOOLEAN NOT NULL DEFAULT FALSE,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       UNIQUE (owner_id, name),
+                       FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE saved_view_defaults (
+                       user_id VARCHAR(36) PRIMARY KEY,
+                       view_id VARCHAR(36) NOT NULL,
+                       FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+                       FOREIGN KEY (view_id) REFERENCES saved_views(id) ON DELETE CASCADE
+);
+
+CREATE TABLE imports (
+                       id VARCHAR(36) PRIMARY KEY,
+                       kind ENUM('tasks', 'users') NOT NULL,
+                       created_by VARCHAR(36) NOT NULL,
+                       dry_run BOOLEAN NOT NULL,
+                       total INT NOT NULL,
+                       imported INT NOT NULL,
+                       rejected INT NOT NULL,
+                       errors_csv MEDIUMBLOB,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE calendar_feeds (
+                       user_id VARCHAR(36) PRIMARY KEY,
+                       token_hash CHAR(64) NOT NULL UNIQUE,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE digest_settings (
+                       manager_id VARCHAR(36) PRIMARY KEY,
+                       frequency ENUM('off', 'daily', 'weekly') NOT NULL DEFAULT 'off',
+                       send_hour TINYINT NOT NULL DEFAULT 7,
+                       send_weekday TINYINT NOT NULL DEFAULT 1,
+                       timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+                       email BOOLEAN NOT NULL DEFAULT TRUE,
+                       last_sent_at DATETIME NULL,
+                       FOREIGN KEY (manager_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE digest_reports (
+                       id VARCHAR(36) PRIMARY KEY,
+                       manager_id VARCHAR(36) NOT NULL,
+                       frequency ENUM('daily', 'weekly') NOT NULL,
+                       period_start DATETIME NOT NULL,
+                       period_end DATETIME NOT NULL,
+                       subject VARCHAR(255) NOT NULL,
+                       text_body MEDIUMTEXT NOT NULL,
+                       html_body MEDIUMTEXT NOT NULL,
+                       digest JSON NOT NULL,
+                       emailed BOOLEAN NOT NULL DEFAULT FALSE,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       INDEX digest_reports_manager (manager_id, period_end),
+                       FOREIGN KEY (manager_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE tasks
+    ADD INDEX tasks_user_date (user_id, date),
+    ADD INDEX tasks_user_completed (user_id, completed_at),
+    ADD INDEX tasks_user_due (user_id, due_date);
+
+CREATE TABLE branding (
+                       manager_id VARCHAR(36) PRIMARY KEY,
+                       company_name VARCHAR(100) NOT NULL,
+                       address VARCHAR(255) NOT NULL DEFAULT '',
+                       phone VARCHAR(50) NOT NULL DEFAULT '',
+                       email VARCHAR(100) NOT NULL DEFAULT '',
+                       accent_color CHAR(7) NOT NULL DEFAULT '#1f4e79',
+                       footer VARCHAR(255) NOT NULL DEFAULT '',
+                       logo_key VARCHAR(255) NULL,
+                       FOREIGN KEY (manager_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE task_revisions
+    MODIFY COLUMN source ENUM('initial', 'create', 'update', 'revert', 'bulk', 'live') NOT NULL;
+
+CREATE TABLE task_viewers (
+                       connection_id CHAR(36) PRIMARY KEY,
+                       task_id VARCHAR(36) NOT NULL,
+                       user_id VARCHAR(36) NOT NULL,
+                       seen_at DATETIME NOT NULL,
+                       INDEX task_viewers_task (task_id, seen_at),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+                       FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE task_comments (
+                       id CHAR(36) PRIMARY KEY,
+                       task_id VARCHAR(36) NOT NULL,
+                       user_id VARCHAR(36) NOT NULL,
+                       body TEXT NOT NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       INDEX task_comments_task (task_id, created_at),
+                       FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+                       FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE webhooks (
+                       id VARCHAR(36) PRIMARY KEY,
+                       manager_id VARCHAR(36) NOT NULL,
+                       url VARCHAR(2048) NOT NULL,
+                       description VARCHAR(255) NOT NULL DEFAULT '',
+                       event_types VARCHAR(255) NOT NULL,
+                       secret CHAR(64) NOT NULL,
+                       active BOOLEAN NOT NULL DEFAULT TRUE,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       INDEX webhooks_manager (manager_id, created_at),
+                       FOREIGN KEY (manager_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE webhook_deliveries (
+                       id VARCHAR(36) PRIMARY KEY,
+                       webhook_id VARCHAR(36) NOT NULL,
+                       event_id BIGINT NOT NULL,
+                       event_type VARCHAR(50) NOT NULL,
+                       task_id VARCHAR(36) NOT NULL,
+                       payload JSON NOT NULL,
+                       status ENUM('pending', 'delivered', 'dead') NOT NULL DEFAULT 'pending',
+                       attempts INT NOT NULL DEFAULT 0,
+                       next_attempt_at DATETIME NULL,
+                       response_code INT NULL,
+                       error VARCHAR(500) NOT NULL DEFAULT '',
+                       delivered_at DATETIME NULL,
+                       created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       INDEX webhook_deliveries_due (status, next_attempt_at),
+                       INDEX webhook_deliveries_webhook (webhook_id, created_at),
+                       FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE webhook_attempts (
+                       id BIGINT AUTO_INCREMENT PRIMARY KEY,
+                       delivery_id VARCHAR(36) NOT NULL,
+                       attempted_at DATETIME NOT NULL,
+                       response_code INT NULL,
+                       response VARCHAR(1024) NOT NULL DEFAULT '',
+                       error VARCHAR(500) NOT NULL DEFAULT '',
+                       duration_ms INT NOT NULL,
+                       INDEX webhook_attempts_delivery (delivery_id, attempted_at),
+                       FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
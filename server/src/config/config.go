@@ -2,6 +2,11 @@ package config
 
 import (
 	"database/sql"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -21,3 +26,46 @@ func TestDbConnect() (db *sql.DB, err error) {
 	}
 	return db, nil
 }
+
+// Getenv returns the value of the environment variable key, or fallback when it is unset
+func Getenv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetenvInt returns the environment variable key parsed as an integer, or fallback when it is unset or invalid
+func GetenvInt(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetenvNetworks parses the environment variable key as a comma separated list of addresses and CIDR
+// ranges, skipping entries that are neither
+func GetenvNetworks(key string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("Ignoring invalid address in %s: %s", key, entry)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,39 @@
+package entities
+
+// AnalyticsPoint is the value of a metric over one day or week. Count is the number of tasks the
+// value was computed from.
+type AnalyticsPoint struct {
+	Period string  `json:"period"`
+	Value  float64 `json:"value"`
+	Count  int     `json:"count"`
+}
+
+// AnalyticsSeries is a metric over time for one technician, or for the whole team under the key "all"
+type AnalyticsSeries struct {
+	Key    string           `json:"key"`
+	Label  string           `json:"label"`
+	Points []AnalyticsPoint `json:"points"`
+}
+
+// AnalyticsReport holds a time series per technician with a point for every period between From and To
+type AnalyticsReport struct {
+	Metric string            `json:"metric"`
+	Bucket string            `json:"bucket"`
+	From   string            `json:"from"`
+	To     string            `json:"to"`
+	Series []AnalyticsSeries `json:"series"`
+}
+
+type AnalyticsRank struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// AnalyticsTop ranks assets or tags by the number of tasks dated between From and To
+type AnalyticsTop struct {
+	Dimension string          `json:"dimension"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Items     []AnalyticsRank `json:"items"`
+}
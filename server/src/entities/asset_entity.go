@@ -0,0 +1,17 @@
+package entities
+
+type Asset struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Location   string `json:"location"`
+	Serial     string `json:"serial"`
+	ParentID   string `json:"parent_id,omitempty"`
+	LocationID string `json:"location_id,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type AssetHistory struct {
+	Asset Asset  `json:"asset"`
+	Tasks []Task `json:"tasks"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+// BulkRequest is a list of task operations applied in one call
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkOperation is a single change to a task. Op is one of delete, retag, reassign or set_status
+// and decides which of the other fields are used.
+type BulkOperation struct {
+	Op         string   `json:"op"`
+	TaskID     string   `json:"task_id"`
+	Tags       []string `json:"tags,omitempty"`
+	AddTags    []string `json:"add_tags,omitempty"`
+	RemoveTags []string `json:"remove_tags,omitempty"`
+	UserID     string   `json:"user_id,omitempty"`
+	Status     string   `json:"status,omitempty"`
+}
+
+// BulkResult reports the outcome of one operation
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	TaskID string `json:"task_id"`
+	Status string `json:"status"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Task   *Task  `json:"task,omitempty"`
+}
@@ -0,0 +1,7 @@
+package entities
+
+// CalendarFeed is a newly issued calendar feed. The token is only shown once, as only its hash is stored.
+type CalendarFeed struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+type CustomField struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Options   []string `json:"options,omitempty"`
+	Required  bool     `json:"required"`
+	CreatedAt string   `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package entities
+
+type TaskDependency struct {
+	TaskID    string `json:"task_id"`
+	BlockedBy string `json:"blocked_by"`
+}
+
+// DependencyNode is a task in a dependency graph. Restricted nodes are tasks outside the
+// requesting user's visibility and carry no summary.
+type DependencyNode struct {
+	ID         string `json:"id"`
+	Summary    string `json:"summary,omitempty"`
+	Status     string `json:"status"`
+	DueDate    string `json:"due_date,omitempty"`
+	Overdue    bool   `json:"overdue"`
+	Restricted bool   `json:"restricted"`
+}
+
+type DependencyGraph struct {
+	TaskID string           `json:"task_id"`
+	Nodes  []DependencyNode `json:"nodes"`
+	Edges  []TaskDependency `json:"edges"`
+}
+
+// OverdueBlocker is an open task past its due date that still blocks other tasks
+type OverdueBlocker struct {
+	TaskID       string   `json:"task_id"`
+	Summary      string   `json:"summary"`
+	DueDate      string   `json:"due_date"`
+	TechnicianID string   `json:"technician_id"`
+	ManagerID    string   `json:"manager_id"`
+	BlockedTasks []string `json:"blocked_tasks"`
+}
@@ -0,0 +1,63 @@
+package entities
+
+// DigestSettings controls when a manager receives a digest. SendHour is the hour of the day and
+// SendWeekday the day of the week for weekly digests (0 is Sunday), both in Timezone.
+type DigestSettings struct {
+	Frequency   string `json:"frequency"`
+	SendHour    int    `json:"send_hour"`
+	SendWeekday int    `json:"send_weekday"`
+	Timezone    string `json:"timezone"`
+	Email       bool   `json:"email"`
+	LastSentAt  string `json:"last_sent_at,omitempty"`
+}
+
+type DigestTask struct {
+	ID          string `json:"id"`
+	Summary     string `json:"summary"`
+	DueDate     string `json:"due_date,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+// DigestTechnician is one technician's activity over the digest period. Overdue tasks are those still
+// open past their due date at the end of the period.
+type DigestTechnician struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Email     string       `json:"email"`
+	Created   []DigestTask `json:"created"`
+	Completed []DigestTask `json:"completed"`
+	Overdue   []DigestTask `json:"overdue"`
+}
+
+type DigestTotals struct {
+	Created   int `json:"created"`
+	Completed int `json:"completed"`
+	Overdue   int `json:"overdue"`
+}
+
+// Digest is a manager's summary of their technicians' tasks. The period is given in the manager's
+// timezone.
+type Digest struct {
+	ManagerID   string             `json:"manager_id"`
+	ManagerName string             `json:"manager_name"`
+	Frequency   string             `json:"frequency"`
+	Timezone    string             `json:"timezone"`
+	PeriodStart string             `json:"period_start"`
+	PeriodEnd   string             `json:"period_end"`
+	Totals      DigestTotals       `json:"totals"`
+	Technicians []DigestTechnician `json:"technicians"`
+}
+
+// DigestReport is a stored digest. Listings leave out the rendered bodies and the digest itself.
+type DigestReport struct {
+	ID          string  `json:"id"`
+	Frequency   string  `json:"frequency"`
+	PeriodStart string  `json:"period_start"`
+	PeriodEnd   string  `json:"period_end"`
+	Subject     string  `json:"subject"`
+	Emailed     bool    `json:"emailed"`
+	CreatedAt   string  `json:"created_at"`
+	Text        string  `json:"text,omitempty"`
+	HTML        string  `json:"html,omitempty"`
+	Digest      *Digest `json:"digest,omitempty"`
+}
@@ -0,0 +1,46 @@
+package entities
+
+// Branding is the company identity a manager's team prints on work orders and timesheets
+type Branding struct {
+	CompanyName string `json:"company_name"`
+	Address     string `json:"address"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	AccentColor string `json:"accent_color"`
+	Footer      string `json:"footer"`
+	HasLogo     bool   `json:"has_logo"`
+}
+
+// TimeEntry is a worklog as printed on work orders and timesheets
+type TimeEntry struct {
+	StartedAt   string `json:"started_at"`
+	EndedAt     string `json:"ended_at,omitempty"`
+	Minutes     int64  `json:"minutes"`
+	Notes       string `json:"notes"`
+	Technician  string `json:"technician"`
+	TaskID      string `json:"task_id"`
+	TaskSummary string `json:"task_summary"`
+}
+
+// WorkOrder is a task with everything its work order shows
+type WorkOrder struct {
+	Task            Task         `json:"task"`
+	Technician      string       `json:"technician"`
+	TechnicianEmail string       `json:"technician_email"`
+	Asset           string       `json:"asset"`
+	Location        string       `json:"location"`
+	Worklogs        []TimeEntry  `json:"worklogs"`
+	Attachments     []Attachment `json:"attachments"`
+	GeneratedAt     string       `json:"generated_at"`
+}
+
+// Timesheet is the time a technician logged between two dates
+type Timesheet struct {
+	TechnicianID string      `json:"technician_id"`
+	Technician   string      `json:"technician"`
+	Email        string      `json:"email"`
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	Entries      []TimeEntry `json:"entries"`
+	GeneratedAt  string      `json:"generated_at"`
+}
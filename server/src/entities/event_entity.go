@@ -0,0 +1,9 @@
+package entities
+
+// TaskEvent is sent on the event stream when a task is created, updated or deleted
+type TaskEvent struct {
+	Type       string `json:"type"`
+	Task       Task   `json:"task"`
+	ActorID    string `json:"actor_id"`
+	OccurredAt string `json:"occurred_at"`
+}
@@ -0,0 +1,21 @@
+package entities
+
+// ImportReport summarises a CSV import. Dry runs validate every row without saving anything.
+type ImportReport struct {
+	ID       string           `json:"id"`
+	Kind     string           `json:"kind"`
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Rejected int              `json:"rejected"`
+	Errors   []ImportRowError `json:"errors"`
+	// ErrorsCSV holds the rejected rows with an added error column
+	ErrorsCSV []byte `json:"-"`
+}
+
+// ImportRowError explains why a row was rejected. Line is the line number in the CSV file.
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,39 @@
+package entities
+
+// LiveViewer is someone who has a task open
+type LiveViewer struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+// TaskComment is a comment posted on a task's live channel
+type TaskComment struct {
+	ID        string `json:"id"`
+	TaskID    string `json:"task_id"`
+	UserID    string `json:"user_id"`
+	Author    string `json:"author"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at"`
+}
+
+// LiveMessage is sent to the clients connected to a task's live channel. Type is snapshot when they
+// connect, then task.created, task.updated, task.deleted, comment, presence or error.
+type LiveMessage struct {
+	Type     string                 `json:"type"`
+	Task     *Task                  `json:"task,omitempty"`
+	Changes  map[string]FieldChange `json:"changes,omitempty"`
+	ActorID  string                 `json:"actor_id,omitempty"`
+	Viewers  []LiveViewer           `json:"viewers,omitempty"`
+	Comments []TaskComment          `json:"comments,omitempty"`
+	Comment  *TaskComment           `json:"comment,omitempty"`
+	CanEdit  bool                   `json:"can_edit,omitempty"`
+	Message  string                 `json:"message,omitempty"`
+}
+
+// LiveRequest is sent by a client on a task's live channel: checklist ticks an item and comment posts a comment
+type LiveRequest struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Done  bool   `json:"done"`
+	Text  string `json:"text"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+// TaskRevision is a numbered snapshot of a task taken each time it changes
+type TaskRevision struct {
+	Revision     int                    `json:"revision"`
+	Source       string                 `json:"source"`
+	RevertedFrom int                    `json:"reverted_from,omitempty"`
+	ChangedBy    string                 `json:"changed_by"`
+	CreatedAt    string                 `json:"created_at"`
+	Changes      map[string]FieldChange `json:"changes"`
+	Task         *Task                  `json:"task,omitempty"`
+}
+
+// FieldChange holds the previous and new value of a changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+// SearchResult is a task matching a search together with its relevance. Highlight is the summary as
+// HTML-escaped text with the matched words wrapped in <mark> tags.
+type SearchResult struct {
+	Task      Task    `json:"task"`
+	Score     float64 `json:"score"`
+	Highlight string  `json:"highlight"`
+}
+
+// SearchResults is one page of search results
+type SearchResults struct {
+	Query   string         `json:"query"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
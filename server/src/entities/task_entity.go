@@ -1,8 +1,27 @@
 package entities
 
 type Task struct {
-	ID      string `json:"id"`
-	Summary string `json:"summary"`
-	Date    string `json:"date"`
-	UserID  string `json:"user_id"`
+	ID               string                 `json:"id"`
+	Summary          string                 `json:"summary"`
+	Date             string                 `json:"date"`
+	UserID           string                 `json:"user_id"`
+	AssetID          string                 `json:"asset_id,omitempty"`
+	LocationID       string                 `json:"location_id,omitempty"`
+	Status           string                 `json:"status"`
+	DueDate          string                 `json:"due_date,omitempty"`
+	CompletedAt      string                 `json:"completed_at,omitempty"`
+	Priority         string                 `json:"priority"`
+	EstimatedMinutes int                    `json:"estimated_minutes,omitempty"`
+	TemplateID       string                 `json:"template_id,omitempty"`
+	DeletedAt        string                 `json:"deleted_at,omitempty"`
+	DeletedBy        string                 `json:"deleted_by,omitempty"`
+	Version          int                    `json:"version"`
+	Tags             []string               `json:"tags"`
+	CustomFields     map[string]interface{} `json:"custom_fields"`
+	Checklist        []ChecklistItem        `json:"checklist"`
+}
+
+type ChecklistItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
 }
@@ -0,0 +1,24 @@
+package entities
+
+type TaskTemplate struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Summary          string   `json:"summary"`
+	Checklist        []string `json:"checklist"`
+	Tags             []string `json:"tags"`
+	Priority         string   `json:"priority"`
+	EstimatedMinutes int      `json:"estimated_minutes,omitempty"`
+	CreatedAt        string   `json:"created_at"`
+}
+
+// TemplateStats summarises how tasks created from a template turned out
+type TemplateStats struct {
+	TemplateID        string  `json:"template_id"`
+	Name              string  `json:"name"`
+	Uses              int     `json:"uses"`
+	Completed         int     `json:"completed"`
+	SummaryOverridden int     `json:"summary_overridden"`
+	EstimatedMinutes  int     `json:"estimated_minutes,omitempty"`
+	AvgLoggedMinutes  float64 `json:"avg_logged_minutes"`
+	LastUsedAt        string  `json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,40 @@
+package entities
+
+import "encoding/json"
+
+// SavedView is a named set of task listing filters. Shared views are visible to the owner's team.
+type SavedView struct {
+	ID        string      `json:"id"`
+	OwnerID   string      `json:"owner_id"`
+	Name      string      `json:"name"`
+	Filters   ViewFilters `json:"filters"`
+	Shared    bool        `json:"shared"`
+	IsDefault bool        `json:"is_default"`
+	CreatedAt string      `json:"created_at"`
+}
+
+// ViewFilters holds listing query parameters. Each value may be given as a string or a list of strings.
+type ViewFilters map[string][]string
+
+func (vf *ViewFilters) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	filters := make(ViewFilters, len(raw))
+	for key, value := range raw {
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			filters[key] = []string{single}
+			continue
+		}
+		var list []string
+		if err := json.Unmarshal(value, &list); err != nil {
+			return err
+		}
+		filters[key] = list
+	}
+	*vf = filters
+	return nil
+}
@@ -0,0 +1,44 @@
+package entities
+
+import "encoding/json"
+
+// Webhook is a URL that receives the task events of a manager's team. The secret signs every
+// delivery and is only shown when the webhook is created or its secret rotated.
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook. Status is pending until the
+// receiver answers with a 2xx status, then delivered, or dead once every retry has failed.
+// Listings leave out the payload and the attempts.
+type WebhookDelivery struct {
+	ID            string           `json:"id"`
+	WebhookID     string           `json:"webhook_id"`
+	EventID       int64            `json:"event_id"`
+	EventType     string           `json:"event_type"`
+	TaskID        string           `json:"task_id"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt string           `json:"next_attempt_at,omitempty"`
+	ResponseCode  int              `json:"response_code,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	DeliveredAt   string           `json:"delivered_at,omitempty"`
+	CreatedAt     string           `json:"created_at"`
+	Payload       json.RawMessage  `json:"payload,omitempty"`
+	AttemptLog    []WebhookAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookAttempt is one try at a delivery. ResponseCode is 0 when the receiver could not be reached.
+type WebhookAttempt struct {
+	AttemptedAt  string `json:"attempted_at"`
+	ResponseCode int    `json:"response_code,omitempty"`
+	Response     string `json:"response,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMs   int64  `json:"duration_ms"`
+}
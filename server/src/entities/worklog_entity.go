@@ -0,0 +1,20 @@
+package entities
+
+type Worklog struct {
+	ID              string `json:"id"`
+	TaskID          string `json:"task_id"`
+	UserID          string `json:"user_id"`
+	StartedAt       string `json:"started_at"`
+	EndedAt         string `json:"ended_at,omitempty"`
+	DurationMinutes int64  `json:"duration_minutes"`
+	Notes           string `json:"notes"`
+	Running         bool   `json:"running"`
+}
+
+type WorklogSummary struct {
+	TaskID       string  `json:"task_id,omitempty"`
+	TechnicianID string  `json:"technician_id,omitempty"`
+	Day          string  `json:"day,omitempty"`
+	Hours        float64 `json:"hours"`
+	Entries      int     `json:"entries"`
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// GetAnalyticsTopHandler defines the route handler function for ranking assets and tags by task count
+func GetAnalyticsTopHandler(w http.ResponseWriter, r *http.Request) {
+	analyticsHandler := models.AnalyticsHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		analyticsHandler.GetTop(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetAnalyticsMetricHandler defines the route handler function for a team metric as time series
+func GetAnalyticsMetricHandler(w http.ResponseWriter, r *http.Request) {
+	analyticsHandler := models.AnalyticsHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		analyticsHandler.GetMetric(w, r, mux.Vars(r)["metric"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
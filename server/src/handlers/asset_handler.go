@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// CreateAssetHandler defines the route handler function for registering an asset
+func CreateAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetHandler := models.AssetHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHandler.CreateAsset(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetAssetsHandler defines the route handler function for listing assets
+func GetAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	assetHandler := models.AssetHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHandler.GetAssets(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetAssetHandler defines the route handler function for retrieving a single asset
+func GetAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetHandler := models.AssetHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHandler.GetAsset(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateAssetHandler defines the route handler function for updating an asset
+func UpdateAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetHandler := models.AssetHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHandler.UpdateAsset(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteAssetHandler defines the route handler function for deleting an asset
+func DeleteAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetHandler := models.AssetHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHandler.DeleteAsset(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetAssetHistoryHandler defines the route handler function for the maintenance history of an asset
+func GetAssetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	assetHandler := models.AssetHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assetHandler.GetAssetHistory(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// UploadAttachmentHandler defines the route handler function for attaching a file to a task
+func UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachmentHandler := models.AttachmentHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attachmentHandler.UploadAttachment(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// ListAttachmentsHandler defines the route handler function for listing the attachments of a task
+func ListAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	attachmentHandler := models.AttachmentHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attachmentHandler.ListAttachments(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DownloadAttachmentHandler defines the route handler function for downloading an attachment
+func DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachmentHandler := models.AttachmentHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		attachmentHandler.DownloadAttachment(w, r, vars["id"], vars["attachment_id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
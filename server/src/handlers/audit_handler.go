@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// GetAuditLogHandler defines the route handler function for querying the audit log
+func GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	auditHandler := models.AuditHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditHandler.GetAuditLog(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// ExportAuditLogHandler defines the route handler function for exporting the audit log as CSV
+func ExportAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	auditHandler := models.AuditHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auditHandler.ExportAuditLog(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// BulkTasksHandler defines the route handler function for applying several task operations at once
+func BulkTasksHandler(w http.ResponseWriter, r *http.Request) {
+	bulkHandler := models.BulkHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bulkHandler.BulkUpdate(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
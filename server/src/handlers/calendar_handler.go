@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// CreateCalendarFeedHandler defines the route handler function for issuing a new calendar feed token
+func CreateCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	calendarHandler := models.CalendarHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calendarHandler.CreateFeed(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteCalendarFeedHandler defines the route handler function for revoking the calendar feed
+func DeleteCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	calendarHandler := models.CalendarHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calendarHandler.DeleteFeed(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetCalendarFeedHandler defines the route handler function for the iCalendar feed. The feed token
+// authenticates the request, as calendar clients cannot send an Authorization header.
+func GetCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	calendarHandler := models.CalendarHandler(db)
+	calendarHandler.GetFeed(w, r, mux.Vars(r)["token"])
+}
@@ -2,34 +2,70 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/segmentio/kafka-go"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
 )
 
-// HandleKafkaMessages starts consuming Kafka messages.
+var kafkaProducer *services.KafkaProducer // Declare a global variable for the producer shared by everything this server sends to Kafka
+
+// InitKafkaProducer sets up the producer writing to KAFKA_PRODUCER_TOPIC, which defaults to the topic
+// the consumer reads so that this server's own messages go through the same handling
+func InitKafkaProducer(brokers []string) error {
+	topic := config.Getenv("KAFKA_PRODUCER_TOPIC", config.Getenv("KAFKA_CONSUMER_TOPIC", "task-events"))
+	producer, err := services.NewKafkaProducer(brokers, topic)
+	if err != nil {
+		return err
+	}
+	kafkaProducer = producer
+	return nil
+}
+
+// HandleKafkaMessages starts consuming Kafka messages. Each message is committed once it has been
+// handled or, after KAFKA_MAX_ATTEMPTS failed tries, written to the dead-letter topic with the reason.
 func HandleKafkaMessages(brokers []string) {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: brokers,
-		GroupID: "task-app",
-		Topic:   "task-events",
-	})
+	consumer := services.NewKafkaConsumer(
+		brokers,
+		config.Getenv("KAFKA_CONSUMER_TOPIC", "task-events"),
+		"task-app",
+		config.Getenv("KAFKA_DLQ_TOPIC", "task-events-dlq"),
+		handleKafkaMessage,
+		int(config.GetenvInt("KAFKA_MAX_ATTEMPTS", 5)),
+		time.Duration(config.GetenvInt("KAFKA_RETRY_MS", 500))*time.Millisecond,
+	)
 
 	defer func() {
-		err := reader.Close()
+		err := consumer.Close()
 		if err != nil {
-			log.Println("Failed to close Kafka reader:", err)
+			log.Println("Failed to close Kafka consumer:", err)
 		}
 	}()
 
-	for {
-		msg, err := reader.ReadMessage(context.Background())
-		if err != nil {
-			log.Println("Error reading Kafka message:", err)
-			continue
-		}
+	err := consumer.Run(context.Background())
+	log.Println("Kafka consumer stopped:", err)
+}
 
-		// Handle the Kafka message here
-		log.Printf("Received Kafka message: %s\n", string(msg.Value))
+// handleKafkaMessage processes a message from the task events topic. Messages must be JSON objects
+// with a type; anything else can never be handled and goes straight to the dead-letter topic.
+func handleKafkaMessage(_ context.Context, msg kafka.Message) error {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(msg.Value, &envelope)
+	if err != nil {
+		return services.Permanent(err)
 	}
+	if envelope.Type == "" {
+		return services.Permanent(errors.New("message has no type"))
+	}
+
+	// Handle the Kafka message here
+	log.Printf("Received Kafka message: %s\n", string(msg.Value))
+	return nil
 }
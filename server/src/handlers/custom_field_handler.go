@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// CreateCustomFieldHandler defines the route handler function for defining a custom task field
+func CreateCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	customFieldHandler := models.CustomFieldHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customFieldHandler.CreateCustomField(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetCustomFieldsHandler defines the route handler function for listing custom task fields
+func GetCustomFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	customFieldHandler := models.CustomFieldHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customFieldHandler.GetCustomFields(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateCustomFieldHandler defines the route handler function for updating a custom task field
+func UpdateCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	customFieldHandler := models.CustomFieldHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customFieldHandler.UpdateCustomField(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteCustomFieldHandler defines the route handler function for deleting a custom task field
+func DeleteCustomFieldHandler(w http.ResponseWriter, r *http.Request) {
+	customFieldHandler := models.CustomFieldHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customFieldHandler.DeleteCustomField(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
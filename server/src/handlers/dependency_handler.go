@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// AddDependencyHandler defines the route handler function for marking a task as blocked by another
+func AddDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	dependencyHandler := models.DependencyHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dependencyHandler.AddDependency(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RemoveDependencyHandler defines the route handler function for removing a blocking relationship
+func RemoveDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	dependencyHandler := models.DependencyHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		dependencyHandler.RemoveDependency(w, r, vars["id"], vars["blocker_id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetDependencyGraphHandler defines the route handler function for retrieving the dependency graph of a task
+func GetDependencyGraphHandler(w http.ResponseWriter, r *http.Request) {
+	dependencyHandler := models.DependencyHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dependencyHandler.GetDependencyGraph(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// WatchOverdueBlockers periodically warns managers about overdue tasks that block other work, on the
+// task event stream and on Kafka. A warning is recorded once it is on the stream, so Kafka being down
+// only costs the Kafka copy and does not repeat the warning on every check.
+func WatchOverdueBlockers(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		blockers, err := models.FindOverdueBlockers(db)
+		if err != nil {
+			log.Println("Failed to check overdue blockers:", err)
+			continue
+		}
+
+		for _, blocker := range blockers {
+			models.PublishOverdueBlocker(taskEvents, blocker)
+
+			message, err := json.Marshal(struct {
+				Type string `json:"type"`
+				entities.OverdueBlocker
+			}{"overdue_blocker", blocker})
+			if err == nil {
+				err = kafkaProducer.SendMessage(message)
+			}
+			if err != nil {
+				log.Println("Failed to send overdue blocker warning to Kafka:", err)
+			}
+
+			err = models.RecordOverdueBlockerWarning(db, blocker)
+			if err != nil {
+				log.Println("Failed to record overdue blocker warning:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+var mailer services.Mailer // Declare a global variable for the mailer, nil when email is turned off
+
+// InitMailer initializes the mailer configured in the environment
+func InitMailer() error {
+	var err error
+	mailer, err = services.NewMailerFromEnv()
+	return err
+}
+
+// GetDigestSettingsHandler defines the route handler function for reading the digest settings
+func GetDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	digestHandler := models.DigestHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digestHandler.GetDigestSettings(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateDigestSettingsHandler defines the route handler function for changing the digest settings
+func UpdateDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	digestHandler := models.DigestHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digestHandler.UpdateDigestSettings(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetDigestsHandler defines the route handler function for listing stored digests
+func GetDigestsHandler(w http.ResponseWriter, r *http.Request) {
+	digestHandler := models.DigestHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digestHandler.GetDigests(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetDigestHandler defines the route handler function for reading a stored digest
+func GetDigestHandler(w http.ResponseWriter, r *http.Request) {
+	digestHandler := models.DigestHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digestHandler.GetDigest(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// SendDigests periodically compiles and delivers the digests that are due
+func SendDigests(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		sent, err := models.SendDueDigests(context.Background(), db, mailer, time.Now())
+		if err != nil {
+			log.Println("Failed to send digests:", err)
+		}
+		if sent > 0 {
+			log.Printf("Sent %d digests\n", sent)
+		}
+	}
+}
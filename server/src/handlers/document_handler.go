@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// GetWorkOrderHandler defines the route handler function for printing a task's work order
+func GetWorkOrderHandler(w http.ResponseWriter, r *http.Request) {
+	documentHandler := models.DocumentHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		documentHandler.GetWorkOrder(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetTimesheetHandler defines the route handler function for printing a technician's timesheet
+func GetTimesheetHandler(w http.ResponseWriter, r *http.Request) {
+	documentHandler := models.DocumentHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		documentHandler.GetTimesheet(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetBrandingHandler defines the route handler function for retrieving the team's branding
+func GetBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	brandingHandler := models.BrandingHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		brandingHandler.GetBranding(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateBrandingHandler defines the route handler function for changing the team's branding
+func UpdateBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	brandingHandler := models.BrandingHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		brandingHandler.UpdateBranding(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UploadBrandingLogoHandler defines the route handler function for replacing the team's logo
+func UploadBrandingLogoHandler(w http.ResponseWriter, r *http.Request) {
+	brandingHandler := models.BrandingHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		brandingHandler.UploadLogo(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteBrandingLogoHandler defines the route handler function for removing the team's logo
+func DeleteBrandingLogoHandler(w http.ResponseWriter, r *http.Request) {
+	brandingHandler := models.BrandingHandler(db, blobStore)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		brandingHandler.DeleteLogo(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+var taskEvents *services.EventBus // Declare a global variable for the in-process bus of task events
+var liveHub *models.LiveHub       // Declare a global variable for the live task channels of this instance
+
+// InitEventBus sets up the task event bus, keeping EVENT_BUFFER_SIZE events for streams that reconnect,
+// and connects it to the other instances through the relay selected by EVENT_RELAY
+func InitEventBus() error {
+	taskEvents = services.NewEventBus(int(config.GetenvInt("EVENT_BUFFER_SIZE", 1000)))
+	liveHub = models.NewLiveHub(db)
+	relay, err := services.NewEventRelayFromEnv()
+	if err != nil {
+		return err
+	}
+	if relay != nil {
+		taskEvents.Relay(relay)
+	}
+	return nil
+}
+
+// StreamEventsHandler defines the route handler function for the Server-Sent Events stream of task changes
+func StreamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	eventHandler := models.EventHandler(db, taskEvents, time.Duration(config.GetenvInt("EVENT_HEARTBEAT_SECONDS", 15))*time.Second)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventHandler.Stream(w, r)
+	})
+
+	tokenFromQuery(r)
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// tokenFromQuery lets the access_token query parameter stand in for the Authorization header, which
+// browsers cannot set on an EventSource or a WebSocket
+func tokenFromQuery(r *http.Request) {
+	if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+		r.Header.Set("Authorization", token)
+	}
+}
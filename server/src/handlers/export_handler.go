@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// ExportTasksHandler defines the route handler function for exporting tasks as CSV or XLSX
+func ExportTasksHandler(w http.ResponseWriter, r *http.Request) {
+	exportHandler := models.ExportHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exportHandler.ExportTasks(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
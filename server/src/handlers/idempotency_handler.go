@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// PurgeIdempotencyKeys periodically removes idempotency keys that are past their window
+func PurgeIdempotencyKeys(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		_, err := models.PurgeIdempotencyKeys(db)
+		if err != nil {
+			log.Println("Failed to purge idempotency keys:", err)
+		}
+	}
+}
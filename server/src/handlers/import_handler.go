@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// ImportHandler defines the route handler function for importing tasks or users from CSV
+func ImportHandler(w http.ResponseWriter, r *http.Request) {
+	importHandler := models.ImportHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		importHandler.Import(w, r, mux.Vars(r)["kind"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetImportErrorsHandler defines the route handler function for downloading the rejected rows of an import
+func GetImportErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	importHandler := models.ImportHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		importHandler.GetImportErrors(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
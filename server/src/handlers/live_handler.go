@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// LiveTaskHandler defines the route handler function for the live WebSocket channel of a task
+func LiveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	liveHandler := models.LiveHandler(db, taskEvents, liveHub)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		liveHandler.Connect(w, r, mux.Vars(r)["id"])
+	})
+
+	tokenFromQuery(r)
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RunLiveHub delivers task events to the live channels and refreshes who is viewing each task
+func RunLiveHub(interval time.Duration) {
+	liveHub.Run(taskEvents, interval)
+}
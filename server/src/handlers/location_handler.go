@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// CreateLocationHandler defines the route handler function for creating a location
+func CreateLocationHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.CreateLocation(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetLocationsHandler defines the route handler function for listing locations
+func GetLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.GetLocations(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetLocationHandler defines the route handler function for retrieving a single location
+func GetLocationHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.GetLocation(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateLocationHandler defines the route handler function for updating a location
+func UpdateLocationHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.UpdateLocation(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteLocationHandler defines the route handler function for deleting a location
+func DeleteLocationHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.DeleteLocation(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetManagerSitesHandler defines the route handler function for listing the sites a manager is responsible for
+func GetManagerSitesHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.GetManagerSites(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// SetManagerSitesHandler defines the route handler function for restricting a manager to specific sites
+func SetManagerSitesHandler(w http.ResponseWriter, r *http.Request) {
+	locationHandler := models.LocationHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locationHandler.SetManagerSites(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
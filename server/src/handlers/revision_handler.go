@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// GetRevisionsHandler defines the route handler function for listing the revisions of a task
+func GetRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	revisionHandler := models.RevisionHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		revisionHandler.GetRevisions(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetRevisionHandler defines the route handler function for retrieving a task at a given revision
+func GetRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	revisionHandler := models.RevisionHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		revisionHandler.GetRevision(w, r, vars["id"], vars["revision"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RevertTaskHandler defines the route handler function for restoring an earlier revision of a task
+func RevertTaskHandler(w http.ResponseWriter, r *http.Request) {
+	revisionHandler := models.RevisionHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		revisionHandler.RevertTask(w, r, vars["id"], vars["revision"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
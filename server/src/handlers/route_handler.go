@@ -15,14 +15,97 @@ func RouteHandler() {
 
 	// Create a new router
 	router := mux.NewRouter()
+	router.Use(requestID)
 
 	// Define the routes
 	router.HandleFunc("/", homeHandler)
 	router.HandleFunc("/login", LoginHandler).Methods(http.MethodPost)
 	router.HandleFunc("/users", CreateUserHandler).Methods(http.MethodPost)
 	router.HandleFunc("/tasks", CreateTaskHandler).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/bulk", BulkTasksHandler).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/export", ExportTasksHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}", GetTaskHandler).Methods(http.MethodGet)
 	router.HandleFunc("/tasks/{id}", UpdateTaskHandler).Methods(http.MethodPatch)
 	router.HandleFunc("/tasks/{id}", DeleteTaskHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks/{id}/attachments", UploadAttachmentHandler).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{id}/attachments", ListAttachmentsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/attachments/{attachment_id}", DownloadAttachmentHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/worklogs", CreateWorklogHandler).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{id}/worklogs", ListWorklogsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/timer/start", StartTimerHandler).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{id}/timer/stop", StopTimerHandler).Methods(http.MethodPost)
+	router.HandleFunc("/worklogs/summary", GetWorklogSummaryHandler).Methods(http.MethodGet)
+	router.HandleFunc("/assets", CreateAssetHandler).Methods(http.MethodPost)
+	router.HandleFunc("/assets", GetAssetsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/assets/{id}", GetAssetHandler).Methods(http.MethodGet)
+	router.HandleFunc("/assets/{id}", UpdateAssetHandler).Methods(http.MethodPatch)
+	router.HandleFunc("/assets/{id}", DeleteAssetHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/assets/{id}/history", GetAssetHistoryHandler).Methods(http.MethodGet)
+	router.HandleFunc("/locations", CreateLocationHandler).Methods(http.MethodPost)
+	router.HandleFunc("/locations", GetLocationsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/locations/{id}", GetLocationHandler).Methods(http.MethodGet)
+	router.HandleFunc("/locations/{id}", UpdateLocationHandler).Methods(http.MethodPatch)
+	router.HandleFunc("/locations/{id}", DeleteLocationHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/users/{id}/sites", GetManagerSitesHandler).Methods(http.MethodGet)
+	router.HandleFunc("/users/{id}/sites", SetManagerSitesHandler).Methods(http.MethodPut)
+	router.HandleFunc("/tags", GetTagsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/custom-fields", CreateCustomFieldHandler).Methods(http.MethodPost)
+	router.HandleFunc("/custom-fields", GetCustomFieldsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/custom-fields/{id}", UpdateCustomFieldHandler).Methods(http.MethodPatch)
+	router.HandleFunc("/custom-fields/{id}", DeleteCustomFieldHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks/{id}/dependencies", AddDependencyHandler).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{id}/dependencies", GetDependencyGraphHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/dependencies/{blocker_id}", RemoveDependencyHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/templates", CreateTemplateHandler).Methods(http.MethodPost)
+	router.HandleFunc("/templates", GetTemplatesHandler).Methods(http.MethodGet)
+	router.HandleFunc("/templates/stats", GetTemplateStatsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/templates/{id}", GetTemplateHandler).Methods(http.MethodGet)
+	router.HandleFunc("/templates/{id}", UpdateTemplateHandler).Methods(http.MethodPatch)
+	router.HandleFunc("/templates/{id}", DeleteTemplateHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/trash", GetTrashHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/restore", RestoreTaskHandler).Methods(http.MethodPost)
+	router.HandleFunc("/audit", GetAuditLogHandler).Methods(http.MethodGet)
+	router.HandleFunc("/audit/export", ExportAuditLogHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/revisions", GetRevisionsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/revisions/{revision}", GetRevisionHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/revisions/{revision}/revert", RevertTaskHandler).Methods(http.MethodPost)
+	router.HandleFunc("/search", SearchHandler).Methods(http.MethodGet)
+	router.HandleFunc("/views", CreateViewHandler).Methods(http.MethodPost)
+	router.HandleFunc("/views", GetViewsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/views/{id}", GetViewHandler).Methods(http.MethodGet)
+	router.HandleFunc("/views/{id}", UpdateViewHandler).Methods(http.MethodPatch)
+	router.HandleFunc("/views/{id}", DeleteViewHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/views/{id}/default", SetDefaultViewHandler).Methods(http.MethodPut)
+	router.HandleFunc("/views/{id}/default", ClearDefaultViewHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/views/{id}/tasks", GetViewTasksHandler).Methods(http.MethodGet)
+	router.HandleFunc("/imports/{kind}", ImportHandler).Methods(http.MethodPost)
+	router.HandleFunc("/imports/{id}/errors", GetImportErrorsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/calendar/feed", CreateCalendarFeedHandler).Methods(http.MethodPost)
+	router.HandleFunc("/calendar/feed", DeleteCalendarFeedHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/calendar/{token}.ics", GetCalendarFeedHandler).Methods(http.MethodGet, http.MethodHead)
+	router.HandleFunc("/digests/settings", GetDigestSettingsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/digests/settings", UpdateDigestSettingsHandler).Methods(http.MethodPut)
+	router.HandleFunc("/digests", GetDigestsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/digests/{id}", GetDigestHandler).Methods(http.MethodGet)
+	router.HandleFunc("/analytics/top", GetAnalyticsTopHandler).Methods(http.MethodGet)
+	router.HandleFunc("/analytics/{metric}", GetAnalyticsMetricHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/work-order.pdf", GetWorkOrderHandler).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{id}/live", LiveTaskHandler).Methods(http.MethodGet)
+	router.HandleFunc("/users/{id}/timesheet.pdf", GetTimesheetHandler).Methods(http.MethodGet)
+	router.HandleFunc("/branding", GetBrandingHandler).Methods(http.MethodGet)
+	router.HandleFunc("/branding", UpdateBrandingHandler).Methods(http.MethodPut)
+	router.HandleFunc("/branding/logo", UploadBrandingLogoHandler).Methods(http.MethodPut)
+	router.HandleFunc("/branding/logo", DeleteBrandingLogoHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/events/stream", StreamEventsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks", CreateWebhookHandler).Methods(http.MethodPost)
+	router.HandleFunc("/webhooks", GetWebhooksHandler).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}", GetWebhookHandler).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}", UpdateWebhookHandler).Methods(http.MethodPut)
+	router.HandleFunc("/webhooks/{id}", DeleteWebhookHandler).Methods(http.MethodDelete)
+	router.HandleFunc("/webhooks/{id}/secret", RotateWebhookSecretHandler).Methods(http.MethodPost)
+	router.HandleFunc("/webhooks/{id}/deliveries", GetWebhookDeliveriesHandler).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}", GetWebhookDeliveryHandler).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}/redeliver", RedeliverWebhookHandler).Methods(http.MethodPost)
 	router.HandleFunc("/users", GetAllUsersAndAllTasksHandler).Methods(http.MethodGet)
 	router.HandleFunc("/users/{id}/tasks", GetAllTasksByUserHandler).Methods(http.MethodGet)
 
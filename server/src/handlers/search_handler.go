@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+var searchIndex *services.SearchIndex // Declare a global variable for the embedded search index, nil when MySQL is used
+
+// InitSearchIndex sets up the search backend selected by SEARCH_BACKEND
+func InitSearchIndex() error {
+	switch backend := config.Getenv("SEARCH_BACKEND", "mysql"); backend {
+	case "mysql":
+		searchIndex = nil
+	case "memory":
+		searchIndex = services.NewSearchIndex()
+	default:
+		return fmt.Errorf("unknown search backend %q", backend)
+	}
+	return nil
+}
+
+// SearchHandler defines the route handler function for searching tasks
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	searchHandler := models.SearchHandler(db, searchIndex)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searchHandler.Search(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RefreshSearchIndex periodically rebuilds the embedded search index from the database
+func RefreshSearchIndex(interval time.Duration) {
+	if searchIndex == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		documents, err := models.LoadSearchDocuments(db)
+		if err != nil {
+			log.Println("Failed to refresh search index:", err)
+			continue
+		}
+		searchIndex.Rebuild(documents)
+	}
+}
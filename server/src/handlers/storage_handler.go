@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+var blobStore services.BlobStore // Declare a global variable for the attachment storage backend
+
+// InitBlobStore initializes the blob store configured in the environment
+func InitBlobStore() error {
+	var err error
+	blobStore, err = services.NewBlobStoreFromEnv()
+	return err
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// GetTagsHandler defines the route handler function for tag autocompletion
+func GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tagHandler := models.TagHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tagHandler.GetTags(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
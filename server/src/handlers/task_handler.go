@@ -9,16 +9,26 @@ import (
 
 // CreateTaskHandler defines the route handler function for creating a task
 func CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
-	taskHandler := models.TaskHandler(db)
+	taskHandler := models.TaskHandler(db, taskEvents)
+	taskHandler.Producer = kafkaProducer
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		taskHandler.CreateTask(w, r)
 	})
 	authenticate(handler).ServeHTTP(w, r)
 }
 
+// GetTaskHandler defines the route handler function for retrieving a single task
+func GetTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskHandler := models.TaskHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		taskHandler.GetTask(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
 // UpdateTaskHandler defines the route handler function for updating a task
 func UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
-	taskHandler := models.TaskHandler(db)
+	taskHandler := models.TaskHandler(db, taskEvents)
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		taskHandler.UpdateTask(w, r, mux.Vars(r)["id"])
 	})
@@ -27,7 +37,7 @@ func UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteTaskHandler defines the route handler function for deleting a task
 func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
-	taskHandler := models.TaskHandler(db)
+	taskHandler := models.TaskHandler(db, taskEvents)
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		taskHandler.DeleteTask(w, r, mux.Vars(r)["id"])
 	})
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// CreateTemplateHandler defines the route handler function for creating a task template
+func CreateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateHandler := models.TemplateHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		templateHandler.CreateTemplate(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetTemplatesHandler defines the route handler function for listing task templates
+func GetTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	templateHandler := models.TemplateHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		templateHandler.GetTemplates(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetTemplateHandler defines the route handler function for retrieving a task template
+func GetTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateHandler := models.TemplateHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		templateHandler.GetTemplate(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateTemplateHandler defines the route handler function for updating a task template
+func UpdateTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateHandler := models.TemplateHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		templateHandler.UpdateTemplate(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteTemplateHandler defines the route handler function for deleting a task template
+func DeleteTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	templateHandler := models.TemplateHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		templateHandler.DeleteTemplate(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetTemplateStatsHandler defines the route handler function for template usage statistics
+func GetTemplateStatsHandler(w http.ResponseWriter, r *http.Request) {
+	templateHandler := models.TemplateHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		templateHandler.GetTemplateStats(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
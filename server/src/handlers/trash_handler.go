@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// GetTrashHandler defines the route handler function for listing trashed tasks
+func GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	trashHandler := models.TrashHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trashHandler.GetTrash(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RestoreTaskHandler defines the route handler function for restoring a trashed task
+func RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	trashHandler := models.TrashHandler(db, taskEvents)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trashHandler.RestoreTask(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// PurgeTrash periodically deletes tasks that have been in the trash for longer than TRASH_RETENTION_DAYS
+func PurgeTrash(interval time.Duration) {
+	retentionDays := config.GetenvInt("TRASH_RETENTION_DAYS", 30)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		purged, err := models.PurgeTrash(context.Background(), db, blobStore, retentionDays)
+		if err != nil {
+			log.Println("Failed to purge trash:", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d tasks from the trash\n", purged)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/gorilla/mux"
+)
+
+// CreateViewHandler defines the route handler function for saving a view
+func CreateViewHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.CreateView(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetViewsHandler defines the route handler function for listing the saved views
+func GetViewsHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.GetViews(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetViewHandler defines the route handler function for retrieving a saved view
+func GetViewHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.GetView(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateViewHandler defines the route handler function for updating a saved view
+func UpdateViewHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.UpdateView(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteViewHandler defines the route handler function for deleting a saved view
+func DeleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.DeleteView(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// SetDefaultViewHandler defines the route handler function for making a view the user's default
+func SetDefaultViewHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.SetDefaultView(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// ClearDefaultViewHandler defines the route handler function for removing a view as the user's default
+func ClearDefaultViewHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.ClearDefaultView(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetViewTasksHandler defines the route handler function for running a saved view
+func GetViewTasksHandler(w http.ResponseWriter, r *http.Request) {
+	viewHandler := models.ViewHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewHandler.GetViewTasks(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
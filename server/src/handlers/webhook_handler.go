@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// CreateWebhookHandler defines the route handler function for registering a webhook
+func CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.CreateWebhook(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetWebhooksHandler defines the route handler function for listing webhooks
+func GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.GetWebhooks(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetWebhookHandler defines the route handler function for reading a webhook
+func GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.GetWebhook(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// UpdateWebhookHandler defines the route handler function for changing a webhook
+func UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.UpdateWebhook(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// DeleteWebhookHandler defines the route handler function for deleting a webhook
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.DeleteWebhook(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RotateWebhookSecretHandler defines the route handler function for rotating a webhook's signing secret
+func RotateWebhookSecretHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.RotateWebhookSecret(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetWebhookDeliveriesHandler defines the route handler function for listing a webhook's deliveries
+func GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.GetWebhookDeliveries(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetWebhookDeliveryHandler defines the route handler function for reading a webhook delivery
+func GetWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.GetWebhookDelivery(w, r, mux.Vars(r)["id"], mux.Vars(r)["deliveryID"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// RedeliverWebhookHandler defines the route handler function for redelivering a webhook delivery
+func RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookHandler := models.WebhookHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookHandler.RedeliverWebhook(w, r, mux.Vars(r)["id"], mux.Vars(r)["deliveryID"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// QueueWebhooks records a delivery for each webhook that should receive a task event
+func QueueWebhooks() {
+	models.QueueWebhookDeliveries(db, taskEvents)
+}
+
+// DeliverWebhooks periodically sends the webhook deliveries that are due, giving receivers
+// WEBHOOK_TIMEOUT_SECONDS to answer and WEBHOOK_MAX_ATTEMPTS tries before a delivery is dead. Receivers
+// on internal addresses are only reached within WEBHOOK_ALLOWED_NETWORKS.
+func DeliverWebhooks(interval time.Duration) {
+	sender := services.NewWebhookSender(time.Duration(config.GetenvInt("WEBHOOK_TIMEOUT_SECONDS", 10))*time.Second,
+		config.GetenvNetworks("WEBHOOK_ALLOWED_NETWORKS"))
+	maxAttempts := int(config.GetenvInt("WEBHOOK_MAX_ATTEMPTS", 8))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		delivered, err := models.DeliverDueWebhooks(context.Background(), db, sender, time.Now(), maxAttempts)
+		if err != nil {
+			log.Println("Failed to deliver webhooks:", err)
+		}
+		if delivered > 0 {
+			log.Printf("Delivered %d webhooks\n", delivered)
+		}
+	}
+}
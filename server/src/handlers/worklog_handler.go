@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// CreateWorklogHandler defines the route handler function for logging time against a task
+func CreateWorklogHandler(w http.ResponseWriter, r *http.Request) {
+	worklogHandler := models.WorklogHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		worklogHandler.CreateWorklog(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// ListWorklogsHandler defines the route handler function for listing the worklogs of a task
+func ListWorklogsHandler(w http.ResponseWriter, r *http.Request) {
+	worklogHandler := models.WorklogHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		worklogHandler.ListWorklogs(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// StartTimerHandler defines the route handler function for starting a timer on a task
+func StartTimerHandler(w http.ResponseWriter, r *http.Request) {
+	worklogHandler := models.WorklogHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		worklogHandler.StartTimer(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// StopTimerHandler defines the route handler function for stopping the timer on a task
+func StopTimerHandler(w http.ResponseWriter, r *http.Request) {
+	worklogHandler := models.WorklogHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		worklogHandler.StopTimer(w, r, mux.Vars(r)["id"])
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
+
+// GetWorklogSummaryHandler defines the route handler function for aggregated worklog hours
+func GetWorklogSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	worklogHandler := models.WorklogHandler(db)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		worklogHandler.GetWorklogSummary(w, r)
+	})
+	authenticate(handler).ServeHTTP(w, r)
+}
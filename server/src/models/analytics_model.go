@@ -0,0 +1,375 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const (
+	maxAnalyticsBuckets    = 366
+	defaultAnalyticsTop    = 10
+	maxAnalyticsTop        = 100
+	analyticsTeamSeriesKey = "all"
+)
+
+// analyticsMetric describes how a metric is aggregated over the tasks t. period is the date a task
+// counts towards and span limits the tasks to a range of those dates with two placeholders, from
+// and to. value is aggregated per period and technician.
+type analyticsMetric struct {
+	period string
+	span   string
+	join   string
+	where  string
+	value  string
+}
+
+var analyticsMetrics = map[string]analyticsMetric{
+	// Tasks scheduled per technician
+	"throughput": {
+		period: "t.date",
+		span:   "t.date BETWEEN ? AND ?",
+		value:  "COUNT(*)",
+	},
+	// Tasks completed per technician
+	"completions": {
+		period: "DATE(t.completed_at)",
+		span:   "t.completed_at >= ? AND t.completed_at < DATE_ADD(?, INTERVAL 1 DAY)",
+		where:  "t.status = 'done'",
+		value:  "COUNT(*)",
+	},
+	// Average hours from creation to completion. Tasks that predate the revision history have no creation time.
+	"cycle_time": {
+		period: "DATE(t.completed_at)",
+		span:   "t.completed_at >= ? AND t.completed_at < DATE_ADD(?, INTERVAL 1 DAY)",
+		join:   "JOIN task_revisions r ON r.task_id = t.id AND r.revision = 1 AND r.source = 'create'",
+		where:  "t.status = 'done' AND t.completed_at >= r.created_at",
+		value:  "AVG(TIMESTAMPDIFF(MINUTE, r.created_at, t.completed_at)) / 60",
+	},
+	// Share of the tasks due on a past day that were completed late or are still open
+	"overdue_rate": {
+		period: "t.due_date",
+		span:   "t.due_date BETWEEN ? AND ?",
+		where:  "t.due_date < UTC_DATE()",
+		value:  "AVG(CASE WHEN t.completed_at IS NULL THEN t.status <> 'done' ELSE DATE(t.completed_at) > t.due_date END)",
+	},
+}
+
+// analyticsRankings maps the dimensions tasks can be ranked by to their key, label and join
+var analyticsRankings = map[string]struct {
+	key, label, join string
+}{
+	"assets": {key: "a.id", label: "a.name", join: "JOIN assets a ON a.id = t.asset_id"},
+	"tags":   {key: "tg.tag", label: "tg.tag", join: "JOIN task_tags tg ON tg.task_id = t.id"},
+}
+
+type AnalyticsModel struct {
+	Db *sql.DB
+}
+
+func AnalyticsHandler(db *sql.DB) *AnalyticsModel {
+	return &AnalyticsModel{
+		Db: db,
+	}
+}
+
+// GetMetric returns a metric as a time series per technician plus one for the whole team. It takes
+// bucket (day or week) and the from and to dates, by default the last 30 days or 12 weeks.
+// Periods without tasks are included with a zero value so charts have no gaps.
+func (am *AnalyticsModel) GetMetric(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can view analytics")
+	if !ok {
+		return
+	}
+
+	metric, ok := analyticsMetrics[name]
+	if !ok {
+		http.Error(w, "Unknown metric", http.StatusNotFound)
+		return
+	}
+
+	bucket, from, to, err := AnalyticsRange(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	periods, err := AnalyticsPeriods(bucket, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := am.metricSeries(userID, metric, bucket, from, to, periods)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to compute analytics:", err)
+		return
+	}
+	report.Metric = name
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GetTop ranks the assets or tags (dimension) by the number of tasks dated between from and to
+func (am *AnalyticsModel) GetTop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can view analytics")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	dimension := query.Get("dimension")
+	ranking, ok := analyticsRankings[dimension]
+	if !ok {
+		http.Error(w, "dimension must be assets or tags", http.StatusBadRequest)
+		return
+	}
+	_, from, to, err := AnalyticsRange(query, time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, _, err := pageParams(query.Get("limit"), "", defaultAnalyticsTop, maxAnalyticsTop)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visible, args := visibleTasksFilter("t", userID)
+	args = append(args, from, to, limit)
+	rows, err := am.Db.Query("SELECT "+ranking.key+", "+ranking.label+", COUNT(*) AS tasks FROM tasks t "+ranking.join+
+		" WHERE "+visible+" AND t.date BETWEEN ? AND ? GROUP BY "+ranking.key+", "+ranking.label+
+		" ORDER BY tasks DESC, "+ranking.label+" LIMIT ?", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to rank tasks:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	top := entities.AnalyticsTop{Dimension: dimension, From: from, To: to, Items: []entities.AnalyticsRank{}}
+	for rows.Next() {
+		var item entities.AnalyticsRank
+		err := rows.Scan(&item.ID, &item.Label, &item.Count)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan ranking:", err)
+			return
+		}
+		top.Items = append(top.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to rank tasks:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, top)
+}
+
+// metricSeries aggregates a metric per period and technician in one GROUP BY query. WITH ROLLUP adds
+// the team total of each period, computed over the tasks rather than averaged over technicians.
+func (am *AnalyticsModel) metricSeries(userID string, metric analyticsMetric, bucket string, from string, to string,
+	periods []string) (entities.AnalyticsReport, error) {
+	report := entities.AnalyticsReport{Bucket: bucket, From: from, To: to}
+
+	period := metric.period
+	if bucket == "week" {
+		period = "DATE_SUB(" + metric.period + ", INTERVAL WEEKDAY(" + metric.period + ") DAY)"
+	}
+	visible, args := visibleTasksFilter("t", userID)
+	where := []string{visible, metric.span}
+	if metric.where != "" {
+		where = append(where, metric.where)
+	}
+	args = append(args, from, to)
+
+	rows, err := am.Db.Query("SELECT "+period+" AS period, t.user_id, COUNT(*), "+metric.value+" FROM tasks t "+metric.join+
+		" WHERE "+strings.Join(where, " AND ")+" GROUP BY period, t.user_id WITH ROLLUP", args...)
+	if err != nil {
+		return report, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	values := make(map[string]map[string]entities.AnalyticsPoint)
+	for rows.Next() {
+		var periodStart, technicianID sql.NullString
+		var point entities.AnalyticsPoint
+		var value sql.NullFloat64
+		err := rows.Scan(&periodStart, &technicianID, &point.Count, &value)
+		if err != nil {
+			return report, err
+		}
+		if !periodStart.Valid {
+			// The grand total over all periods
+			continue
+		}
+		key := analyticsTeamSeriesKey
+		if technicianID.Valid {
+			key = technicianID.String
+		}
+		if values[key] == nil {
+			values[key] = make(map[string]entities.AnalyticsPoint)
+		}
+		point.Period = periodStart.String
+		point.Value = value.Float64
+		values[key][point.Period] = point
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	series, err := am.technicianSeries(userID, values)
+	if err != nil {
+		return report, err
+	}
+	for i := range series {
+		series[i].Points = make([]entities.AnalyticsPoint, len(periods))
+		for j, start := range periods {
+			point, ok := values[series[i].Key][start]
+			if !ok {
+				point = entities.AnalyticsPoint{Period: start}
+			}
+			series[i].Points[j] = point
+		}
+	}
+	report.Series = series
+	return report, nil
+}
+
+// technicianSeries lists the series of a report: the team first, then every technician of the manager
+// by name, and anyone else whose tasks were counted
+func (am *AnalyticsModel) technicianSeries(userID string, values map[string]map[string]entities.AnalyticsPoint) ([]entities.AnalyticsSeries, error) {
+	series := []entities.AnalyticsSeries{{Key: analyticsTeamSeriesKey, Label: "All technicians"}}
+
+	rows, err := am.Db.Query("SELECT u.id, u.first_name, u.last_name FROM managers m JOIN users u ON u.id = m.technician_id"+
+		" WHERE m.manager_id = ? ORDER BY u.first_name, u.last_name, u.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	listed := map[string]bool{analyticsTeamSeriesKey: true}
+	for rows.Next() {
+		var id, firstName, lastName string
+		err := rows.Scan(&id, &firstName, &lastName)
+		if err != nil {
+			return nil, err
+		}
+		listed[id] = true
+		series = append(series, entities.AnalyticsSeries{Key: id, Label: firstName + " " + lastName})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var others []string
+	for key := range values {
+		if !listed[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		series = append(series, entities.AnalyticsSeries{Key: key, Label: key})
+	}
+	return series, nil
+}
+
+// AnalyticsRange reads bucket, from and to. Weekly ranges are widened to whole weeks from Monday to Sunday.
+func AnalyticsRange(query url.Values, now time.Time) (string, string, string, error) {
+	bucket := query.Get("bucket")
+	if bucket == "" {
+		bucket = "day"
+	}
+	if bucket != "day" && bucket != "week" {
+		return "", "", "", errors.New("bucket must be day or week")
+	}
+
+	to := now
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := time.Parse(mysqlDate, raw)
+		if err != nil {
+			return "", "", "", errors.New("to must be a date in YYYY-MM-DD format")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -29)
+	if bucket == "week" {
+		from = to.AddDate(0, 0, -7*11)
+	}
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := time.Parse(mysqlDate, raw)
+		if err != nil {
+			return "", "", "", errors.New("from must be a date in YYYY-MM-DD format")
+		}
+		from = parsed
+	}
+
+	if bucket == "week" {
+		from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+		to = to.AddDate(0, 0, (7-int(to.Weekday()))%7)
+	}
+	if from.After(to) {
+		return "", "", "", errors.New("from must not be after to")
+	}
+	return bucket, from.Format(mysqlDate), to.Format(mysqlDate), nil
+}
+
+// AnalyticsPeriods lists the start date of every day or week between from and to
+func AnalyticsPeriods(bucket string, from string, to string) ([]string, error) {
+	start, err := time.Parse(mysqlDate, from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(mysqlDate, to)
+	if err != nil {
+		return nil, err
+	}
+	step := 1
+	if bucket == "week" {
+		step = 7
+	}
+
+	var periods []string
+	for day := start; !day.After(end); day = day.AddDate(0, 0, step) {
+		if len(periods) == maxAnalyticsBuckets {
+			return nil, fmt.Errorf("a report can cover at most %d %ss", maxAnalyticsBuckets, bucket)
+		}
+		periods = append(periods, day.Format(mysqlDate))
+	}
+	return periods, nil
+}
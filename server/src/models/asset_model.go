@@ -0,0 +1,396 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const assetColumns = "id, name, type, location, serial, parent_id, location_id, created_at"
+
+type AssetModel struct {
+	Db *sql.DB
+}
+
+func AssetHandler(db *sql.DB) *AssetModel {
+	return &AssetModel{
+		Db: db,
+	}
+}
+
+func (am *AssetModel) CreateAsset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage assets"); !ok {
+		return
+	}
+
+	asset := entities.Asset{}
+	err := json.NewDecoder(r.Body).Decode(&asset)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	asset.ID = uuid.New().String()
+	if !am.validateAsset(w, asset) {
+		return
+	}
+
+	insertQuery := "INSERT INTO assets (id, name, type, location, serial, parent_id, location_id) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, err = am.Db.Exec(insertQuery, asset.ID, asset.Name, asset.Type, asset.Location, asset.Serial,
+		nullString(asset.ParentID), nullString(asset.LocationID))
+	if err != nil {
+		http.Error(w, "Asset creation failed", http.StatusInternalServerError)
+		log.Println("Asset creation failed:", err)
+		return
+	}
+
+	created, err := am.findAsset(asset.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve created asset", http.StatusInternalServerError)
+		log.Println("Failed to retrieve created asset:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetAssets lists assets, optionally filtered by type, parent or a name/serial search
+func (am *AssetModel) GetAssets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	where := []string{"1 = 1"}
+	var args []interface{}
+	if assetType := query.Get("type"); assetType != "" {
+		where = append(where, "type = ?")
+		args = append(args, assetType)
+	}
+	if parentID := query.Get("parent_id"); parentID == "root" {
+		where = append(where, "parent_id IS NULL")
+	} else if parentID != "" {
+		where = append(where, "parent_id = ?")
+		args = append(args, parentID)
+	}
+	if locationID := query.Get("location_id"); locationID != "" {
+		where = append(where, locationSubtreeClause("location_id"))
+		args = append(args, locationID)
+	}
+	if search := query.Get("q"); search != "" {
+		where = append(where, "(name LIKE ? OR serial LIKE ?)")
+		args = append(args, "%"+search+"%", "%"+search+"%")
+	}
+
+	rows, err := am.Db.Query("SELECT "+assetColumns+" FROM assets WHERE "+strings.Join(where, " AND ")+" ORDER BY name", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving assets:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	assets := []entities.Asset{}
+	for rows.Next() {
+		asset, err := scanAsset(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		assets = append(assets, asset)
+	}
+
+	writeJSON(w, http.StatusOK, assets)
+}
+
+func (am *AssetModel) GetAsset(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	asset, ok := am.loadAsset(w, id)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, asset)
+}
+
+func (am *AssetModel) UpdateAsset(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage assets"); !ok {
+		return
+	}
+
+	asset, ok := am.loadAsset(w, id)
+	if !ok {
+		return
+	}
+
+	patchAsset := make(map[string]interface{})
+	err := json.NewDecoder(r.Body).Decode(&patchAsset)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Decoding failed:", err)
+		return
+	}
+
+	if name, ok := patchAsset["name"].(string); ok {
+		asset.Name = name
+	}
+	if assetType, ok := patchAsset["type"].(string); ok {
+		asset.Type = assetType
+	}
+	if location, ok := patchAsset["location"].(string); ok {
+		asset.Location = location
+	}
+	if serial, ok := patchAsset["serial"].(string); ok {
+		asset.Serial = serial
+	}
+	if parentID, ok := patchAsset["parent_id"]; ok {
+		// A null parent_id moves the asset to the top of the hierarchy
+		asset.ParentID, _ = parentID.(string)
+	}
+	if locationID, ok := patchAsset["location_id"]; ok {
+		asset.LocationID, _ = locationID.(string)
+	}
+
+	if !am.validateAsset(w, asset) {
+		return
+	}
+
+	updateQuery := "UPDATE assets SET name = ?, type = ?, location = ?, serial = ?, parent_id = ?, location_id = ? WHERE id = ?"
+	_, err = am.Db.Exec(updateQuery, asset.Name, asset.Type, asset.Location, asset.Serial,
+		nullString(asset.ParentID), nullString(asset.LocationID), id)
+	if err != nil {
+		http.Error(w, "Asset update failed", http.StatusInternalServerError)
+		log.Println("Failed to update asset:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, asset)
+}
+
+func (am *AssetModel) DeleteAsset(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage assets"); !ok {
+		return
+	}
+
+	asset, ok := am.loadAsset(w, id)
+	if !ok {
+		return
+	}
+
+	// Keep the maintenance history intact; assets in use have to be detached first
+	var children, tasks int
+	err := am.Db.QueryRow("SELECT (SELECT COUNT(*) FROM assets WHERE parent_id = ?), (SELECT COUNT(*) FROM tasks WHERE asset_id = ?)",
+		asset.ID, asset.ID).Scan(&children, &tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to count asset references:", err)
+		return
+	}
+	if children > 0 || tasks > 0 {
+		http.Error(w, "Asset still has child assets or tasks and cannot be deleted", http.StatusConflict)
+		return
+	}
+
+	_, err = am.Db.Exec("DELETE FROM assets WHERE id = ?", asset.ID)
+	if err != nil {
+		http.Error(w, "Asset deletion failed", http.StatusInternalServerError)
+		log.Println("Asset deletion failed:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{
+		Message: "Asset deleted successfully",
+	})
+}
+
+// GetAssetHistory lists the tasks performed on an asset, and with include_children=true on
+// everything below it in the hierarchy, restricted to the tasks the current user may see
+func (am *AssetModel) GetAssetHistory(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	asset, ok := am.loadAsset(w, id)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	assetFilter := "asset_id = ?"
+	if query.Get("include_children") == "true" {
+		assetFilter = `asset_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM assets WHERE id = ?
+				UNION ALL
+				SELECT a.id FROM assets a JOIN subtree s ON a.parent_id = s.id
+			)
+			SELECT id FROM subtree)`
+	}
+
+	filters, filterArgs, err := taskFilters(query, "t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visibleClause, visibleArgs := visibleTasksFilter("t", userID)
+	where := append([]string{"t." + assetFilter, visibleClause}, filters...)
+	args := append(append([]interface{}{asset.ID}, visibleArgs...), filterArgs...)
+
+	rows, err := am.Db.Query("SELECT "+taskColumns+" FROM tasks t WHERE "+strings.Join(where, " AND ")+" ORDER BY t.date DESC", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving asset history:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	history := entities.AssetHistory{Asset: asset, Tasks: []entities.Task{}}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		history.Tasks = append(history.Tasks, task)
+	}
+
+	err = loadTaskDetails(am.Db, history.Tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load task details:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (am *AssetModel) validateAsset(w http.ResponseWriter, asset entities.Asset) bool {
+	if asset.Name == "" {
+		http.Error(w, "Missing required fields: name", http.StatusBadRequest)
+		return false
+	}
+	if asset.Type == "" {
+		http.Error(w, "Missing required fields: type", http.StatusBadRequest)
+		return false
+	}
+	if asset.LocationID != "" && !locationExists(am.Db, asset.LocationID) {
+		http.Error(w, "Location not found", http.StatusBadRequest)
+		return false
+	}
+	if asset.ParentID == "" {
+		return true
+	}
+	if !assetExists(am.Db, asset.ParentID) {
+		http.Error(w, "Parent asset not found", http.StatusBadRequest)
+		return false
+	}
+
+	// Walk up from the new parent to make sure the asset would not become its own ancestor
+	for parentID := asset.ParentID; parentID != ""; {
+		if parentID == asset.ID {
+			http.Error(w, "An asset cannot be moved below itself", http.StatusBadRequest)
+			return false
+		}
+		var next sql.NullString
+		err := am.Db.QueryRow("SELECT parent_id FROM assets WHERE id = ?", parentID).Scan(&next)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to walk asset hierarchy:", err)
+			return false
+		}
+		parentID = next.String
+	}
+	return true
+}
+
+func (am *AssetModel) loadAsset(w http.ResponseWriter, id string) (entities.Asset, bool) {
+	asset, err := am.findAsset(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Asset not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan asset:", err)
+		}
+		return asset, false
+	}
+	return asset, true
+}
+
+func (am *AssetModel) findAsset(id string) (entities.Asset, error) {
+	return scanAsset(am.Db.QueryRow("SELECT "+assetColumns+" FROM assets WHERE id = ?", id))
+}
+
+func scanAsset(row rowScanner) (entities.Asset, error) {
+	var asset entities.Asset
+	var parentID, locationID sql.NullString
+	err := row.Scan(&asset.ID, &asset.Name, &asset.Type, &asset.Location, &asset.Serial, &parentID, &locationID, &asset.CreatedAt)
+	asset.ParentID = parentID.String
+	asset.LocationID = locationID.String
+	return asset, err
+}
+
+func assetExists(db *sql.DB, id string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM assets WHERE id = ?", id).Scan(&count)
+	if err != nil {
+		log.Println("Failed to query asset database:", err)
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const defaultAllowedAttachmentTypes = "image/jpeg,image/png,image/gif,image/webp,application/pdf"
+
+type AttachmentModel struct {
+	Db           *sql.DB
+	Store        services.BlobStore
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+func AttachmentHandler(db *sql.DB, store services.BlobStore) *AttachmentModel {
+	return &AttachmentModel{
+		Db:           db,
+		Store:        store,
+		MaxSize:      config.GetenvInt("ATTACHMENT_MAX_BYTES", 10<<20),
+		AllowedTypes: strings.Split(config.Getenv("ATTACHMENT_ALLOWED_TYPES", defaultAllowedAttachmentTypes), ","),
+	}
+}
+
+func (am *AttachmentModel) UploadAttachment(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, am.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	// Leave some room for the multipart envelope around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, am.MaxSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected a multipart/form-data request", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	var (
+		part             io.Reader
+		fileName         string
+		expectedChecksum string
+	)
+	for part == nil {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Bad Input", http.StatusBadRequest)
+			log.Println("Failed to read multipart body:", err)
+			return
+		}
+
+		switch p.FormName() {
+		case "sha256":
+			expectedChecksum, err = readChecksumField(p)
+			if err != nil {
+				http.Error(w, "Bad Input", http.StatusBadRequest)
+				return
+			}
+		case "file":
+			part = p
+			fileName = filepath.Base(p.FileName())
+		}
+	}
+	if part == nil {
+		http.Error(w, "Missing required fields: file", http.StatusBadRequest)
+		return
+	}
+
+	// Spool the upload to disk so the size, type and checksum are known before storing it
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to create temporary file:", err)
+		return
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(part, am.MaxSize+1))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("Attachment exceeds the %d byte limit", am.MaxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Failed to read attachment:", err)
+		return
+	}
+	if size > am.MaxSize {
+		http.Error(w, fmt.Sprintf("Attachment exceeds the %d byte limit", am.MaxSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if size == 0 {
+		http.Error(w, "Attachment is empty", http.StatusBadRequest)
+		return
+	}
+
+	// The sha256 field may also follow the file
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Bad Input", http.StatusBadRequest)
+			log.Println("Failed to read multipart body:", err)
+			return
+		}
+		if p.FormName() == "sha256" {
+			expectedChecksum, err = readChecksumField(p)
+			if err != nil {
+				http.Error(w, "Bad Input", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if expectedChecksum != "" && expectedChecksum != checksum {
+		http.Error(w, "Checksum mismatch", http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Sniff the content type rather than trusting the client supplied header
+	sniff := make([]byte, 512)
+	n, err := tmp.ReadAt(sniff, 0)
+	if err != nil && err != io.EOF {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to read temporary file:", err)
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(sniff[:n]))
+	if !am.isAllowedType(contentType) {
+		http.Error(w, "Unsupported attachment type: "+contentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to rewind temporary file:", err)
+		return
+	}
+
+	attachment := entities.Attachment{
+		ID:          uuid.New().String(),
+		TaskID:      task.ID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+		UploadedBy:  userID,
+	}
+	attachment.StorageKey = fmt.Sprintf("tasks/%s/%s", task.ID, attachment.ID)
+
+	err = am.Store.Put(r.Context(), attachment.StorageKey, tmp, size, contentType)
+	if err != nil {
+		http.Error(w, "Attachment upload failed", http.StatusInternalServerError)
+		log.Println("Failed to store attachment:", err)
+		return
+	}
+
+	insertQuery := "INSERT INTO task_attachments (id, task_id, file_name, content_type, size, checksum, storage_key, uploaded_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = am.Db.Exec(insertQuery, attachment.ID, attachment.TaskID, attachment.FileName, attachment.ContentType,
+		attachment.Size, attachment.Checksum, attachment.StorageKey, attachment.UploadedBy)
+	if err != nil {
+		http.Error(w, "Attachment upload failed", http.StatusInternalServerError)
+		log.Println("Failed to save attachment:", err)
+		if err := am.Store.Delete(r.Context(), attachment.StorageKey); err != nil {
+			log.Println("Failed to remove orphaned attachment:", err)
+		}
+		return
+	}
+
+	err = am.Db.QueryRow("SELECT created_at FROM task_attachments WHERE id = ?", attachment.ID).Scan(&attachment.CreatedAt)
+	if err != nil {
+		log.Println("Failed to retrieve created attachment:", err)
+	}
+	attachment.DownloadURL = downloadURL(attachment)
+
+	writeJSON(w, http.StatusCreated, attachment)
+}
+
+func (am *AttachmentModel) ListAttachments(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, am.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	rows, err := am.Db.Query("SELECT id, task_id, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at FROM task_attachments WHERE task_id = ? ORDER BY created_at", task.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving attachments:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	attachments := []entities.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	writeJSON(w, http.StatusOK, attachments)
+}
+
+func (am *AttachmentModel) DownloadAttachment(w http.ResponseWriter, r *http.Request, taskID string, attachmentID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, am.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	row := am.Db.QueryRow("SELECT id, task_id, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at FROM task_attachments WHERE id = ? AND task_id = ?", attachmentID, task.ID)
+	attachment, err := scanAttachment(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan attachment:", err)
+		}
+		return
+	}
+
+	etag := `"` + attachment.Checksum + `"`
+	w.Header().Set("ETag", etag)
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := am.Store.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, services.ErrBlobNotFound) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to read attachment:", err)
+		}
+		return
+	}
+	defer func(blob io.ReadCloser) {
+		err := blob.Close()
+		if err != nil {
+			log.Println("Failed to close attachment:", err)
+		}
+	}(blob)
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", fmt.Sprint(attachment.Size))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Checksum-Sha256", attachment.Checksum)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, blob)
+	if err != nil {
+		log.Println("Failed to write attachment:", err)
+	}
+}
+
+func (am *AttachmentModel) isAllowedType(contentType string) bool {
+	for _, allowed := range am.AllowedTypes {
+		if strings.TrimSpace(allowed) == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func scanAttachment(row rowScanner) (entities.Attachment, error) {
+	var attachment entities.Attachment
+	err := row.Scan(&attachment.ID, &attachment.TaskID, &attachment.FileName, &attachment.ContentType,
+		&attachment.Size, &attachment.Checksum, &attachment.StorageKey, &attachment.UploadedBy, &attachment.CreatedAt)
+	if err != nil {
+		return attachment, err
+	}
+	attachment.DownloadURL = downloadURL(attachment)
+	return attachment, nil
+}
+
+func downloadURL(attachment entities.Attachment) string {
+	return fmt.Sprintf("/tasks/%s/attachments/%s", attachment.TaskID, attachment.ID)
+}
+
+// readChecksumField reads the hex SHA-256 a client sent to have the upload verified
+func readChecksumField(part io.Reader) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, 128))
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.TrimSpace(string(value))), nil
+}
@@ -0,0 +1,340 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const auditColumns = "id, actor_id, action, entity_type, entity_id, before_json, after_json, request_id, ip, created_at"
+
+const maxAuditPageSize = 500
+
+type AuditModel struct {
+	Db *sql.DB
+}
+
+func AuditHandler(db *sql.DB) *AuditModel {
+	return &AuditModel{
+		Db: db,
+	}
+}
+
+// GetAuditLog lists audit entries visible to the manager, newest first
+func (am *AuditModel) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	rows, ok := am.queryAuditLog(w, r, true)
+	if !ok {
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	entries := []entities.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// ExportAuditLog streams the audit entries matching the same filters as GetAuditLog as CSV, without paging
+func (am *AuditModel) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	rows, ok := am.queryAuditLog(w, r, false)
+	if !ok {
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit_log.csv"`)
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"id", "created_at", "actor_id", "action", "entity_type", "entity_id", "before", "after", "request_id", "ip"})
+	for err == nil && rows.Next() {
+		var entry entities.AuditEntry
+		entry, err = scanAuditEntry(rows)
+		if err != nil {
+			break
+		}
+		record := []string{entry.CreatedAt, entry.ActorID, entry.Action, entry.EntityType, entry.EntityID,
+			string(entry.Before), string(entry.After), entry.RequestID, entry.IP}
+		// Summaries, names and request IDs come from users, so none of them may run as a formula
+		for i := range record {
+			record[i] = EscapeSpreadsheetFormula(record[i])
+		}
+		err = writer.Write(append([]string{strconv.FormatInt(entry.ID, 10)}, record...))
+	}
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err != nil {
+		// The status line has been sent already, so the export can only be cut short
+		log.Println("Failed to export audit log:", err)
+	}
+}
+
+// queryAuditLog runs the filtered audit query. Managers see the entries made by themselves or their
+// technicians and those about their technicians and their tasks.
+func (am *AuditModel) queryAuditLog(w http.ResponseWriter, r *http.Request, paged bool) (*sql.Rows, bool) {
+	userID, ok := requireManager(w, r, "Only Managers can read the audit log")
+	if !ok {
+		return nil, false
+	}
+
+	where := []string{"(" + visibleUsersClause("a.actor_id") +
+		" OR (a.entity_type = 'user' AND " + visibleUsersClause("a.entity_id") + ")" +
+		" OR (a.entity_type = 'task' AND a.entity_id IN (SELECT t.id FROM tasks t WHERE " + visibleUsersClause("t.user_id") + ")))"}
+	args := []interface{}{userID, userID, userID, userID, userID, userID}
+
+	query := r.URL.Query()
+	for _, filter := range []struct{ param, column string }{
+		{"actor_id", "a.actor_id"},
+		{"action", "a.action"},
+		{"entity_type", "a.entity_type"},
+		{"entity_id", "a.entity_id"},
+		{"request_id", "a.request_id"},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			where = append(where, filter.column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if from := query.Get("from"); from != "" {
+		if !isDate(from) {
+			http.Error(w, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return nil, false
+		}
+		where = append(where, "a.created_at >= ?")
+		args = append(args, from)
+	}
+	if to := query.Get("to"); to != "" {
+		if !isDate(to) {
+			http.Error(w, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return nil, false
+		}
+		where = append(where, "a.created_at < ? + INTERVAL 1 DAY")
+		args = append(args, to)
+	}
+
+	sqlQuery := "SELECT " + prefixColumns("a", auditColumns) + " FROM audit_log a WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY a.id DESC"
+	if paged {
+		limit, offset, err := pageParams(query.Get("limit"), query.Get("offset"), 100, maxAuditPageSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		sqlQuery += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+
+	rows, err := am.Db.Query(sqlQuery, args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving audit log:", err)
+		return nil, false
+	}
+	return rows, true
+}
+
+func scanAuditEntry(row rowScanner) (entities.AuditEntry, error) {
+	var entry entities.AuditEntry
+	var actorID, requestID, ip sql.NullString
+	var before, after []byte
+	err := row.Scan(&entry.ID, &actorID, &entry.Action, &entry.EntityType, &entry.EntityID, &before, &after,
+		&requestID, &ip, &entry.CreatedAt)
+	entry.ActorID = actorID.String
+	entry.RequestID = requestID.String
+	entry.IP = ip.String
+	if len(before) > 0 {
+		entry.Before = before
+	}
+	if len(after) > 0 {
+		entry.After = after
+	}
+	return entry, err
+}
+
+// recordAudit appends an entry to the audit log for a write made while serving r. Only the fields that
+// differ between before and after are stored; before is nil for creations. Failures are logged rather
+// than returned because the change itself has already been made.
+func recordAudit(db dbExecutor, r *http.Request, action string, entityType string, entityID string, before interface{}, after interface{}) {
+	actorID, _ := r.Context().Value("userID").(string)
+	requestID, _ := r.Context().Value("requestID").(string)
+	writeAudit(db, auditSource{actorID: actorID, requestID: requestID, ip: ClientIP(r)}, action, entityType, entityID, before, after)
+}
+
+// auditSource identifies who made a change and through which request
+type auditSource struct {
+	actorID   string
+	requestID string
+	ip        string
+}
+
+// writeAudit appends an audit entry on behalf of source. Changes made outside an HTTP request, such as
+// by the import command, use it directly.
+func writeAudit(db dbExecutor, source auditSource, action string, entityType string, entityID string, before interface{}, after interface{}) {
+	beforeJSON, afterJSON, err := AuditDiff(before, after)
+	if err != nil {
+		log.Println("Failed to compute audit diff:", err)
+		return
+	}
+
+	_, err = db.Exec("INSERT INTO audit_log (actor_id, action, entity_type, entity_id, before_json, after_json, request_id, ip)"+
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?)", nullString(source.actorID), action, entityType, entityID, beforeJSON, afterJSON,
+		nullString(source.requestID), nullString(source.ip))
+	if err != nil {
+		log.Println("Failed to write audit log:", err)
+	}
+}
+
+// AuditDiff serializes the fields that changed between before and after. Either side may be nil.
+func AuditDiff(before interface{}, after interface{}) ([]byte, []byte, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	var beforeJSON, afterJSON []byte
+	if beforeFields != nil {
+		beforeJSON, err = json.Marshal(beforeFields)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if afterFields != nil {
+		afterJSON, err = json.Marshal(afterFields)
+	}
+	return beforeJSON, afterJSON, err
+}
+
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &fields)
+	return fields, err
+}
+
+// ClientIP returns the address of the client. X-Forwarded-For is only believed when the request
+// comes from one of TRUSTED_PROXIES, a comma separated list of addresses or CIDR ranges; the client
+// is then the nearest forwarded hop that is not a trusted proxy itself.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	proxies := config.GetenvNetworks("TRUSTED_PROXIES")
+	if !isTrustedProxy(host, proxies) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		host = hop
+		if !isTrustedProxy(hop, proxies) {
+			break
+		}
+	}
+	return host
+}
+
+func isTrustedProxy(address string, proxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageParams parses limit and offset query parameters
+func pageParams(rawLimit string, rawOffset string, defaultLimit int, maxLimit int) (int, int, error) {
+	limit, offset := defaultLimit, 0
+	var err error
+	if rawLimit != "" {
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, errors.New("limit must be between 1 and " + strconv.Itoa(maxLimit))
+		}
+	}
+	if rawOffset != "" {
+		offset, err = strconv.Atoi(rawOffset)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a positive number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// prefixColumns qualifies a comma separated column list with a table alias
+func prefixColumns(alias string, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i := range parts {
+		parts[i] = alias + "." + parts[i]
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const (
+	defaultAccentColor = "#1f4e79"
+	maxLogoBytes       = 1 << 20
+)
+
+type BrandingModel struct {
+	Db    *sql.DB
+	Store services.BlobStore
+}
+
+func BrandingHandler(db *sql.DB, store services.BlobStore) *BrandingModel {
+	return &BrandingModel{
+		Db:    db,
+		Store: store,
+	}
+}
+
+// GetBranding returns the branding of the current user's team
+func (bm *BrandingModel) GetBranding(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	branding, _, err := loadBranding(bm.Db, organizationID(userID, managerID))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load branding:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, branding)
+}
+
+// UpdateBranding changes the branding of the manager's team. Fields left out keep their value.
+func (bm *BrandingModel) UpdateBranding(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can change the branding")
+	if !ok {
+		return
+	}
+
+	branding, _, err := loadBranding(bm.Db, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load branding:", err)
+		return
+	}
+	err = json.NewDecoder(r.Body).Decode(&branding)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if message := ValidateBranding(branding); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	_, err = bm.Db.Exec(`INSERT INTO branding (manager_id, company_name, address, phone, email, accent_color, footer)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE company_name = VALUES(company_name), address = VALUES(address), phone = VALUES(phone),
+			email = VALUES(email), accent_color = VALUES(accent_color), footer = VALUES(footer)`,
+		userID, branding.CompanyName, branding.Address, branding.Phone, branding.Email, branding.AccentColor, branding.Footer)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to save branding:", err)
+		return
+	}
+	recordAudit(bm.Db, r, "update", "branding", userID, nil, branding)
+	writeJSON(w, http.StatusOK, branding)
+}
+
+// UploadLogo replaces the team's logo with the JPEG, PNG or GIF image in the request body
+func (bm *BrandingModel) UploadLogo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can change the branding")
+	if !ok {
+		return
+	}
+
+	logo, err := io.ReadAll(io.LimitReader(r.Body, maxLogoBytes+1))
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Failed to read logo:", err)
+		return
+	}
+	if len(logo) > maxLogoBytes {
+		http.Error(w, "The logo must be at most 1 MB", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if _, err := services.NewPDFImage(logo); err != nil {
+		http.Error(w, "The logo must be a JPEG, PNG or GIF image", http.StatusBadRequest)
+		return
+	}
+
+	key := "branding/" + userID + "/logo"
+	err = bm.Store.Put(r.Context(), key, bytes.NewReader(logo), int64(len(logo)), http.DetectContentType(logo))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to store logo:", err)
+		return
+	}
+
+	_, err = bm.Db.Exec("INSERT INTO branding (manager_id, company_name, logo_key) VALUES (?, ?, ?)"+
+		" ON DUPLICATE KEY UPDATE logo_key = VALUES(logo_key)", userID, defaultCompanyName(), key)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to save branding:", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteLogo removes the team's logo
+func (bm *BrandingModel) DeleteLogo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can change the branding")
+	if !ok {
+		return
+	}
+
+	_, logoKey, err := loadBranding(bm.Db, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load branding:", err)
+		return
+	}
+	if logoKey == "" {
+		http.Error(w, "Logo not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = bm.Db.Exec("UPDATE branding SET logo_key = NULL WHERE manager_id = ?", userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to save branding:", err)
+		return
+	}
+	err = bm.Store.Delete(r.Context(), logoKey)
+	if err != nil && !errors.Is(err, services.ErrBlobNotFound) {
+		log.Println("Failed to delete logo:", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ValidateBranding returns a message describing what is wrong with the branding, or "" when it is valid
+func ValidateBranding(branding entities.Branding) string {
+	if strings.TrimSpace(branding.CompanyName) == "" {
+		return "Missing required fields: company_name"
+	}
+	if len(branding.CompanyName) > 100 || len(branding.Address) > 255 || len(branding.Phone) > 50 ||
+		len(branding.Email) > 100 || len(branding.Footer) > 255 {
+		return "company_name and email can have 100 characters, phone 50, and address and footer 255"
+	}
+	if _, err := services.ParsePDFColor(branding.AccentColor); err != nil {
+		return "accent_color must look like #rrggbb"
+	}
+	return ""
+}
+
+// organizationID returns the manager whose team the current user belongs to
+func organizationID(userID string, managerID string) string {
+	if managerID != "" {
+		return managerID
+	}
+	return userID
+}
+
+func defaultCompanyName() string {
+	return config.Getenv("COMPANY_NAME", "Tasks Tracker")
+}
+
+// loadBranding returns the branding of a manager's team, or the default one, and the blob key of its logo
+func loadBranding(db *sql.DB, managerID string) (entities.Branding, string, error) {
+	branding := entities.Branding{CompanyName: defaultCompanyName(), AccentColor: defaultAccentColor}
+	var logoKey sql.NullString
+	err := db.QueryRow("SELECT company_name, address, phone, email, accent_color, footer, logo_key FROM branding WHERE manager_id = ?",
+		managerID).Scan(&branding.CompanyName, &branding.Address, &branding.Phone, &branding.Email, &branding.AccentColor,
+		&branding.Footer, &logoKey)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return branding, "", err
+	}
+	branding.HasLogo = logoKey.Valid
+	return branding, logoKey.String, nil
+}
+
+// loadPDFBranding prepares a team's branding for printing. A logo that cannot be loaded is left out.
+func loadPDFBranding(ctx context.Context, db *sql.DB, store services.BlobStore, managerID string) (services.PDFBranding, error) {
+	branding, logoKey, err := loadBranding(db, managerID)
+	if err != nil {
+		return services.PDFBranding{}, err
+	}
+	pdfBranding := PDFBranding(branding)
+
+	if logoKey != "" && store != nil {
+		logo, err := loadLogo(ctx, store, logoKey)
+		if err != nil {
+			log.Println("Failed to load logo:", err)
+		}
+		pdfBranding.Logo = logo
+	}
+	return pdfBranding, nil
+}
+
+// PDFBranding converts a branding to what the PDF reports print, without the logo
+func PDFBranding(branding entities.Branding) services.PDFBranding {
+	accent, err := services.ParsePDFColor(branding.AccentColor)
+	if err != nil {
+		accent, _ = services.ParsePDFColor(defaultAccentColor)
+	}
+	var details []string
+	if branding.Address != "" {
+		details = append(details, branding.Address)
+	}
+	var contact []string
+	for _, value := range []string{branding.Phone, branding.Email} {
+		if value != "" {
+			contact = append(contact, value)
+		}
+	}
+	if len(contact) > 0 {
+		details = append(details, strings.Join(contact, "  |  "))
+	}
+	return services.PDFBranding{CompanyName: branding.CompanyName, Details: details, Footer: branding.Footer, Accent: accent}
+}
+
+func loadLogo(ctx context.Context, store services.BlobStore, key string) (*services.PDFImage, error) {
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := body.Close()
+		if err != nil {
+			log.Println("Failed to close logo:", err)
+		}
+	}()
+
+	logo, err := io.ReadAll(io.LimitReader(body, maxLogoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	return services.NewPDFImage(logo)
+}
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const maxBulkOperations = 500
+
+type BulkModel struct {
+	Db     *sql.DB
+	Events *services.EventBus
+}
+
+func BulkHandler(db *sql.DB, events *services.EventBus) *BulkModel {
+	return &BulkModel{
+		Db:     db,
+		Events: events,
+	}
+}
+
+// bulkError is the outcome of an operation that could not be applied
+type bulkError struct {
+	code    int
+	message string
+}
+
+func (e *bulkError) Error() string {
+	return e.message
+}
+
+// BulkUpdate applies a list of task operations. Each operation is authorized like the single task
+// endpoints: deleting and reassigning follow DeleteTask, retagging and status changes follow
+// UpdateTask. In atomic mode the first failure rolls back the whole batch; otherwise every
+// operation is applied on its own and reported separately.
+func (bm *BulkModel) BulkUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	var request entities.BulkRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		return
+	}
+	err = ValidateBulkOperations(request.Operations)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]entities.BulkResult, len(request.Operations))
+	for i, operation := range request.Operations {
+		results[i] = entities.BulkResult{Index: i, Op: operation.Op, TaskID: operation.TaskID}
+	}
+
+	if request.Atomic {
+		bm.applyAtomic(w, r, request.Operations, results, userID, managerID)
+		return
+	}
+
+	for i, operation := range request.Operations {
+		task, err := bm.applyInTransaction(r, operation, userID, managerID)
+		setBulkResult(&results[i], task, err)
+	}
+	bm.publishResults(results, userID)
+	writeJSON(w, http.StatusOK, results)
+}
+
+// applyAtomic runs all operations in one transaction. When one of them fails the batch is rolled
+// back and answered with 409, or with 500 when the failure was a server error rather than a conflict.
+func (bm *BulkModel) applyAtomic(w http.ResponseWriter, r *http.Request, operations []entities.BulkOperation, results []entities.BulkResult, userID string, managerID string) {
+	tx, err := bm.Db.Begin()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to begin transaction:", err)
+		return
+	}
+
+	for i, operation := range operations {
+		task, err := applyBulkOperation(tx, r, operation, userID, managerID)
+		if err == nil {
+			setBulkResult(&results[i], task, nil)
+			continue
+		}
+
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Println("Failed to roll back bulk operations:", rollbackErr)
+		}
+		setBulkResult(&results[i], task, err)
+		for j := range results {
+			if j < i {
+				results[j].Status = "rolled_back"
+				results[j].Task = nil
+			} else if j > i {
+				results[j].Status = "skipped"
+			}
+		}
+		status := http.StatusConflict
+		if results[i].Code == http.StatusInternalServerError {
+			status = http.StatusInternalServerError
+		}
+		writeJSON(w, status, results)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		http.Error(w, "Bulk operations failed", http.StatusInternalServerError)
+		log.Println("Failed to commit bulk operations:", err)
+		return
+	}
+	bm.publishResults(results, userID)
+	writeJSON(w, http.StatusOK, results)
+}
+
+// applyInTransaction applies a single operation in its own transaction
+func (bm *BulkModel) applyInTransaction(r *http.Request, operation entities.BulkOperation, userID string, managerID string) (entities.Task, error) {
+	tx, err := bm.Db.Begin()
+	if err != nil {
+		return entities.Task{}, err
+	}
+	task, err := applyBulkOperation(tx, r, operation, userID, managerID)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Println("Failed to roll back bulk operation:", rollbackErr)
+		}
+		return task, err
+	}
+	return task, tx.Commit()
+}
+
+// publishResults announces the committed operations to the event streams
+func (bm *BulkModel) publishResults(results []entities.BulkResult, userID string) {
+	for _, result := range results {
+		if result.Status != "ok" {
+			continue
+		}
+		eventType := taskUpdated
+		if result.Op == "delete" {
+			eventType = taskDeleted
+		}
+		publishTaskEvent(bm.Events, eventType, *result.Task, userID)
+	}
+}
+
+func setBulkResult(result *entities.BulkResult, task entities.Task, err error) {
+	if err == nil {
+		result.Status = "ok"
+		result.Task = &task
+		return
+	}
+
+	result.Status = "error"
+	var opErr *bulkError
+	if errors.As(err, &opErr) {
+		result.Code = opErr.code
+		result.Error = opErr.message
+	} else {
+		result.Code = http.StatusInternalServerError
+		result.Error = "Something went wrong"
+		log.Println("Bulk operation on task", result.TaskID, "failed:", err)
+	}
+}
+
+// ValidateBulkOperations checks the shape of a batch before any of it is applied
+func ValidateBulkOperations(operations []entities.BulkOperation) error {
+	if len(operations) == 0 {
+		return errors.New("operations cannot be empty")
+	}
+	if len(operations) > maxBulkOperations {
+		return fmt.Errorf("at most %d operations can be sent at once", maxBulkOperations)
+	}
+
+	for i, operation := range operations {
+		if operation.TaskID == "" {
+			return fmt.Errorf("operation %d needs a task_id", i)
+		}
+		switch operation.Op {
+		case "delete":
+		case "retag":
+			if operation.Tags == nil && len(operation.AddTags) == 0 && len(operation.RemoveTags) == 0 {
+				return fmt.Errorf("operation %d needs tags, add_tags or remove_tags", i)
+			}
+			for _, tags := range [][]string{operation.Tags, operation.AddTags, operation.RemoveTags} {
+				if _, err := normalizeTags(tags); err != nil {
+					return fmt.Errorf("operation %d: %v", i, err)
+				}
+			}
+		case "reassign":
+			if operation.UserID == "" {
+				return fmt.Errorf("operation %d needs a user_id", i)
+			}
+		case "set_status":
+			if !taskStatuses[operation.Status] {
+				return fmt.Errorf("operation %d: status must be one of open, in_progress or done", i)
+			}
+		default:
+			return fmt.Errorf("operation %d: op must be one of delete, retag, reassign or set_status", i)
+		}
+	}
+	return nil
+}
+
+// applyBulkOperation authorizes and applies one operation, recording its revision and audit entry
+func applyBulkOperation(db dbExecutor, r *http.Request, operation entities.BulkOperation, userID string, managerID string) (entities.Task, error) {
+	task, err := findTaskWithDetails(db, operation.TaskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return task, &bulkError{http.StatusNotFound, "Task not found"}
+		}
+		return task, err
+	}
+	before := task
+
+	switch operation.Op {
+	case "delete", "reassign":
+		allowed, err := canDeleteTask(db, task, userID, managerID)
+		if err != nil {
+			return task, err
+		}
+		if !allowed {
+			return task, &bulkError{http.StatusForbidden, "Only Managers associated with this user can " + operation.Op + " this task"}
+		}
+	default:
+		if !canUpdateTask(task, userID, managerID) {
+			return task, &bulkError{http.StatusForbidden, "Only the task owner can update this task"}
+		}
+	}
+
+	if operation.Op == "delete" {
+		deleted, err := trashTask(db, task, userID)
+		if err != nil {
+			return task, err
+		}
+		recordAudit(db, r, "delete", "task", task.ID, before, deleted)
+		return deleted, nil
+	}
+
+	switch operation.Op {
+	case "retag":
+		err = retagTask(db, &task, operation)
+	case "reassign":
+		err = reassignTask(db, &task, operation.UserID, userID)
+	case "set_status":
+		err = setTaskStatus(db, &task, operation.Status)
+	}
+	if err != nil {
+		return before, err
+	}
+
+	updated, err := findTaskWithDetails(db, task.ID)
+	if err != nil || updated.Version == before.Version {
+		return updated, err
+	}
+	err = recordRevision(db, before, updated, userID, "bulk", 0)
+	if err != nil {
+		return before, err
+	}
+	recordAudit(db, r, "update", "task", task.ID, before, updated)
+	return updated, nil
+}
+
+// bumpTaskVersion claims the next version of a task, failing when it changed since it was read
+func bumpTaskVersion(db dbExecutor, task *entities.Task) error {
+	result, err := db.Exec("UPDATE tasks SET version = version + 1 WHERE id = ? AND version = ?", task.ID, task.Version)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return &bulkError{http.StatusPreconditionFailed, "Task was modified by someone else"}
+	}
+	task.Version++
+	return nil
+}
+
+func retagTask(db dbExecutor, task *entities.Task, operation entities.BulkOperation) error {
+	tags := task.Tags
+	if operation.Tags != nil {
+		tags, _ = normalizeTags(operation.Tags)
+	}
+	added, _ := normalizeTags(operation.AddTags)
+	removed, _ := normalizeTags(operation.RemoveTags)
+
+	skip := make(map[string]bool)
+	for _, tag := range removed {
+		skip[tag] = true
+	}
+	result := []string{}
+	for _, tag := range append(append([]string{}, tags...), added...) {
+		if !skip[tag] {
+			skip[tag] = true
+			result = append(result, tag)
+		}
+	}
+
+	err := bumpTaskVersion(db, task)
+	if err != nil {
+		return err
+	}
+	return setTaskTags(db, task.ID, result)
+}
+
+func reassignTask(db dbExecutor, task *entities.Task, technicianID string, managerID string) error {
+	// The new owner has to be one of the manager's own technicians
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM managers WHERE manager_id = ? AND technician_id = ?", managerID, technicianID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return &bulkError{http.StatusBadRequest, "Tasks can only be reassigned to your own technicians"}
+	}
+
+	err = bumpTaskVersion(db, task)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("UPDATE tasks SET user_id = ? WHERE id = ?", technicianID, task.ID)
+	return err
+}
+
+func setTaskStatus(db dbExecutor, task *entities.Task, status string) error {
+	if status == task.Status {
+		return nil
+	}
+
+	// A task cannot move forward while tasks blocking it are still open, as in UpdateTask
+	if status != "open" {
+		blockers, err := openBlockers(db, task.ID)
+		if err != nil {
+			return err
+		}
+		if len(blockers) > 0 {
+			return &bulkError{http.StatusConflict, "Task is blocked by open tasks: " + strings.Join(blockers, ", ")}
+		}
+	}
+
+	err := bumpTaskVersion(db, task)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE tasks
+		SET completed_at = CASE WHEN ? <> 'done' THEN NULL WHEN status = 'done' THEN completed_at ELSE UTC_TIMESTAMP() END,
+		    status = ?
+		WHERE id = ?`, status, status, task.ID)
+	return err
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+	calendarUID  = "@tasks-tracker"
+)
+
+// icalPriorities maps task priorities to the iCalendar scale, where 1 is the highest
+var icalPriorities = map[string]string{"urgent": "1", "high": "3", "normal": "5", "low": "9"}
+
+// icalTodoStatuses maps task statuses to VTODO statuses
+var icalTodoStatuses = map[string]string{"open": "NEEDS-ACTION", "in_progress": "IN-PROCESS", "done": "COMPLETED"}
+
+type CalendarModel struct {
+	Db *sql.DB
+}
+
+func CalendarHandler(db *sql.DB) *CalendarModel {
+	return &CalendarModel{
+		Db: db,
+	}
+}
+
+// CreateFeed issues a new calendar feed token for the current user, replacing the previous one
+func (cm *CalendarModel) CreateFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to generate feed token:", err)
+		return
+	}
+	token := hex.EncodeToString(secret)
+
+	_, err = cm.Db.Exec("INSERT INTO calendar_feeds (user_id, token_hash) VALUES (?, ?)"+
+		" ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_at = CURRENT_TIMESTAMP", userID, hashFeedToken(token))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to save feed token:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entities.CalendarFeed{Token: token, URL: "/calendar/" + token + ".ics"})
+}
+
+// DeleteFeed revokes the current user's calendar feed
+func (cm *CalendarModel) DeleteFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	result, err := cm.Db.Exec("DELETE FROM calendar_feeds WHERE user_id = ?", userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to delete feed token:", err)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFeed serves the calendar of the feed's owner. The token in the URL takes the place of the
+// Authorization header, which calendar clients cannot send. Every task is an all-day event on its
+// date and, when it has a due date, also a to-do due on that day. Unchanged feeds answer
+// If-None-Match with 304 Not Modified.
+func (cm *CalendarModel) GetFeed(w http.ResponseWriter, r *http.Request, token string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	var userID string
+	err := cm.Db.QueryRow("SELECT user_id FROM calendar_feeds WHERE token_hash = ?", hashFeedToken(token)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to look up feed token:", err)
+		}
+		return
+	}
+
+	components, err := cm.feedComponents(userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load calendar tasks:", err)
+		return
+	}
+
+	var calendar bytes.Buffer
+	err = services.WriteICalendar(&calendar, "Tasks", components)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to write calendar:", err)
+		return
+	}
+
+	sum := sha256.Sum256(calendar.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, err = w.Write(calendar.Bytes())
+	if err != nil {
+		log.Println("Failed to write response:", err)
+	}
+}
+
+// feedComponents lists the visible tasks dated or due from CALENDAR_PAST_DAYS ago onwards
+func (cm *CalendarModel) feedComponents(userID string) ([]services.ICalComponent, error) {
+	since := time.Now().UTC().AddDate(0, 0, -int(config.GetenvInt("CALENDAR_PAST_DAYS", 30))).Format(mysqlDate)
+	visible, args := visibleTasksFilter("t", userID)
+	args = append(args, since, since)
+
+	rows, err := cm.Db.Query("SELECT t.id, t.summary, t.date, t.status, t.priority, t.due_date, t.completed_at, t.version,"+
+		" (SELECT MAX(r.created_at) FROM task_revisions r WHERE r.task_id = t.id)"+
+		" FROM tasks t WHERE "+visible+" AND (t.date >= ? OR t.due_date >= ?) ORDER BY t.date, t.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	components := []services.ICalComponent{}
+	for rows.Next() {
+		var task entities.Task
+		var dueDate, completedAt, changedAt sql.NullString
+		err := rows.Scan(&task.ID, &task.Summary, &task.Date, &task.Status, &task.Priority, &dueDate, &completedAt,
+			&task.Version, &changedAt)
+		if err != nil {
+			return nil, err
+		}
+		task.DueDate = dueDate.String
+		task.CompletedAt = completedAt.String
+		components = append(components, TaskCalendarComponents(task, changedAt.String)...)
+	}
+	return components, rows.Err()
+}
+
+// TaskCalendarComponents turns a task into an all-day VEVENT on its date and, when it has a due date,
+// a VTODO due that day. changedAt is when the task last changed, used as the DTSTAMP.
+func TaskCalendarComponents(task entities.Task, changedAt string) []services.ICalComponent {
+	stamp := icalTimestamp(changedAt)
+	if stamp == "" {
+		stamp = strings.ReplaceAll(task.Date, "-", "") + "T000000Z"
+	}
+	sequence := fmt.Sprint(task.Version - 1)
+	if task.Version < 1 {
+		sequence = "0"
+	}
+	description := services.ICalText("Status: " + task.Status + "\nPriority: " + task.Priority)
+
+	components := []services.ICalComponent{}
+	if date, err := time.Parse(mysqlDate, task.Date); err == nil {
+		components = append(components, services.ICalComponent{Name: "VEVENT", Properties: []services.ICalProperty{
+			{Name: "UID", Value: "task-" + task.ID + calendarUID},
+			{Name: "DTSTAMP", Value: stamp},
+			{Name: "SEQUENCE", Value: sequence},
+			{Name: "DTSTART;VALUE=DATE", Value: date.Format(icalDate)},
+			{Name: "DTEND;VALUE=DATE", Value: date.AddDate(0, 0, 1).Format(icalDate)},
+			{Name: "SUMMARY", Value: services.ICalText(task.Summary)},
+			{Name: "DESCRIPTION", Value: description},
+			{Name: "TRANSP", Value: "TRANSPARENT"},
+		}})
+	}
+
+	if due, err := time.Parse(mysqlDate, task.DueDate); err == nil {
+		todo := services.ICalComponent{Name: "VTODO", Properties: []services.ICalProperty{
+			{Name: "UID", Value: "due-" + task.ID + calendarUID},
+			{Name: "DTSTAMP", Value: stamp},
+			{Name: "SEQUENCE", Value: sequence},
+			{Name: "DUE;VALUE=DATE", Value: due.Format(icalDate)},
+			{Name: "SUMMARY", Value: services.ICalText(task.Summary)},
+			{Name: "DESCRIPTION", Value: description},
+			{Name: "PRIORITY", Value: icalPriorities[task.Priority]},
+			{Name: "STATUS", Value: icalTodoStatuses[task.Status]},
+		}}
+		if completed := icalTimestamp(task.CompletedAt); completed != "" && task.Status == "done" {
+			todo.Properties = append(todo.Properties, services.ICalProperty{Name: "COMPLETED", Value: completed})
+		}
+		components = append(components, todo)
+	}
+	return components
+}
+
+// icalTimestamp converts a MySQL UTC datetime to an iCalendar UTC date-time, or "" when it is not one
+func icalTimestamp(value string) string {
+	parsed, err := time.Parse(mysqlDateTime, value)
+	if err != nil {
+		return ""
+	}
+	return parsed.Format(icalDateTime)
+}
+
+// hashFeedToken hashes a feed token for storage, so a leaked database does not leak the feeds
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,370 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const customFieldColumns = "id, name, label, type, options, required, created_at"
+
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+var customFieldTypes = map[string]bool{
+	"text":   true,
+	"number": true,
+	"enum":   true,
+	"date":   true,
+}
+
+type CustomFieldModel struct {
+	Db *sql.DB
+}
+
+func CustomFieldHandler(db *sql.DB) *CustomFieldModel {
+	return &CustomFieldModel{
+		Db: db,
+	}
+}
+
+func (cm *CustomFieldModel) CreateCustomField(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage custom fields"); !ok {
+		return
+	}
+
+	field := entities.CustomField{}
+	err := json.NewDecoder(r.Body).Decode(&field)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	if !customFieldName.MatchString(field.Name) {
+		http.Error(w, "name must start with a letter and contain only lower-case letters, digits and underscores", http.StatusBadRequest)
+		return
+	}
+	if field.Label == "" {
+		field.Label = field.Name
+	}
+	if !cm.validateDefinition(w, field) {
+		return
+	}
+
+	var count int
+	err = cm.Db.QueryRow("SELECT COUNT(*) FROM custom_fields WHERE name = ?", field.Name).Scan(&count)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to query custom fields:", err)
+		return
+	}
+	if count > 0 {
+		http.Error(w, "A custom field with this name already exists", http.StatusConflict)
+		return
+	}
+
+	options, err := json.Marshal(field.Options)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize options:", err)
+		return
+	}
+
+	field.ID = uuid.New().String()
+	insertQuery := "INSERT INTO custom_fields (id, name, label, type, options, required) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = cm.Db.Exec(insertQuery, field.ID, field.Name, field.Label, field.Type, options, field.Required)
+	if err != nil {
+		http.Error(w, "Custom field creation failed", http.StatusInternalServerError)
+		log.Println("Custom field creation failed:", err)
+		return
+	}
+
+	created, err := scanCustomField(cm.Db.QueryRow("SELECT "+customFieldColumns+" FROM custom_fields WHERE id = ?", field.ID))
+	if err != nil {
+		http.Error(w, "Failed to retrieve created custom field", http.StatusInternalServerError)
+		log.Println("Failed to retrieve created custom field:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (cm *CustomFieldModel) GetCustomFields(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	fields, err := loadCustomFields(cm.Db)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving custom fields:", err)
+		return
+	}
+
+	list := []entities.CustomField{}
+	for _, field := range fields {
+		list = append(list, field)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// UpdateCustomField changes the label, options or required flag of a field; its name and type are fixed
+func (cm *CustomFieldModel) UpdateCustomField(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage custom fields"); !ok {
+		return
+	}
+
+	field, err := scanCustomField(cm.Db.QueryRow("SELECT "+customFieldColumns+" FROM custom_fields WHERE id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Custom field not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan custom field:", err)
+		}
+		return
+	}
+
+	patchField := make(map[string]interface{})
+	err = json.NewDecoder(r.Body).Decode(&patchField)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Decoding failed:", err)
+		return
+	}
+
+	if label, ok := patchField["label"].(string); ok {
+		field.Label = label
+	}
+	if required, ok := patchField["required"].(bool); ok {
+		field.Required = required
+	}
+	if rawOptions, ok := patchField["options"]; ok {
+		options, ok := interfaceStrings(rawOptions)
+		if !ok {
+			http.Error(w, "options must be a list of strings", http.StatusBadRequest)
+			return
+		}
+		field.Options = options
+	}
+
+	if !cm.validateDefinition(w, field) {
+		return
+	}
+
+	options, err := json.Marshal(field.Options)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize options:", err)
+		return
+	}
+
+	_, err = cm.Db.Exec("UPDATE custom_fields SET label = ?, options = ?, required = ? WHERE id = ?",
+		field.Label, options, field.Required, id)
+	if err != nil {
+		http.Error(w, "Custom field update failed", http.StatusInternalServerError)
+		log.Println("Failed to update custom field:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, field)
+}
+
+func (cm *CustomFieldModel) DeleteCustomField(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage custom fields"); !ok {
+		return
+	}
+
+	// Stored values are removed together with the field by the foreign key
+	result, err := cm.Db.Exec("DELETE FROM custom_fields WHERE id = ?", id)
+	if err != nil {
+		http.Error(w, "Custom field deletion failed", http.StatusInternalServerError)
+		log.Println("Custom field deletion failed:", err)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Custom field not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{
+		Message: "Custom field deleted successfully",
+	})
+}
+
+func (cm *CustomFieldModel) validateDefinition(w http.ResponseWriter, field entities.CustomField) bool {
+	if !customFieldTypes[field.Type] {
+		http.Error(w, "type must be one of text, number, enum or date", http.StatusBadRequest)
+		return false
+	}
+	if field.Type == "enum" && len(field.Options) == 0 {
+		http.Error(w, "enum fields need at least one option", http.StatusBadRequest)
+		return false
+	}
+	if field.Type != "enum" && len(field.Options) > 0 {
+		http.Error(w, "options are only allowed on enum fields", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// loadCustomFields returns all field definitions keyed by name
+func loadCustomFields(db *sql.DB) (map[string]entities.CustomField, error) {
+	rows, err := db.Query("SELECT " + customFieldColumns + " FROM custom_fields")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	fields := make(map[string]entities.CustomField)
+	for rows.Next() {
+		field, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields[field.Name] = field
+	}
+	return fields, rows.Err()
+}
+
+// ValidateCustomFieldValues checks submitted values against the field definitions and returns
+// them keyed by field ID in their stored string form. A null value clears the field. With
+// requireAll every required field has to be present, as on task creation.
+func ValidateCustomFieldValues(fields map[string]entities.CustomField, values map[string]interface{}, requireAll bool) (map[string]*string, error) {
+	stored := make(map[string]*string)
+	for name, value := range values {
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom field %q", name)
+		}
+
+		if value == nil {
+			if field.Required {
+				return nil, fmt.Errorf("custom field %q is required", name)
+			}
+			stored[field.ID] = nil
+			continue
+		}
+
+		str, err := formatCustomFieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		stored[field.ID] = &str
+	}
+
+	if requireAll {
+		for name, field := range fields {
+			if field.Required && stored[field.ID] == nil {
+				return nil, fmt.Errorf("custom field %q is required", name)
+			}
+		}
+	}
+
+	return stored, nil
+}
+
+func formatCustomFieldValue(field entities.CustomField, value interface{}) (string, error) {
+	switch field.Type {
+	case "number":
+		number, ok := value.(float64)
+		if !ok {
+			return "", fmt.Errorf("custom field %q must be a number", field.Name)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case "date":
+		date, ok := value.(string)
+		if !ok || !isDate(date) {
+			return "", fmt.Errorf("custom field %q must be a date in YYYY-MM-DD format", field.Name)
+		}
+		return date, nil
+	case "enum":
+		option, ok := value.(string)
+		if ok {
+			for _, allowed := range field.Options {
+				if option == allowed {
+					return option, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("custom field %q must be one of %v", field.Name, field.Options)
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("custom field %q must be text", field.Name)
+		}
+		if len(text) > 255 {
+			return "", fmt.Errorf("custom field %q must be at most 255 characters", field.Name)
+		}
+		return text, nil
+	}
+}
+
+// setTaskCustomFields stores validated values, deleting the ones set to null
+func setTaskCustomFields(db dbExecutor, taskID string, values map[string]*string) error {
+	for fieldID, value := range values {
+		var err error
+		if value == nil {
+			_, err = db.Exec("DELETE FROM task_custom_field_values WHERE task_id = ? AND field_id = ?", taskID, fieldID)
+		} else {
+			_, err = db.Exec("INSERT INTO task_custom_field_values (task_id, field_id, value) VALUES (?, ?, ?)"+
+				" ON DUPLICATE KEY UPDATE value = VALUES(value)", taskID, fieldID, *value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanCustomField(row rowScanner) (entities.CustomField, error) {
+	var field entities.CustomField
+	var options []byte
+	err := row.Scan(&field.ID, &field.Name, &field.Label, &field.Type, &options, &field.Required, &field.CreatedAt)
+	if err != nil {
+		return field, err
+	}
+	if len(options) > 0 {
+		err = json.Unmarshal(options, &field.Options)
+	}
+	return field, err
+}
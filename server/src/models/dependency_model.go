@@ -0,0 +1,345 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+type DependencyModel struct {
+	Db *sql.DB
+}
+
+func DependencyHandler(db *sql.DB) *DependencyModel {
+	return &DependencyModel{
+		Db: db,
+	}
+}
+
+// AddDependency records that the task cannot start before the blocked_by task is done
+func (dm *DependencyModel) AddDependency(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	var input struct {
+		BlockedBy string `json:"blocked_by"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+	if input.BlockedBy == "" {
+		http.Error(w, "Missing required fields: blocked_by", http.StatusBadRequest)
+		return
+	}
+	if input.BlockedBy == taskID {
+		http.Error(w, "A task cannot block itself", http.StatusBadRequest)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, dm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+	blocker, ok := loadAccessibleTask(w, dm.Db, input.BlockedBy, userID, managerID)
+	if !ok {
+		return
+	}
+
+	// The new edge closes a cycle when the task already blocks the blocker, directly or transitively
+	var cycles int
+	err = dm.Db.QueryRow(`WITH RECURSIVE upstream AS (
+			SELECT blocked_by_id AS id FROM task_dependencies WHERE task_id = ?
+			UNION
+			SELECT d.blocked_by_id FROM task_dependencies d JOIN upstream u ON d.task_id = u.id
+		)
+		SELECT COUNT(*) FROM upstream WHERE id = ?`, blocker.ID, task.ID).Scan(&cycles)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to check dependency cycle:", err)
+		return
+	}
+	if cycles > 0 {
+		http.Error(w, "Dependency would create a cycle", http.StatusConflict)
+		return
+	}
+
+	_, err = dm.Db.Exec("INSERT IGNORE INTO task_dependencies (task_id, blocked_by_id) VALUES (?, ?)", task.ID, blocker.ID)
+	if err != nil {
+		http.Error(w, "Dependency creation failed", http.StatusInternalServerError)
+		log.Println("Dependency creation failed:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entities.TaskDependency{TaskID: task.ID, BlockedBy: blocker.ID})
+}
+
+func (dm *DependencyModel) RemoveDependency(w http.ResponseWriter, r *http.Request, taskID string, blockerID string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, dm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	result, err := dm.Db.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocked_by_id = ?", task.ID, blockerID)
+	if err != nil {
+		http.Error(w, "Dependency deletion failed", http.StatusInternalServerError)
+		log.Println("Dependency deletion failed:", err)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Dependency not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{
+		Message: "Dependency removed successfully",
+	})
+}
+
+// GetDependencyGraph returns every task upstream (blockers) and downstream (dependents) of the task.
+// Tasks the user may not see are included as restricted nodes without their summary.
+func (dm *DependencyModel) GetDependencyGraph(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, dm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	rows, err := dm.Db.Query(`WITH RECURSIVE
+			upstream AS (
+				SELECT task_id, blocked_by_id FROM task_dependencies WHERE task_id = ?
+				UNION
+				SELECT d.task_id, d.blocked_by_id FROM task_dependencies d JOIN upstream u ON d.task_id = u.blocked_by_id
+			),
+			downstream AS (
+				SELECT task_id, blocked_by_id FROM task_dependencies WHERE blocked_by_id = ?
+				UNION
+				SELECT d.task_id, d.blocked_by_id FROM task_dependencies d JOIN downstream s ON d.blocked_by_id = s.task_id
+			)
+		SELECT task_id, blocked_by_id FROM upstream
+		UNION
+		SELECT task_id, blocked_by_id FROM downstream`, task.ID, task.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving dependencies:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	graph := entities.DependencyGraph{TaskID: task.ID, Nodes: []entities.DependencyNode{}, Edges: []entities.TaskDependency{}}
+	nodeIDs := map[string]bool{task.ID: true}
+	for rows.Next() {
+		var edge entities.TaskDependency
+		err := rows.Scan(&edge.TaskID, &edge.BlockedBy)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		graph.Edges = append(graph.Edges, edge)
+		nodeIDs[edge.TaskID] = true
+		nodeIDs[edge.BlockedBy] = true
+	}
+
+	graph.Nodes, err = dm.loadNodes(nodeIDs, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving dependency nodes:", err)
+		return
+	}
+
+	// Drop the edges leading to trashed tasks, which are not part of the graph
+	live := make(map[string]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		live[node.ID] = true
+	}
+	edges := []entities.TaskDependency{}
+	for _, edge := range graph.Edges {
+		if live[edge.TaskID] && live[edge.BlockedBy] {
+			edges = append(edges, edge)
+		}
+	}
+	graph.Edges = edges
+
+	writeJSON(w, http.StatusOK, graph)
+}
+
+func (dm *DependencyModel) loadNodes(ids map[string]bool, userID string) ([]entities.DependencyNode, error) {
+	visibleClause, args := visibleTasksFilter("t", userID)
+	placeholders := make([]string, 0, len(ids))
+	for id := range ids {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+
+	rows, err := dm.Db.Query("SELECT t.id, t.summary, t.status, t.due_date, t.due_date < CURDATE() AND t.status <> 'done', "+visibleClause+
+		" FROM tasks t WHERE t.id IN ("+strings.Join(placeholders, ", ")+") AND t.deleted_at IS NULL ORDER BY t.date", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	nodes := []entities.DependencyNode{}
+	for rows.Next() {
+		var node entities.DependencyNode
+		var dueDate sql.NullString
+		var overdue sql.NullBool
+		var visible bool
+		err := rows.Scan(&node.ID, &node.Summary, &node.Status, &dueDate, &overdue, &visible)
+		if err != nil {
+			return nil, err
+		}
+		node.DueDate = dueDate.String
+		node.Overdue = overdue.Bool
+		if !visible {
+			node.Summary = ""
+			node.Restricted = true
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
+// openBlockers returns the IDs of the tasks blocking the task that are not done yet
+func openBlockers(db dbExecutor, taskID string) ([]string, error) {
+	rows, err := db.Query("SELECT t.id FROM task_dependencies d JOIN tasks t ON t.id = d.blocked_by_id"+
+		" WHERE d.task_id = ? AND t.status <> 'done' AND t.deleted_at IS NULL", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	var blockers []string
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, id)
+	}
+	return blockers, rows.Err()
+}
+
+// FindOverdueBlockers returns open blockers whose due date has passed and whose manager has not
+// been warned about that due date yet. Each warning is recorded with RecordOverdueBlockerWarning once
+// it has been published.
+func FindOverdueBlockers(db *sql.DB) ([]entities.OverdueBlocker, error) {
+	rows, err := db.Query(`SELECT t.id, t.summary, t.due_date, t.user_id, m.manager_id,
+			GROUP_CONCAT(DISTINCT d.task_id ORDER BY d.task_id)
+		FROM tasks t
+		JOIN task_dependencies d ON d.blocked_by_id = t.id
+		JOIN tasks blocked ON blocked.id = d.task_id AND blocked.status <> 'done' AND blocked.deleted_at IS NULL
+		JOIN managers m ON m.technician_id = t.user_id
+		LEFT JOIN overdue_blocker_warnings ow ON ow.task_id = t.id AND ow.due_date = t.due_date
+		WHERE t.status <> 'done' AND t.due_date < CURDATE() AND t.deleted_at IS NULL AND ow.task_id IS NULL
+		GROUP BY t.id, t.summary, t.due_date, t.user_id, m.manager_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	var blockers []entities.OverdueBlocker
+	for rows.Next() {
+		var blocker entities.OverdueBlocker
+		var blocked string
+		err := rows.Scan(&blocker.TaskID, &blocker.Summary, &blocker.DueDate, &blocker.TechnicianID, &blocker.ManagerID, &blocked)
+		if err != nil {
+			return nil, err
+		}
+		blocker.BlockedTasks = strings.Split(blocked, ",")
+		blockers = append(blockers, blocker)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return blockers, nil
+}
+
+// RecordOverdueBlockerWarning stops the blocker being reported again for the same due date
+func RecordOverdueBlockerWarning(db *sql.DB, blocker entities.OverdueBlocker) error {
+	_, err := db.Exec("INSERT IGNORE INTO overdue_blocker_warnings (task_id, due_date) VALUES (?, ?)", blocker.TaskID, blocker.DueDate)
+	return err
+}
+
+// PublishOverdueBlocker announces the warning on the event stream, where it reaches the manager and
+// the technician who owns the blocking task
+func PublishOverdueBlocker(events *services.EventBus, blocker entities.OverdueBlocker) {
+	if events == nil {
+		return
+	}
+	data, err := json.Marshal(struct {
+		Type string `json:"type"`
+		entities.OverdueBlocker
+		OccurredAt string `json:"occurred_at"`
+	}{taskOverdueBlocker, blocker, time.Now().UTC().Format(time.RFC3339)})
+	if err != nil {
+		log.Println("Failed to serialize overdue blocker event:", err)
+		return
+	}
+	events.Publish(taskOverdueBlocker, blocker.TaskID, data)
+}
@@ -0,0 +1,537 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/http"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const (
+	defaultDigestPageSize = 20
+	maxDigestPageSize     = 100
+)
+
+var (
+	//go:embed mail/digest.txt
+	digestTextSource string
+	//go:embed mail/digest.html
+	digestHTMLSource string
+
+	digestTextTemplate = texttemplate.Must(texttemplate.New("digest.txt").Parse(digestTextSource))
+	digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Parse(digestHTMLSource))
+)
+
+var digestFrequencies = map[string]bool{"off": true, "daily": true, "weekly": true}
+
+// defaultDigestSettings apply to managers who never changed their digest settings
+var defaultDigestSettings = entities.DigestSettings{Frequency: "off", SendHour: 7, SendWeekday: int(time.Monday), Timezone: "UTC", Email: true}
+
+type DigestModel struct {
+	Db *sql.DB
+}
+
+func DigestHandler(db *sql.DB) *DigestModel {
+	return &DigestModel{
+		Db: db,
+	}
+}
+
+// GetDigestSettings returns the current manager's digest settings
+func (dm *DigestModel) GetDigestSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers receive digests")
+	if !ok {
+		return
+	}
+
+	settings, err := loadDigestSettings(dm.Db, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load digest settings:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// UpdateDigestSettings changes the current manager's digest settings. Fields left out keep their
+// value. The first digest under a new schedule covers the period ending at the next send time.
+func (dm *DigestModel) UpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers receive digests")
+	if !ok {
+		return
+	}
+
+	settings, err := loadDigestSettings(dm.Db, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load digest settings:", err)
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&settings)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	location, err := ValidateDigestSettings(settings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var lastSentAt interface{}
+	settings.LastSentAt = ""
+	if settings.Frequency != "off" {
+		settings.LastSentAt = LatestDigestTime(settings, location, time.Now()).UTC().Format(mysqlDateTime)
+		lastSentAt = settings.LastSentAt
+	}
+
+	_, err = dm.Db.Exec(`INSERT INTO digest_settings (manager_id, frequency, send_hour, send_weekday, timezone, email, last_sent_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE frequency = VALUES(frequency), send_hour = VALUES(send_hour), send_weekday = VALUES(send_weekday),
+			timezone = VALUES(timezone), email = VALUES(email), last_sent_at = VALUES(last_sent_at)`,
+		userID, settings.Frequency, settings.SendHour, settings.SendWeekday, settings.Timezone, settings.Email, lastSentAt)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to save digest settings:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// GetDigests lists the current manager's stored digests, newest first
+func (dm *DigestModel) GetDigests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers receive digests")
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r.URL.Query().Get("limit"), r.URL.Query().Get("offset"), defaultDigestPageSize, maxDigestPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := dm.Db.Query("SELECT id, frequency, period_start, period_end, subject, emailed, created_at FROM digest_reports"+
+		" WHERE manager_id = ? ORDER BY period_end DESC, id LIMIT ? OFFSET ?", userID, limit, offset)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error fetching digests:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	reports := []entities.DigestReport{}
+	for rows.Next() {
+		var report entities.DigestReport
+		err := rows.Scan(&report.ID, &report.Frequency, &report.PeriodStart, &report.PeriodEnd, &report.Subject, &report.Emailed,
+			&report.CreatedAt)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan digest:", err)
+			return
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error fetching digests:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// GetDigest returns a stored digest as JSON, or its rendered body with format=html or format=text
+func (dm *DigestModel) GetDigest(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers receive digests")
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "html" && format != "text" {
+		http.Error(w, "format must be json, html or text", http.StatusBadRequest)
+		return
+	}
+
+	var report entities.DigestReport
+	var digest []byte
+	err := dm.Db.QueryRow("SELECT id, frequency, period_start, period_end, subject, emailed, created_at, text_body, html_body, digest"+
+		" FROM digest_reports WHERE id = ? AND manager_id = ?", id, userID).Scan(&report.ID, &report.Frequency, &report.PeriodStart,
+		&report.PeriodEnd, &report.Subject, &report.Emailed, &report.CreatedAt, &report.Text, &report.HTML, &digest)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Digest not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to load digest:", err)
+		}
+		return
+	}
+
+	switch format {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err = w.Write([]byte(report.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = w.Write([]byte(report.Text))
+	default:
+		report.Digest = &entities.Digest{}
+		err = json.Unmarshal(digest, report.Digest)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to read digest:", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	}
+	if err != nil {
+		log.Println("Failed to write response:", err)
+	}
+}
+
+func loadDigestSettings(db *sql.DB, managerID string) (entities.DigestSettings, error) {
+	settings := defaultDigestSettings
+	var lastSentAt sql.NullString
+	err := db.QueryRow("SELECT frequency, send_hour, send_weekday, timezone, email, last_sent_at FROM digest_settings WHERE manager_id = ?",
+		managerID).Scan(&settings.Frequency, &settings.SendHour, &settings.SendWeekday, &settings.Timezone, &settings.Email, &lastSentAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultDigestSettings, nil
+	}
+	settings.LastSentAt = lastSentAt.String
+	return settings, err
+}
+
+// ValidateDigestSettings checks the settings and returns the location of their timezone
+func ValidateDigestSettings(settings entities.DigestSettings) (*time.Location, error) {
+	if !digestFrequencies[settings.Frequency] {
+		return nil, errors.New("frequency must be one of off, daily or weekly")
+	}
+	if settings.SendHour < 0 || settings.SendHour > 23 {
+		return nil, errors.New("send_hour must be between 0 and 23")
+	}
+	if settings.SendWeekday < 0 || settings.SendWeekday > 6 {
+		return nil, errors.New("send_weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil || settings.Timezone == "" || settings.Timezone == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", settings.Timezone)
+	}
+	return location, nil
+}
+
+// LatestDigestTime returns the last scheduled send time at or before now, which ends the period
+// of the digest due at that point
+func LatestDigestTime(settings entities.DigestSettings, location *time.Location, now time.Time) time.Time {
+	local := now.In(location)
+	at := time.Date(local.Year(), local.Month(), local.Day(), settings.SendHour, 0, 0, 0, location)
+	if settings.Frequency == "weekly" {
+		at = at.AddDate(0, 0, -((int(at.Weekday()) - settings.SendWeekday + 7) % 7))
+	}
+	if at.After(local) {
+		at = DigestPeriodStart(settings.Frequency, at)
+	}
+	return at
+}
+
+// DigestPeriodStart returns the start of the period ending at end, a day or a week earlier on the calendar
+func DigestPeriodStart(frequency string, end time.Time) time.Time {
+	if frequency == "weekly" {
+		return end.AddDate(0, 0, -7)
+	}
+	return end.AddDate(0, 0, -1)
+}
+
+// BuildDigest compiles what each of the manager's technicians created and completed between start
+// and end, and which of their tasks were overdue at the end
+func BuildDigest(db *sql.DB, managerID string, frequency string, start time.Time, end time.Time) (entities.Digest, error) {
+	digest := entities.Digest{
+		ManagerID:   managerID,
+		Frequency:   frequency,
+		Timezone:    end.Location().String(),
+		PeriodStart: start.Format(time.RFC3339),
+		PeriodEnd:   end.Format(time.RFC3339),
+		Technicians: []entities.DigestTechnician{},
+	}
+
+	var firstName, lastName string
+	err := db.QueryRow("SELECT first_name, last_name FROM users WHERE id = ?", managerID).Scan(&firstName, &lastName)
+	if err != nil {
+		return digest, err
+	}
+	digest.ManagerName = firstName + " " + lastName
+
+	rows, err := db.Query("SELECT u.id, u.first_name, u.last_name, u.email FROM managers m JOIN users u ON u.id = m.technician_id"+
+		" WHERE m.manager_id = ? ORDER BY u.first_name, u.last_name, u.id", managerID)
+	if err != nil {
+		return digest, err
+	}
+	technicians := make(map[string]int)
+	for rows.Next() {
+		technician := entities.DigestTechnician{Created: []entities.DigestTask{}, Completed: []entities.DigestTask{}, Overdue: []entities.DigestTask{}}
+		err := rows.Scan(&technician.ID, &firstName, &lastName, &technician.Email)
+		if err != nil {
+			_ = rows.Close()
+			return digest, err
+		}
+		technician.Name = firstName + " " + lastName
+		technicians[technician.ID] = len(digest.Technicians)
+		digest.Technicians = append(digest.Technicians, technician)
+	}
+	if err := rows.Close(); err != nil {
+		return digest, err
+	}
+	if err := rows.Err(); err != nil {
+		return digest, err
+	}
+
+	from, to := start.UTC().Format(mysqlDateTime), end.UTC().Format(mysqlDateTime)
+	activity := `SELECT %s AS kind, t.user_id, t.id, t.summary, t.due_date, t.completed_at FROM tasks t
+		JOIN managers m ON m.technician_id = t.user_id %s
+		WHERE m.manager_id = ? AND t.deleted_at IS NULL AND %s`
+	rows, err = db.Query(fmt.Sprintf(activity, "'created'", "JOIN task_revisions r ON r.task_id = t.id AND r.revision = 1 AND r.source = 'create'",
+		"r.created_at >= ? AND r.created_at < ?")+" UNION ALL "+
+		fmt.Sprintf(activity, "'completed'", "", "t.completed_at >= ? AND t.completed_at < ?")+" UNION ALL "+
+		fmt.Sprintf(activity, "'overdue'", "", "t.status <> 'done' AND t.due_date < ?")+" ORDER BY summary, id",
+		managerID, from, to, managerID, from, to, managerID, end.Format(mysqlDate))
+	if err != nil {
+		return digest, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var kind, technicianID string
+		var task entities.DigestTask
+		var dueDate, completedAt sql.NullString
+		err := rows.Scan(&kind, &technicianID, &task.ID, &task.Summary, &dueDate, &completedAt)
+		if err != nil {
+			return digest, err
+		}
+		task.DueDate = dueDate.String
+		task.CompletedAt = completedAt.String
+
+		index, ok := technicians[technicianID]
+		if !ok {
+			// The technician joined the team while the digest was being built
+			continue
+		}
+		technician := &digest.Technicians[index]
+		switch kind {
+		case "created":
+			technician.Created = append(technician.Created, task)
+			digest.Totals.Created++
+		case "completed":
+			technician.Completed = append(technician.Completed, task)
+			digest.Totals.Completed++
+		case "overdue":
+			technician.Overdue = append(technician.Overdue, task)
+			digest.Totals.Overdue++
+		}
+	}
+	return digest, rows.Err()
+}
+
+// RenderDigest renders the subject and the text and HTML bodies of a digest
+func RenderDigest(digest entities.Digest) (string, string, string, error) {
+	start, err := time.Parse(time.RFC3339, digest.PeriodStart)
+	if err != nil {
+		return "", "", "", err
+	}
+	end, err := time.Parse(time.RFC3339, digest.PeriodEnd)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	subject := "Daily digest for " + start.Format("Mon 2 Jan 2006")
+	if digest.Frequency == "weekly" {
+		subject = "Weekly digest for " + start.Format("2 Jan 2006") + " to " + end.Add(-time.Second).Format("2 Jan 2006")
+	}
+	data := struct {
+		entities.Digest
+		Subject string
+		Period  string
+	}{
+		Digest:  digest,
+		Subject: subject,
+		Period:  "From " + start.Format("Mon 2 Jan 2006 15:04") + " to " + end.Format("Mon 2 Jan 2006 15:04") + " (" + digest.Timezone + ")",
+	}
+
+	var text, html bytes.Buffer
+	err = digestTextTemplate.Execute(&text, data)
+	if err != nil {
+		return "", "", "", err
+	}
+	err = digestHTMLTemplate.Execute(&html, data)
+	if err != nil {
+		return "", "", "", err
+	}
+	return subject, text.String(), html.String(), nil
+}
+
+// SendDueDigests compiles the digests whose send time has passed, stores them and emails them when the
+// manager wants email and a mailer is configured. Only the latest missed period is sent. A digest is
+// claimed in the transaction that stores it, so concurrent servers do not send it twice and one that
+// fails to build is tried again on the next check.
+func SendDueDigests(ctx context.Context, db *sql.DB, mailer services.Mailer, now time.Time) (int, error) {
+	type schedule struct {
+		managerID string
+		email     string
+		settings  entities.DigestSettings
+	}
+
+	rows, err := db.Query("SELECT s.manager_id, u.email, s.frequency, s.send_hour, s.send_weekday, s.timezone, s.email, s.last_sent_at" +
+		" FROM digest_settings s JOIN users u ON u.id = s.manager_id WHERE s.frequency <> 'off'")
+	if err != nil {
+		return 0, err
+	}
+	var schedules []schedule
+	for rows.Next() {
+		var s schedule
+		var lastSentAt sql.NullString
+		err := rows.Scan(&s.managerID, &s.email, &s.settings.Frequency, &s.settings.SendHour, &s.settings.SendWeekday,
+			&s.settings.Timezone, &s.settings.Email, &lastSentAt)
+		if err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		s.settings.LastSentAt = lastSentAt.String
+		schedules = append(schedules, s)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, s := range schedules {
+		location, err := ValidateDigestSettings(s.settings)
+		if err != nil {
+			log.Printf("Skipping digest for manager %s: %v\n", s.managerID, err)
+			continue
+		}
+		end := LatestDigestTime(s.settings, location, now)
+		due := end.UTC().Format(mysqlDateTime)
+		if s.settings.LastSentAt >= due {
+			continue
+		}
+
+		claimed, err := sendDigest(ctx, db, mailer, s.managerID, s.email, s.settings, due, DigestPeriodStart(s.settings.Frequency, end), end)
+		if err != nil {
+			log.Printf("Failed to send digest for manager %s: %v\n", s.managerID, err)
+			continue
+		}
+		if claimed {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// sendDigest claims the period ending at due, stores its digest and emails it. It returns false when
+// another server claimed the period first.
+func sendDigest(ctx context.Context, db *sql.DB, mailer services.Mailer, managerID string, email string,
+	settings entities.DigestSettings, due string, start time.Time, end time.Time) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Exec("UPDATE digest_settings SET last_sent_at = ? WHERE manager_id = ? AND (last_sent_at IS NULL OR last_sent_at < ?)",
+		due, managerID, due)
+	if err != nil {
+		return false, err
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return false, err
+	}
+
+	digest, err := BuildDigest(db, managerID, settings.Frequency, start, end)
+	if err != nil {
+		return false, err
+	}
+	subject, text, html, err := RenderDigest(digest)
+	if err != nil {
+		return false, err
+	}
+	digestJSON, err := json.Marshal(digest)
+	if err != nil {
+		return false, err
+	}
+
+	id := uuid.New().String()
+	_, err = tx.Exec("INSERT INTO digest_reports (id, manager_id, frequency, period_start, period_end, subject, text_body, html_body, digest)"+
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", id, managerID, settings.Frequency, start.UTC().Format(mysqlDateTime),
+		end.UTC().Format(mysqlDateTime), subject, text, html, digestJSON)
+	if err != nil {
+		return false, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	if !settings.Email || mailer == nil {
+		return true, nil
+	}
+	// The digest stays available through the API when it cannot be emailed
+	err = mailer.Send(ctx, services.MailMessage{To: email, Subject: subject, Text: text, HTML: html})
+	if err != nil {
+		return true, err
+	}
+	_, err = db.Exec("UPDATE digest_reports SET emailed = TRUE WHERE id = ?", id)
+	return true, err
+}
@@ -0,0 +1,466 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const maxTimesheetDays = 93
+
+type DocumentModel struct {
+	Db    *sql.DB
+	Store services.BlobStore
+}
+
+func DocumentHandler(db *sql.DB, store services.BlobStore) *DocumentModel {
+	return &DocumentModel{
+		Db:    db,
+		Store: store,
+	}
+}
+
+// GetWorkOrder renders a task as a printable work order
+func (dm *DocumentModel) GetWorkOrder(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, dm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	order, err := dm.loadWorkOrder(task)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load work order:", err)
+		return
+	}
+
+	branding, err := loadPDFBranding(r.Context(), dm.Db, dm.Store, organizationID(userID, managerID))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load branding:", err)
+		return
+	}
+
+	writePDF(w, "work-order-"+task.ID+".pdf", func(out io.Writer) error {
+		return WriteWorkOrderPDF(out, branding, order)
+	})
+}
+
+// GetTimesheet renders the time a technician logged over a period, the current week by default.
+// Technicians can get their own timesheet and Managers those of their technicians.
+func (dm *DocumentModel) GetTimesheet(w http.ResponseWriter, r *http.Request, technicianID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	if technicianID != userID {
+		var count int
+		if managerID == "" {
+			err = dm.Db.QueryRow("SELECT COUNT(*) FROM managers WHERE manager_id = ? AND technician_id = ?", userID, technicianID).Scan(&count)
+			if err != nil {
+				http.Error(w, "Something went wrong", http.StatusInternalServerError)
+				log.Println("Failed to check technician:", err)
+				return
+			}
+		}
+		if count == 0 {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+	}
+
+	from, to, err := TimesheetRange(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timesheet, err := dm.loadTimesheet(technicianID, from, to)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to load timesheet:", err)
+		}
+		return
+	}
+
+	branding, err := loadPDFBranding(r.Context(), dm.Db, dm.Store, organizationID(userID, managerID))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load branding:", err)
+		return
+	}
+
+	writePDF(w, fmt.Sprintf("timesheet-%s-%s.pdf", from, to), func(out io.Writer) error {
+		return WriteTimesheetPDF(out, branding, timesheet)
+	})
+}
+
+// TimesheetRange reads the from and to dates of a timesheet. Both default to the bounds of the
+// week, Monday to Sunday, that contains now.
+func TimesheetRange(query url.Values, now time.Time) (string, string, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	end := start.AddDate(0, 0, 6)
+
+	for param, bound := range map[string]*time.Time{"from": &start, "to": &end} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(mysqlDate, value)
+		if err != nil {
+			return "", "", fmt.Errorf("%s must be a date in YYYY-MM-DD format", param)
+		}
+		*bound = parsed
+	}
+
+	if end.Before(start) {
+		return "", "", errors.New("from must not be after to")
+	}
+	if end.Sub(start) >= maxTimesheetDays*24*time.Hour {
+		return "", "", fmt.Errorf("a timesheet can cover at most %d days", maxTimesheetDays)
+	}
+	return start.Format(mysqlDate), end.Format(mysqlDate), nil
+}
+
+func (dm *DocumentModel) loadWorkOrder(task entities.Task) (entities.WorkOrder, error) {
+	tasks := []entities.Task{task}
+	err := loadTaskDetails(dm.Db, tasks)
+	if err != nil {
+		return entities.WorkOrder{}, err
+	}
+	order := entities.WorkOrder{Task: tasks[0], GeneratedAt: time.Now().UTC().Format(mysqlDateTime)}
+
+	err = dm.Db.QueryRow("SELECT CONCAT(first_name, ' ', last_name), email FROM users WHERE id = ?", task.UserID).
+		Scan(&order.Technician, &order.TechnicianEmail)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return order, err
+	}
+	if task.AssetID != "" {
+		err = dm.Db.QueryRow("SELECT name FROM assets WHERE id = ?", task.AssetID).Scan(&order.Asset)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return order, err
+		}
+	}
+	if task.LocationID != "" {
+		err = dm.Db.QueryRow("SELECT name FROM locations WHERE id = ?", task.LocationID).Scan(&order.Location)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return order, err
+		}
+	}
+
+	order.Worklogs, err = dm.queryTimeEntries(`SELECT w.started_at, w.ended_at, w.duration_minutes, w.notes,
+			CONCAT(u.first_name, ' ', u.last_name), w.task_id, ''
+		FROM worklogs w JOIN users u ON u.id = w.user_id
+		WHERE w.task_id = ? ORDER BY w.started_at`, task.ID)
+	if err != nil {
+		return order, err
+	}
+
+	rows, err := dm.Db.Query("SELECT id, task_id, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at FROM task_attachments WHERE task_id = ? ORDER BY created_at", task.ID)
+	if err != nil {
+		return order, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	order.Attachments = []entities.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return order, err
+		}
+		order.Attachments = append(order.Attachments, attachment)
+	}
+	return order, rows.Err()
+}
+
+func (dm *DocumentModel) loadTimesheet(technicianID string, from string, to string) (entities.Timesheet, error) {
+	timesheet := entities.Timesheet{TechnicianID: technicianID, From: from, To: to, GeneratedAt: time.Now().UTC().Format(mysqlDateTime)}
+	err := dm.Db.QueryRow("SELECT CONCAT(first_name, ' ', last_name), email FROM users WHERE id = ?", technicianID).
+		Scan(&timesheet.Technician, &timesheet.Email)
+	if err != nil {
+		return timesheet, err
+	}
+
+	// Running timers are left out until they are stopped and their time is known
+	timesheet.Entries, err = dm.queryTimeEntries(`SELECT w.started_at, w.ended_at, w.duration_minutes, w.notes, '', w.task_id, t.summary
+		FROM worklogs w JOIN tasks t ON t.id = w.task_id
+		WHERE w.user_id = ? AND w.ended_at IS NOT NULL AND w.started_at >= ? AND w.started_at < DATE_ADD(?, INTERVAL 1 DAY)
+		ORDER BY w.started_at`, technicianID, from, to)
+	return timesheet, err
+}
+
+func (dm *DocumentModel) queryTimeEntries(query string, args ...interface{}) ([]entities.TimeEntry, error) {
+	rows, err := dm.Db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	entries := []entities.TimeEntry{}
+	for rows.Next() {
+		var entry entities.TimeEntry
+		var endedAt, notes sql.NullString
+		var minutes sql.NullInt64
+		err := rows.Scan(&entry.StartedAt, &endedAt, &minutes, &notes, &entry.Technician, &entry.TaskID, &entry.TaskSummary)
+		if err != nil {
+			return nil, err
+		}
+		entry.EndedAt = endedAt.String
+		entry.Minutes = minutes.Int64
+		entry.Notes = notes.String
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// writePDF renders a document before sending it, so that a rendering error can still be reported
+func writePDF(w http.ResponseWriter, fileName string, render func(io.Writer) error) {
+	var body bytes.Buffer
+	err := render(&body)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to render PDF:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", fmt.Sprint(body.Len()))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fileName}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	_, err = body.WriteTo(w)
+	if err != nil {
+		log.Println("Failed to write PDF:", err)
+	}
+}
+
+// WriteWorkOrderPDF prints a work order with the task details, checklist, time log and attachments
+func WriteWorkOrderPDF(w io.Writer, branding services.PDFBranding, order entities.WorkOrder) error {
+	task := order.Task
+	report := services.NewPDFReport("Work Order", branding)
+
+	technician := order.Technician
+	if order.TechnicianEmail != "" {
+		technician += " <" + order.TechnicianEmail + ">"
+	}
+	estimate := ""
+	if task.EstimatedMinutes > 0 {
+		estimate = formatMinutes(int64(task.EstimatedMinutes))
+	}
+	report.Fields([][2]string{
+		{"Work order", task.ID},
+		{"Summary", task.Summary},
+		{"Status", task.Status},
+		{"Priority", task.Priority},
+		{"Date", task.Date},
+		{"Due date", task.DueDate},
+		{"Completed at", task.CompletedAt},
+		{"Technician", technician},
+		{"Asset", order.Asset},
+		{"Location", order.Location},
+		{"Estimated time", estimate},
+		{"Tags", strings.Join(task.Tags, ", ")},
+		{"Generated at", order.GeneratedAt + " UTC"},
+	})
+
+	if len(task.CustomFields) > 0 {
+		keys := make([]string, 0, len(task.CustomFields))
+		for key := range task.CustomFields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([][2]string, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, [2]string{key, fmt.Sprint(task.CustomFields[key])})
+		}
+		report.Heading("Details")
+		report.Fields(fields)
+	}
+
+	if len(task.Checklist) > 0 {
+		rows := make([]services.PDFRow, 0, len(task.Checklist))
+		for _, item := range task.Checklist {
+			mark := "[ ]"
+			if item.Done {
+				mark = "[x]"
+			}
+			rows = append(rows, services.PDFRow{Cells: []string{mark, item.Text}})
+		}
+		report.Heading("Checklist")
+		report.Table([]services.PDFColumn{{Title: "Done", Width: 0.1}, {Title: "Item", Width: 0.9}}, rows)
+	}
+
+	report.Heading("Time log")
+	if len(order.Worklogs) == 0 {
+		report.Paragraph("No time has been logged.")
+	} else {
+		var total int64
+		rows := make([]services.PDFRow, 0, len(order.Worklogs)+1)
+		for _, entry := range order.Worklogs {
+			ended, spent := entry.EndedAt, formatMinutes(entry.Minutes)
+			if ended == "" {
+				ended, spent = "running", ""
+			}
+			total += entry.Minutes
+			rows = append(rows, services.PDFRow{Cells: []string{entry.StartedAt, ended, entry.Technician, entry.Notes, spent}})
+		}
+		rows = append(rows, services.PDFRow{Cells: []string{"Total", "", "", "", formatMinutes(total)}, Bold: true})
+		report.Table([]services.PDFColumn{
+			{Title: "Started (UTC)", Width: 0.2},
+			{Title: "Ended (UTC)", Width: 0.2},
+			{Title: "Technician", Width: 0.18},
+			{Title: "Notes", Width: 0.3},
+			{Title: "Time", Width: 0.12, AlignRight: true},
+		}, rows)
+	}
+
+	if len(order.Attachments) > 0 {
+		rows := make([]services.PDFRow, 0, len(order.Attachments))
+		for _, attachment := range order.Attachments {
+			checksum := attachment.Checksum
+			if len(checksum) > 12 {
+				checksum = checksum[:12]
+			}
+			rows = append(rows, services.PDFRow{Cells: []string{attachment.FileName, attachment.ContentType, formatBytes(attachment.Size), checksum}})
+		}
+		report.Heading("Attachments")
+		report.Table([]services.PDFColumn{
+			{Title: "File", Width: 0.42},
+			{Title: "Type", Width: 0.22},
+			{Title: "Size", Width: 0.14, AlignRight: true},
+			{Title: "SHA-256", Width: 0.22},
+		}, rows)
+	}
+
+	report.Signatures([]string{"Technician", "Client"})
+	return report.Write(w)
+}
+
+// WriteTimesheetPDF prints a timesheet with a subtotal for every day and the total for the period
+func WriteTimesheetPDF(w io.Writer, branding services.PDFBranding, timesheet entities.Timesheet) error {
+	report := services.NewPDFReport("Timesheet", branding)
+
+	var total int64
+	for _, entry := range timesheet.Entries {
+		total += entry.Minutes
+	}
+	report.Fields([][2]string{
+		{"Technician", timesheet.Technician},
+		{"Email", timesheet.Email},
+		{"Period", timesheet.From + " to " + timesheet.To},
+		{"Total time", formatMinutes(total)},
+		{"Entries", fmt.Sprint(len(timesheet.Entries))},
+		{"Generated at", timesheet.GeneratedAt + " UTC"},
+	})
+
+	report.Heading("Time entries")
+	if len(timesheet.Entries) == 0 {
+		report.Paragraph("No time was logged in this period.")
+	} else {
+		var rows []services.PDFRow
+		var day string
+		var dayTotal int64
+		closeDay := func() {
+			rows = append(rows, services.PDFRow{Cells: []string{"", "", "", "Total for " + day, "", formatMinutes(dayTotal)}, Bold: true})
+		}
+		for _, entry := range timesheet.Entries {
+			date, start, _ := strings.Cut(entry.StartedAt, " ")
+			if day != "" && date != day {
+				closeDay()
+				dayTotal = 0
+			}
+			day = date
+			dayTotal += entry.Minutes
+
+			_, end, _ := strings.Cut(entry.EndedAt, " ")
+			rows = append(rows, services.PDFRow{Cells: []string{date, shortTime(start), shortTime(end), entry.TaskSummary, entry.Notes, formatMinutes(entry.Minutes)}})
+		}
+		closeDay()
+		rows = append(rows, services.PDFRow{Cells: []string{"Total", "", "", "", "", formatMinutes(total)}, Bold: true})
+
+		report.Table([]services.PDFColumn{
+			{Title: "Date", Width: 0.14},
+			{Title: "Start", Width: 0.09},
+			{Title: "End", Width: 0.09},
+			{Title: "Task", Width: 0.3},
+			{Title: "Notes", Width: 0.26},
+			{Title: "Time", Width: 0.12, AlignRight: true},
+		}, rows)
+		report.Paragraph("Times are in UTC.")
+	}
+
+	report.Signatures([]string{"Technician", "Manager"})
+	return report.Write(w)
+}
+
+// formatMinutes writes a duration as hours and minutes, such as 2:05
+func formatMinutes(minutes int64) string {
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// shortTime drops the seconds of a MySQL time of day
+func shortTime(value string) string {
+	if len(value) > 5 {
+		return value[:5]
+	}
+	return value
+}
+
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
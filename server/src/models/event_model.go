@@ -0,0 +1,195 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// Task event types sent on the event stream
+const (
+	taskCreated = "task.created"
+	taskUpdated = "task.updated"
+	taskDeleted = "task.deleted"
+
+	// taskOverdueBlocker warns that an overdue task is holding up other tasks
+	taskOverdueBlocker = "task.overdue_blocker"
+)
+
+// streamBuffer is how many events a stream may fall behind before it is dropped
+const streamBuffer = 64
+
+type EventModel struct {
+	Db        *sql.DB
+	Events    *services.EventBus
+	Heartbeat time.Duration
+}
+
+func EventHandler(db *sql.DB, events *services.EventBus, heartbeat time.Duration) *EventModel {
+	return &EventModel{
+		Db:        db,
+		Events:    events,
+		Heartbeat: heartbeat,
+	}
+}
+
+// Stream sends the task events of the current user's team as Server-Sent Events: a user's own tasks
+// and, for Managers, those of their technicians within their sites. A client reconnecting with
+// Last-Event-ID gets the events it missed, or a reset event when they are no longer available and
+// it has to reload its tasks.
+func (em *EventModel) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID int64
+	foreign := false
+	if lastEventID != "" {
+		var origin string
+		origin, lastID, err = parseStreamEventID(lastEventID)
+		if err != nil || lastID < 0 {
+			http.Error(w, "Last-Event-ID must be an event ID", http.StatusBadRequest)
+			return
+		}
+		// Each instance numbers the events it delivers, so an ID from another instance, or from before
+		// a restart, cannot tell which events were missed here
+		foreign = origin != em.Events.Origin()
+		if foreign {
+			lastID = 0
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		log.Println("Response writer does not support flushing")
+		return
+	}
+
+	subscription := em.Events.Subscribe(lastID, streamBuffer)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Ask clients to reconnect quickly when the stream is dropped
+	_, err = fmt.Fprint(w, "retry: 3000\n\n")
+	if err == nil && (foreign || !subscription.Complete) {
+		_, err = fmt.Fprintf(w, "id: %s\nevent: reset\ndata: {}\n\n", streamEventID(em.Events, subscription.LastID))
+	}
+	for _, event := range subscription.Missed {
+		if err != nil {
+			break
+		}
+		err = em.send(w, event, userID)
+	}
+	if err != nil {
+		log.Println("Failed to write event stream:", err)
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(em.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The stream fell behind; the client reconnects and resumes from its last event
+				return
+			}
+			err = em.send(w, event, userID)
+		}
+		if err != nil {
+			log.Println("Failed to write event stream:", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// send writes a task event when its task is visible to the user
+func (em *EventModel) send(w http.ResponseWriter, event services.Event, userID string) error {
+	visible := false
+	if strings.HasPrefix(event.Type, "task.") {
+		var err error
+		visible, err = taskVisibleTo(em.Db, event.Subject, userID)
+		if err != nil {
+			return err
+		}
+	}
+	if !visible {
+		// Moving the client's Last-Event-ID past hidden events keeps its resume point within the buffer
+		_, err := fmt.Fprintf(w, "id: %s\n\n", streamEventID(em.Events, event.ID))
+		return err
+	}
+	return WriteServerSentEvent(w, streamEventID(em.Events, event.ID), event)
+}
+
+// streamEventID qualifies an event ID with the bus that numbered it, as origin:id
+func streamEventID(events *services.EventBus, id int64) string {
+	return events.Origin() + ":" + strconv.FormatInt(id, 10)
+}
+
+// parseStreamEventID splits an ID written by streamEventID. A bare number has no origin.
+func parseStreamEventID(value string) (string, int64, error) {
+	origin := ""
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		origin, value = value[:i], value[i+1:]
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	return origin, id, err
+}
+
+// WriteServerSentEvent writes an event in the text/event-stream format under the given ID
+func WriteServerSentEvent(w io.Writer, id string, event services.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event.Type, event.Data)
+	return err
+}
+
+// publishTaskEvent announces a task change to the open event streams
+func publishTaskEvent(events *services.EventBus, eventType string, task entities.Task, actorID string) {
+	if events == nil {
+		return
+	}
+	data, err := json.Marshal(entities.TaskEvent{
+		Type:       eventType,
+		Task:       task,
+		ActorID:    actorID,
+		OccurredAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Println("Failed to serialize task event:", err)
+		return
+	}
+	events.Publish(eventType, task.ID, data)
+}
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// exportColumn is a column that can be chosen for a task export
+type exportColumn struct {
+	expr   string
+	number bool
+}
+
+// exportColumns maps the column names accepted by the export to SQL over tasks t joined with users u
+var exportColumns = map[string]exportColumn{
+	"id":                {expr: "t.id"},
+	"summary":           {expr: "t.summary"},
+	"date":              {expr: "t.date"},
+	"status":            {expr: "t.status"},
+	"priority":          {expr: "t.priority"},
+	"due_date":          {expr: "t.due_date"},
+	"completed_at":      {expr: "t.completed_at"},
+	"estimated_minutes": {expr: "t.estimated_minutes", number: true},
+	"logged_minutes": {expr: "(SELECT SUM(wl.duration_minutes) FROM worklogs wl WHERE wl.task_id = t.id AND wl.ended_at IS NOT NULL)",
+		number: true},
+	"technician_id":    {expr: "t.user_id"},
+	"technician":       {expr: "CONCAT(u.first_name, ' ', u.last_name)"},
+	"technician_email": {expr: "u.email"},
+	"asset_id":         {expr: "t.asset_id"},
+	"location_id":      {expr: "t.location_id"},
+	"template_id":      {expr: "t.template_id"},
+	"tags":             {expr: "(SELECT GROUP_CONCAT(tg.tag ORDER BY tg.tag SEPARATOR ', ') FROM task_tags tg WHERE tg.task_id = t.id)"},
+}
+
+var defaultExportColumns = []string{"id", "date", "summary", "technician", "status", "priority", "due_date", "completed_at",
+	"estimated_minutes", "logged_minutes", "tags"}
+
+type ExportModel struct {
+	Db *sql.DB
+}
+
+func ExportHandler(db *sql.DB) *ExportModel {
+	return &ExportModel{
+		Db: db,
+	}
+}
+
+// ExportTasks streams the visible tasks as CSV or XLSX. It takes the listing filters plus user_id,
+// format (csv or xlsx) and columns, a comma separated list of column names or field.<name> for
+// custom fields.
+func (em *ExportModel) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		http.Error(w, "format must be csv or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	columns := defaultExportColumns
+	if rawColumns := query.Get("columns"); rawColumns != "" {
+		columns = strings.Split(rawColumns, ",")
+	}
+	selects, selectArgs, numbers, err := exportSelect(columns)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	where, args, err := taskFilters(query, "t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filterUserID := query.Get("user_id"); filterUserID != "" {
+		where = append(where, "t.user_id = ?")
+		args = append(args, filterUserID)
+	}
+	visible, visibleArgs := visibleTasksFilter("t", userID)
+	where = append([]string{visible}, where...)
+	args = append(append(selectArgs, visibleArgs...), args...)
+
+	rows, err := em.Db.Query("SELECT "+strings.Join(selects, ", ")+" FROM tasks t JOIN users u ON u.id = t.user_id WHERE "+
+		strings.Join(where, " AND ")+" ORDER BY t.date, t.id", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error exporting tasks:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	var writer exportWriter
+	if format == "xlsx" {
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.xlsx"`)
+		writer, err = services.NewXLSXWriter(w, "Tasks")
+	} else {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.csv"`)
+		writer = &csvExportWriter{writer: csv.NewWriter(w)}
+	}
+
+	header := make([]services.XLSXCell, len(columns))
+	for i, column := range columns {
+		header[i] = services.XLSXCell{Value: column}
+	}
+	if err == nil {
+		err = writer.WriteRow(header)
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for err == nil && rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			break
+		}
+		cells := make([]services.XLSXCell, len(columns))
+		for i, value := range values {
+			cells[i] = services.XLSXCell{Value: value.String, Number: numbers[i]}
+		}
+		err = writer.WriteRow(cells)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// The status line has been sent already, so the export can only be cut short
+		log.Println("Failed to export tasks:", err)
+	}
+}
+
+// exportSelect resolves the requested columns to SQL expressions and whether each one is numeric
+func exportSelect(columns []string) ([]string, []interface{}, []bool, error) {
+	selects := make([]string, len(columns))
+	numbers := make([]bool, len(columns))
+	var args []interface{}
+	for i, name := range columns {
+		if fieldName := strings.TrimPrefix(name, customFieldFilterPrefix); fieldName != name && fieldName != "" {
+			selects[i] = "(SELECT cv.value FROM task_custom_field_values cv JOIN custom_fields cf ON cf.id = cv.field_id" +
+				" WHERE cv.task_id = t.id AND cf.name = ?)"
+			args = append(args, fieldName)
+			continue
+		}
+		column, ok := exportColumns[name]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+		selects[i] = column.expr
+		numbers[i] = column.number
+	}
+	return selects, args, numbers, nil
+}
+
+// exportWriter writes the rows of an export in one of the supported formats
+type exportWriter interface {
+	WriteRow(cells []services.XLSXCell) error
+	Close() error
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (cw *csvExportWriter) WriteRow(cells []services.XLSXCell) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = cell.Value
+		if !cell.Number {
+			record[i] = EscapeSpreadsheetFormula(cell.Value)
+		}
+	}
+	return cw.writer.Write(record)
+}
+
+func (cw *csvExportWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// EscapeSpreadsheetFormula prefixes text that a spreadsheet would run as a formula with a quote
+func EscapeSpreadsheetFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/christianotieno/tasks-traker-app/server/src/config"
@@ -134,35 +135,43 @@ func (tm *UserModel) GetUserByEmail(email string) (*entities.UserJSON, error) {
 }
 
 func (tm *UserModel) validateUser(user entities.UserJSON, w http.ResponseWriter) error {
+	if message := tm.checkUser(user); message != "" {
+		return httpError(w, http.StatusBadRequest, message)
+	}
+	return nil
+}
+
+// checkUser applies the sign-up rules to a user and returns why it is rejected, or "" when it is valid
+func (tm *UserModel) checkUser(user entities.UserJSON) string {
 	if user.FirstName == "" {
-		return httpError(w, http.StatusBadRequest, "Missing required fields: first_name")
+		return "Missing required fields: first_name"
 	}
 
 	if user.LastName == "" {
-		return httpError(w, http.StatusBadRequest, "Missing required fields: last_name")
+		return "Missing required fields: last_name"
 	}
 
 	if user.Email == "" {
-		return httpError(w, http.StatusBadRequest, "Missing required fields: email")
+		return "Missing required fields: email"
 	}
 
 	if user.Password == "" {
-		return httpError(w, http.StatusBadRequest, "Missing password")
+		return "Missing password"
 	}
 
 	if len(user.Password) < 6 {
-		return httpError(w, http.StatusBadRequest, "Password must be at least 6 characters")
+		return "Password must be at least 6 characters"
 	}
 
 	if !strings.Contains(user.Email, "@") {
-		return httpError(w, http.StatusBadRequest, "Invalid email address")
+		return "Invalid email address"
 	}
 
 	if tm.userExists(user.Email) {
-		return httpError(w, http.StatusBadRequest, "Email already exists, please try again with a different email")
+		return "Email already exists, please try again with a different email"
 	}
 
-	return nil
+	return ""
 }
 
 func httpError(w http.ResponseWriter, statusCode int, message string) error {
@@ -170,3 +179,234 @@ func httpError(w http.ResponseWriter, statusCode int, message string) error {
 	log.Println(message)
 	return errors.New(message)
 }
+
+// userFromContext returns the authenticated user ID and, for Technicians, their manager ID
+func userFromContext(r *http.Request) (string, string, error) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		return "", "", errors.New("failed to retrieve userID from context")
+	}
+
+	managerID, ok := r.Context().Value("managerID").(string)
+	if !ok {
+		return "", "", errors.New("failed to retrieve managerID from context")
+	}
+
+	return userID, managerID, nil
+}
+
+// requireManager returns the ID of the authenticated Manager, answering with message and 403 for
+// Technicians
+func requireManager(w http.ResponseWriter, r *http.Request, message string) (string, bool) {
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return "", false
+	}
+
+	// Check if the user is a "Manager"
+	if managerID != "" {
+		http.Error(w, message, http.StatusForbidden)
+		return "", false
+	}
+	return userID, true
+}
+
+// writeJSON serializes v and writes it with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	responseJSON, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize response:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		log.Println("Failed to write response:", err)
+	}
+}
+
+// dbExecutor is implemented by both *sql.DB and *sql.Tx so helpers can run inside a transaction
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// inputError is a validation failure whose message can be shown to the client
+type inputError string
+
+func (e inputError) Error() string {
+	return string(e)
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// nullString maps an empty string to a SQL NULL
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullInt maps zero to a SQL NULL
+func nullInt(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// taskETag identifies the current version of a task
+func taskETag(task entities.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// checkIfMatch enforces an If-Match header against the task version, answering 412 when the client
+// edited an outdated copy. Requests without the header are not checked.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, task entities.Task) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	etag := taskETag(task)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	w.Header().Set("ETag", etag)
+	http.Error(w, "Task was modified by someone else", http.StatusPreconditionFailed)
+	return false
+}
+
+// ETagMatches reports whether an If-None-Match header matches etag. Weak validators match too, as
+// If-None-Match uses the weak comparison.
+func ETagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// findTask loads a single task by its ID, treating trashed tasks as missing
+func findTask(db dbExecutor, id string) (entities.Task, error) {
+	return scanTask(db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ? AND deleted_at IS NULL", id))
+}
+
+// canAccessTask reports whether the user owns the task or manages its owner.
+// Managers restricted to specific sites only see tasks located within those sites.
+func canAccessTask(db dbExecutor, task entities.Task, userID string, managerID string) (bool, error) {
+	if task.UserID == userID {
+		return true, nil
+	}
+
+	// Technicians can only access their own tasks
+	if managerID != "" {
+		return false, nil
+	}
+
+	clause, args := visibleTasksFilter("t", userID)
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM tasks t WHERE t.id = ? AND "+clause,
+		append([]interface{}{task.ID}, args...)...).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// canDeleteTask reports whether the user is the Manager of the technician who owns the task and,
+// when the Manager has sites assigned, the task is at one of them. Trashed tasks are included so
+// restoring applies the same rule.
+func canDeleteTask(db dbExecutor, task entities.Task, userID string, managerID string) (bool, error) {
+	if managerID != "" {
+		return false, nil
+	}
+	clause, args := teamTasksFilter("t", userID)
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM tasks t WHERE t.id = ? AND t.user_id IN (SELECT technician_id FROM managers WHERE manager_id = ?) AND "+clause,
+		append([]interface{}{task.ID, userID}, args...)...).Scan(&count)
+	return count > 0, err
+}
+
+// canUpdateTask reports whether the user is the technician who owns the task, the rule UpdateTask applies
+func canUpdateTask(task entities.Task, userID string, managerID string) bool {
+	return managerID != "" && task.UserID == userID
+}
+
+// loadAccessibleTask loads the task and checks the current user may access it,
+// writing the matching error response when it cannot be used
+func loadAccessibleTask(w http.ResponseWriter, db *sql.DB, taskID string, userID string, managerID string) (entities.Task, bool) {
+	task, err := findTask(db, taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan task:", err)
+		}
+		return task, false
+	}
+
+	allowed, err := canAccessTask(db, task, userID, managerID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to check task access:", err)
+		return task, false
+	}
+	if !allowed {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return task, false
+	}
+
+	return task, true
+}
+
+// visibleUsersClause restricts column to the current user and, for Managers, their technicians.
+// It expects the current user ID to be bound twice.
+func visibleUsersClause(column string) string {
+	return "(" + column + " = ? OR " + column + " IN (SELECT technician_id FROM managers WHERE manager_id = ?))"
+}
+
+// visibleTasksFilter restricts a query over tasks aliased as alias to the tasks the current user may see:
+// their own tasks or those of their technicians, limited to the manager's sites when any are assigned.
+// Tasks without a location are not subject to site restrictions. Trashed tasks are never visible.
+func visibleTasksFilter(alias string, userID string) (string, []interface{}) {
+	clause, args := teamTasksFilter(alias, userID)
+	return alias + ".deleted_at IS NULL AND " + clause, args
+}
+
+// teamTasksFilter is visibleTasksFilter including trashed tasks
+func teamTasksFilter(alias string, userID string) (string, []interface{}) {
+	clause := visibleUsersClause(alias+".user_id") + ` AND (
+		NOT EXISTS (SELECT 1 FROM manager_sites WHERE manager_id = ?)
+		OR ` + alias + `.location_id IS NULL
+		OR ` + alias + `.location_id IN (
+			WITH RECURSIVE scope AS (
+				SELECT location_id AS id FROM manager_sites WHERE manager_id = ?
+				UNION ALL
+				SELECT l.id FROM locations l JOIN scope s ON l.parent_id = s.id
+			)
+			SELECT id FROM scope))`
+	return clause, []interface{}{userID, userID, userID, userID}
+}
+
+// taskVisibleTo reports whether the task, trashed or not, belongs to the user's team
+func taskVisibleTo(db *sql.DB, taskID string, userID string) (bool, error) {
+	clause, args := teamTasksFilter("t", userID)
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM tasks t WHERE t.id = ? AND "+clause, append([]interface{}{taskID}, args...)...).Scan(&count)
+	return count > 0, err
+}
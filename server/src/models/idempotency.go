@@ -0,0 +1,152 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are stored with a response and sent again when it is replayed
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// idempotencyWindowHours is how long a key and its stored response are kept
+func idempotencyWindowHours() int64 {
+	return config.GetenvInt("IDEMPOTENCY_WINDOW_HOURS", 24)
+}
+
+// withIdempotencyKey runs handle at most once per user and key within the window. The first request
+// reserves the key and its response is stored; repeats with the same body get the stored response,
+// while a different body under the same key is rejected with 422. Server errors release the key so
+// the request can be retried.
+func withIdempotencyKey(db *sql.DB, w http.ResponseWriter, r *http.Request, userID string, key string, body []byte, handle func(w http.ResponseWriter)) {
+	if len(key) > maxIdempotencyKeyLength {
+		http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+	requestHash := hex.EncodeToString(sum[:])
+
+	reserved, err := reserveIdempotencyKey(db, userID, key, requestHash)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to reserve idempotency key:", err)
+		return
+	}
+	if !reserved {
+		replayIdempotentResponse(db, w, userID, key, requestHash)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	handle(recorder)
+
+	if recorder.status >= http.StatusInternalServerError {
+		_, err = db.Exec("DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ?", userID, key)
+	} else {
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		encodedHeaders, _ := json.Marshal(headers)
+		_, err = db.Exec("UPDATE idempotency_keys SET status_code = ?, headers = ?, response_body = ? WHERE user_id = ? AND idem_key = ?",
+			recorder.status, encodedHeaders, recorder.body.Bytes(), userID, key)
+	}
+	if err != nil {
+		log.Println("Failed to store idempotent response:", err)
+	}
+}
+
+// reserveIdempotencyKey claims the key for a new request, returning false when it is already taken
+func reserveIdempotencyKey(db *sql.DB, userID string, key string, requestHash string) (bool, error) {
+	_, err := db.Exec("DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ? AND created_at < UTC_TIMESTAMP() - INTERVAL ? HOUR",
+		userID, key, idempotencyWindowHours())
+	if err != nil {
+		return false, err
+	}
+
+	_, err = db.Exec("INSERT INTO idempotency_keys (user_id, idem_key, request_hash, created_at) VALUES (?, ?, ?, UTC_TIMESTAMP())",
+		userID, key, requestHash)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func replayIdempotentResponse(db *sql.DB, w http.ResponseWriter, userID string, key string, requestHash string) {
+	var storedHash string
+	var status sql.NullInt64
+	var headers, body []byte
+	err := db.QueryRow("SELECT request_hash, status_code, headers, response_body FROM idempotency_keys WHERE user_id = ? AND idem_key = ?",
+		userID, key).Scan(&storedHash, &status, &headers, &body)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load idempotent response:", err)
+		return
+	}
+
+	if storedHash != requestHash {
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+		return
+	}
+	if !status.Valid {
+		http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+		return
+	}
+
+	stored := make(map[string]string)
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &stored); err != nil {
+			log.Println("Failed to decode stored headers:", err)
+		}
+	}
+	for name, value := range stored {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(status.Int64))
+	_, err = w.Write(body)
+	if err != nil {
+		log.Println("Failed to write response:", err)
+	}
+}
+
+// PurgeIdempotencyKeys removes keys older than the idempotency window
+func PurgeIdempotencyKeys(db *sql.DB) (int64, error) {
+	result, err := db.Exec("DELETE FROM idempotency_keys WHERE created_at < UTC_TIMESTAMP() - INTERVAL ? HOUR", idempotencyWindowHours())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	rr.body.Write(data)
+	return rr.ResponseWriter.Write(data)
+}
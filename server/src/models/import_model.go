@@ -0,0 +1,624 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const (
+	maxImportRows = 50000
+	// maxReportedImportErrors caps the errors listed in the report; the error file has all of them
+	maxReportedImportErrors = 100
+	importMappingPrefix     = "map."
+)
+
+// importFields lists the fields each kind of import reads and whether they are required. Task
+// imports also accept field.<name> for custom fields and need technician_email or user_id.
+var importFields = map[string]map[string]bool{
+	"tasks": {
+		"summary":           true,
+		"date":              true,
+		"technician_email":  false,
+		"user_id":           false,
+		"status":            false,
+		"due_date":          false,
+		"completed_at":      false,
+		"priority":          false,
+		"estimated_minutes": false,
+		"tags":              false,
+		"asset_id":          false,
+		"location_id":       false,
+	},
+	"users": {
+		"first_name":    true,
+		"last_name":     true,
+		"email":         true,
+		"password":      true,
+		"manager_id":    false,
+		"manager_email": false,
+	},
+}
+
+type ImportModel struct {
+	Db *sql.DB
+}
+
+func ImportHandler(db *sql.DB) *ImportModel {
+	return &ImportModel{
+		Db: db,
+	}
+}
+
+// ImportOptions describes a CSV import run by a manager. Mapping maps a field to the CSV column
+// holding it; fields that are not mapped are read from a column of the same name.
+type ImportOptions struct {
+	Kind      string
+	Mapping   map[string]string
+	DryRun    bool
+	ManagerID string
+	RequestID string
+	IP        string
+}
+
+// Import reads a CSV file of tasks or users from the request body. Columns are mapped with
+// map.<field>=<column> query parameters and dry_run=true only validates the rows.
+func (im *ImportModel) Import(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can import data")
+	if !ok {
+		return
+	}
+
+	requestID, _ := r.Context().Value("requestID").(string)
+	query := r.URL.Query()
+	options := ImportOptions{
+		Kind:      kind,
+		Mapping:   ImportMapping(query),
+		DryRun:    query.Get("dry_run") == "true",
+		ManagerID: userID,
+		RequestID: requestID,
+		IP:        ClientIP(r),
+	}
+
+	body := http.MaxBytesReader(w, r.Body, config.GetenvInt("IMPORT_MAX_BYTES", 20<<20))
+	report, err := RunImport(im.Db, options, body)
+	if err != nil {
+		var invalid inputError
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &invalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &tooLarge):
+			http.Error(w, "Import file is too large", http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, "Import failed", http.StatusInternalServerError)
+			log.Println("Import failed:", err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GetImportErrors serves the rejected rows of an import as CSV with an added error column
+func (im *ImportModel) GetImportErrors(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can import data")
+	if !ok {
+		return
+	}
+
+	var errorsCSV []byte
+	err := im.Db.QueryRow("SELECT errors_csv FROM imports WHERE id = ? AND created_by = ?", id, userID).Scan(&errorsCSV)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Import not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to load import errors:", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-`+id+`-errors.csv"`)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(errorsCSV)
+	if err != nil {
+		log.Println("Failed to write response:", err)
+	}
+}
+
+// ImportMapping reads map.<field>=<column> query parameters
+func ImportMapping(query url.Values) map[string]string {
+	mapping := make(map[string]string)
+	for param := range query {
+		if field := strings.TrimPrefix(param, importMappingPrefix); field != param && field != "" {
+			mapping[field] = query.Get(param)
+		}
+	}
+	return mapping
+}
+
+// ResolveImportColumns finds the column index of every field read by the import. Mapped columns have
+// to exist and required fields have to be present.
+func ResolveImportColumns(kind string, header []string, mapping map[string]string) (map[string]int, error) {
+	fields, ok := importFields[kind]
+	if !ok {
+		return nil, inputError("import kind must be tasks or users")
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, seen := positions[name]; !seen {
+			positions[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, column := range mapping {
+		if !fields[field] && !importsField(kind, field) {
+			return nil, inputError(fmt.Sprintf("unknown field %q in mapping", field))
+		}
+		index, ok := positions[column]
+		if !ok {
+			return nil, inputError(fmt.Sprintf("column %q mapped to %s is not in the file", column, field))
+		}
+		columns[field] = index
+	}
+	for name, index := range positions {
+		if _, mapped := columns[name]; !mapped && importsField(kind, name) {
+			columns[name] = index
+		}
+	}
+
+	for field, required := range fields {
+		if _, ok := columns[field]; required && !ok {
+			return nil, inputError("Missing required column: " + field)
+		}
+	}
+	if kind == "tasks" {
+		_, byEmail := columns["technician_email"]
+		_, byID := columns["user_id"]
+		if !byEmail && !byID {
+			return nil, inputError("Missing required column: technician_email or user_id")
+		}
+	}
+	return columns, nil
+}
+
+func importsField(kind string, field string) bool {
+	if _, ok := importFields[kind][field]; ok {
+		return true
+	}
+	return kind == "tasks" && strings.HasPrefix(field, customFieldFilterPrefix) && len(field) > len(customFieldFilterPrefix)
+}
+
+// RunImport validates every row of a CSV file and, unless it is a dry run, saves the valid rows in a
+// single transaction. The report and its error file are stored so the rejected rows can be downloaded
+// later. Problems with the file as a whole are returned as errors.
+func RunImport(db *sql.DB, options ImportOptions, input io.Reader) (entities.ImportReport, error) {
+	report := entities.ImportReport{Kind: options.Kind, DryRun: options.DryRun, Errors: []entities.ImportRowError{}}
+
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return report, inputError("The file is empty")
+		}
+		return report, importReadError(err)
+	}
+
+	columns, err := ResolveImportColumns(options.Kind, header, options.Mapping)
+	if err != nil {
+		return report, err
+	}
+
+	imp := &importer{
+		db:         db,
+		options:    options,
+		columns:    columns,
+		source:     auditSource{actorID: options.ManagerID, requestID: options.RequestID, ip: options.IP},
+		userIDs:    make(map[string]string),
+		fileEmails: make(map[string]bool),
+	}
+	if options.Kind == "tasks" {
+		imp.fields, err = loadCustomFields(db)
+		if err != nil {
+			return report, err
+		}
+		for field := range columns {
+			name := strings.TrimPrefix(field, customFieldFilterPrefix)
+			if _, ok := imp.fields[name]; name != field && !ok {
+				return report, inputError(fmt.Sprintf("unknown custom field %q", name))
+			}
+		}
+	}
+
+	var errorFile bytes.Buffer
+	errorWriter := csv.NewWriter(&errorFile)
+	err = errorWriter.Write(append(append([]string{}, header...), "error"))
+	if err != nil {
+		return report, err
+	}
+
+	var valid []interface{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, importReadError(err)
+		}
+		if blankRecord(record) {
+			continue
+		}
+		report.Total++
+		if report.Total > maxImportRows {
+			return report, inputError(fmt.Sprintf("An import can have at most %d rows", maxImportRows))
+		}
+
+		line, _ := reader.FieldPos(0)
+		var item interface{}
+		if options.Kind == "tasks" {
+			item, err = imp.prepareTask(record)
+		} else {
+			item, err = imp.prepareUser(record)
+		}
+		var invalid inputError
+		if errors.As(err, &invalid) {
+			report.Rejected++
+			if len(report.Errors) < maxReportedImportErrors {
+				report.Errors = append(report.Errors, entities.ImportRowError{Line: line, Message: err.Error()})
+			}
+			err = errorWriter.Write(append(imp.redacted(record), err.Error()))
+		}
+		if err != nil {
+			return report, err
+		}
+		if item != nil {
+			valid = append(valid, item)
+		}
+	}
+	report.Valid = len(valid)
+
+	if !options.DryRun && len(valid) > 0 {
+		err = imp.save(valid)
+		if err != nil {
+			return report, err
+		}
+		report.Imported = len(valid)
+	}
+
+	errorWriter.Flush()
+	if err := errorWriter.Error(); err != nil {
+		return report, err
+	}
+	if report.Rejected > 0 {
+		report.ErrorsCSV = errorFile.Bytes()
+	}
+
+	report.ID = uuid.New().String()
+	_, err = db.Exec("INSERT INTO imports (id, kind, created_by, dry_run, total, imported, rejected, errors_csv) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		report.ID, report.Kind, options.ManagerID, report.DryRun, report.Total, report.Imported, report.Rejected, report.ErrorsCSV)
+	return report, err
+}
+
+// importReadError reports malformed CSV to the client while passing read failures through
+func importReadError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return inputError("The file is not valid CSV: " + parseErr.Error())
+	}
+	return err
+}
+
+func blankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// importer validates and saves the rows of one import
+type importer struct {
+	db      *sql.DB
+	options ImportOptions
+	columns map[string]int
+	source  auditSource
+	fields  map[string]entities.CustomField
+	// userIDs caches looked up users by email or ID, and holds the users created by the import
+	userIDs map[string]string
+	// fileEmails holds the emails of the valid user rows seen so far
+	fileEmails map[string]bool
+}
+
+type importedTask struct {
+	task         entities.Task
+	tags         []string
+	customFields map[string]*string
+}
+
+type importedUser struct {
+	user         entities.UserJSON
+	managerEmail string
+}
+
+func (imp *importer) value(record []string, field string) string {
+	index, ok := imp.columns[field]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+// prepareTask checks a task row with the same rules as CreateTask. Historical tasks may carry their
+// completion time, and belong to one of the importing manager's technicians.
+func (imp *importer) prepareTask(record []string) (interface{}, error) {
+	task := entities.Task{
+		Summary:     imp.value(record, "summary"),
+		Date:        imp.value(record, "date"),
+		Status:      imp.value(record, "status"),
+		DueDate:     imp.value(record, "due_date"),
+		CompletedAt: imp.value(record, "completed_at"),
+		Priority:    imp.value(record, "priority"),
+		AssetID:     imp.value(record, "asset_id"),
+		LocationID:  imp.value(record, "location_id"),
+	}
+	if task.Summary == "" {
+		return nil, inputError("Missing required fields: summary")
+	}
+	if len(task.Summary) > 255 {
+		return nil, inputError("summary must be at most 255 characters")
+	}
+	if !isDate(task.Date) {
+		return nil, inputError("date must be a date in YYYY-MM-DD format")
+	}
+
+	userID, err := imp.technicianID(imp.value(record, "technician_email"), imp.value(record, "user_id"))
+	if err != nil {
+		return nil, err
+	}
+	task.UserID = userID
+
+	if minutes := imp.value(record, "estimated_minutes"); minutes != "" {
+		task.EstimatedMinutes, err = strconv.Atoi(minutes)
+		if err != nil {
+			return nil, inputError("estimated_minutes must be a whole number")
+		}
+	}
+
+	if task.CompletedAt != "" {
+		if task.Status != "done" {
+			return nil, inputError("completed_at can only be given for done tasks")
+		}
+		if isDate(task.CompletedAt) {
+			task.CompletedAt += " 00:00:00"
+		} else if _, err := time.Parse(mysqlDateTime, task.CompletedAt); err != nil {
+			return nil, inputError("completed_at must be a date or a date and time in YYYY-MM-DD HH:MM:SS format")
+		}
+	}
+
+	for _, tag := range strings.Split(imp.value(record, "tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+
+	task.CustomFields = make(map[string]interface{})
+	for column := range imp.columns {
+		name := strings.TrimPrefix(column, customFieldFilterPrefix)
+		raw := imp.value(record, column)
+		if name == column || raw == "" {
+			continue
+		}
+		if imp.fields[name].Type != "number" {
+			task.CustomFields[name] = raw
+			continue
+		}
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, inputError(fmt.Sprintf("custom field %q must be a number", name))
+		}
+		task.CustomFields[name] = number
+	}
+
+	tags, customFields, err := validateNewTask(imp.db, &task, imp.fields)
+	if err != nil {
+		return nil, err
+	}
+	return importedTask{task: task, tags: tags, customFields: customFields}, nil
+}
+
+// technicianID resolves the technician of a task row, who has to be one of the importing manager's technicians
+func (imp *importer) technicianID(email string, userID string) (string, error) {
+	key := userID
+	if email != "" {
+		key = strings.ToLower(email)
+	}
+	if key == "" {
+		return "", inputError("Missing required fields: technician_email or user_id")
+	}
+	if id, ok := imp.userIDs[key]; ok {
+		if id == "" {
+			return "", inputError("Technician " + key + " is not one of your technicians")
+		}
+		return id, nil
+	}
+
+	var id string
+	err := imp.db.QueryRow("SELECT u.id FROM users u JOIN managers m ON m.technician_id = u.id"+
+		" WHERE m.manager_id = ? AND (u.email = ? OR u.id = ?)", imp.options.ManagerID, email, userID).Scan(&id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	imp.userIDs[key] = id
+	if id == "" {
+		return "", inputError("Technician " + key + " is not one of your technicians")
+	}
+	return id, nil
+}
+
+// redacted copies a rejected row for the error file with its password blanked, since the file is stored
+// and can be downloaded again
+func (imp *importer) redacted(record []string) []string {
+	row := append([]string{}, record...)
+	if index, ok := imp.columns["password"]; ok && index < len(row) {
+		row[index] = ""
+	}
+	return row
+}
+
+// prepareUser checks a user row with the same rules as sign-up. The manager may be given by ID or
+// by email, including a user created earlier in the same file.
+func (imp *importer) prepareUser(record []string) (interface{}, error) {
+	user := entities.UserJSON{
+		FirstName: imp.value(record, "first_name"),
+		LastName:  imp.value(record, "last_name"),
+		Email:     imp.value(record, "email"),
+		Password:  imp.value(record, "password"),
+		ManagerID: imp.value(record, "manager_id"),
+	}
+	managerEmail := strings.ToLower(imp.value(record, "manager_email"))
+
+	userModel := UserModel{Db: imp.db}
+	if message := userModel.checkUser(user); message != "" {
+		return nil, inputError(message)
+	}
+	if len(user.FirstName) > 50 || len(user.LastName) > 50 || len(user.Email) > 100 {
+		return nil, inputError("Names must be at most 50 characters and emails at most 100 characters")
+	}
+	email := strings.ToLower(user.Email)
+	if imp.fileEmails[email] {
+		return nil, inputError("Email appears more than once in the file")
+	}
+
+	if user.ManagerID != "" && managerEmail != "" {
+		return nil, inputError("Only one of manager_id and manager_email can be given")
+	}
+	if user.ManagerID != "" {
+		var count int
+		err := imp.db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", user.ManagerID).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, inputError("Manager " + user.ManagerID + " not found")
+		}
+	}
+	if managerEmail != "" && !imp.fileEmails[managerEmail] {
+		if _, ok := imp.userIDs[managerEmail]; !ok {
+			var id string
+			err := imp.db.QueryRow("SELECT id FROM users WHERE email = ?", managerEmail).Scan(&id)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			imp.userIDs[managerEmail] = id
+		}
+		if imp.userIDs[managerEmail] == "" {
+			return nil, inputError("Manager " + managerEmail + " not found")
+		}
+	}
+
+	imp.fileEmails[email] = true
+	return importedUser{user: user, managerEmail: managerEmail}, nil
+}
+
+// save writes the valid rows in one transaction, so either all of them are imported or none
+func (imp *importer) save(items []interface{}) error {
+	tx, err := imp.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		switch item := item.(type) {
+		case importedTask:
+			err = imp.saveTask(tx, item)
+		case importedUser:
+			err = imp.saveUser(tx, item)
+		}
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println("Failed to roll back import:", rollbackErr)
+			}
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// saveTask inserts a task like CreateTask, without announcing it on Kafka since it is historical
+func (imp *importer) saveTask(tx *sql.Tx, item importedTask) error {
+	id, err := insertTask(tx, item.task, item.tags, item.customFields)
+	if err != nil {
+		return err
+	}
+	task, err := findTaskWithDetails(tx, id)
+	if err != nil {
+		return err
+	}
+	err = insertRevision(tx, task, imp.options.ManagerID, "create", 0)
+	if err != nil {
+		return err
+	}
+	writeAudit(tx, imp.source, "create", "task", id, nil, task)
+	return nil
+}
+
+func (imp *importer) saveUser(tx *sql.Tx, item importedUser) error {
+	user := item.user
+	if item.managerEmail != "" {
+		user.ManagerID = imp.userIDs[item.managerEmail]
+	}
+
+	userModel := UserModel{Db: imp.db}
+	hashedPassword, err := userModel.hashPassword([]byte(user.Password))
+	if err != nil {
+		return err
+	}
+	userID, err := insertUser(tx, user.FirstName, user.LastName, user.Email, hashedPassword, user.ManagerID)
+	if err != nil {
+		return err
+	}
+	imp.userIDs[strings.ToLower(user.Email)] = userID
+
+	writeAudit(tx, imp.source, "create", "user", userID, nil, entities.User{
+		ID:        userID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		ManagerID: user.ManagerID,
+	})
+	return nil
+}
@@ -0,0 +1,638 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const (
+	liveMaxMessage     = 16 << 10
+	liveClientBuffer   = 64
+	liveHubBuffer      = 1024
+	livePingInterval   = 30 * time.Second
+	liveReadTimeout    = 75 * time.Second
+	liveWriteTimeout   = 10 * time.Second
+	liveCommentsShown  = 50
+	maxCommentLength   = 2000
+	livePresenceWindow = "INTERVAL 90 SECOND"
+)
+
+// Live event types carried on the event bus, so that clients connected to other instances get them
+const (
+	liveComment  = "live.comment"
+	livePresence = "live.presence"
+)
+
+type LiveModel struct {
+	Db     *sql.DB
+	Events *services.EventBus
+	Hub    *LiveHub
+}
+
+func LiveHandler(db *sql.DB, events *services.EventBus, hub *LiveHub) *LiveModel {
+	return &LiveModel{
+		Db:     db,
+		Events: events,
+		Hub:    hub,
+	}
+}
+
+// liveUpgrader accepts connections from any origin: the channel is authorized by the access token,
+// never by cookies, so another site cannot open it on a user's behalf
+var liveUpgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// liveClient is a WebSocket connection to a task's live channel
+type liveClient struct {
+	id        string
+	userID    string
+	managerID string
+	send      chan []byte
+}
+
+// Connect opens a WebSocket channel on a task for everyone who can see it. Clients get the task,
+// its recent comments and its viewers, then every change, comment and presence update. Checklist
+// ticks sent on the channel follow the rules of UpdateTask: only the technician who owns the task
+// may make them.
+func (lm *LiveModel) Connect(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, lm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+	if task.DeletedAt != "" {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	task, err = findTaskWithDetails(lm.Db, task.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load task details:", err)
+		return
+	}
+
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has answered the request already
+		log.Println("WebSocket handshake failed:", err)
+		return
+	}
+	conn.SetReadLimit(liveMaxMessage)
+	// The connection fails when the client sends nothing, not even a pong, for liveReadTimeout
+	_ = conn.SetReadDeadline(time.Now().Add(liveReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(liveReadTimeout))
+	})
+
+	client := &liveClient{id: uuid.New().String(), userID: userID, managerID: managerID, send: make(chan []byte, liveClientBuffer)}
+	lm.Hub.join(task, client)
+	defer lm.leave(task.ID, client)
+	lm.touchViewer(task.ID, client)
+	lm.Events.Publish(livePresence, task.ID, nil)
+
+	snapshot, err := lm.snapshot(task, client)
+	if err == nil {
+		_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		err = conn.WriteMessage(websocket.TextMessage, snapshot)
+	}
+	if err != nil {
+		log.Println("Failed to send live snapshot:", err)
+		closeLive(conn, websocket.CloseGoingAway)
+		return
+	}
+
+	go lm.writeMessages(conn, task.ID, client)
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(liveReadTimeout))
+		if messageType != websocket.TextMessage {
+			closeLive(conn, websocket.CloseUnsupportedData)
+			break
+		}
+		lm.handle(r, task.ID, client, message)
+	}
+	closeLive(conn, websocket.CloseNormalClosure)
+}
+
+// writeMessages sends the client's messages and pings it until its channel is closed. It is the
+// only writer of data messages; control messages may be sent alongside it.
+func (lm *LiveModel) writeMessages(conn *websocket.Conn, taskID string, client *liveClient) {
+	ticker := time.NewTicker(livePingInterval)
+	defer ticker.Stop()
+	for {
+		var err error
+		select {
+		case message, ok := <-client.send:
+			if !ok {
+				closeLive(conn, websocket.CloseNormalClosure)
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			err = conn.WriteMessage(websocket.TextMessage, message)
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout))
+			lm.touchViewer(taskID, client)
+		}
+		if err != nil {
+			// Closing the connection ends the read loop, which removes the client
+			closeLive(conn, websocket.CloseGoingAway)
+			return
+		}
+	}
+}
+
+// closeLive sends a close message with the code and closes the connection
+func closeLive(conn *websocket.Conn, code int) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(liveWriteTimeout))
+	_ = conn.Close()
+}
+
+// leave removes a client that disconnected and tells the other viewers
+func (lm *LiveModel) leave(taskID string, client *liveClient) {
+	lm.Hub.leave(taskID, client)
+	_, err := lm.Db.Exec("DELETE FROM task_viewers WHERE connection_id = ?", client.id)
+	if err != nil {
+		log.Println("Failed to remove task viewer:", err)
+	}
+	lm.Events.Publish(livePresence, taskID, nil)
+}
+
+// touchViewer records that the client is still viewing the task
+func (lm *LiveModel) touchViewer(taskID string, client *liveClient) {
+	_, err := lm.Db.Exec("INSERT INTO task_viewers (connection_id, task_id, user_id, seen_at) VALUES (?, ?, ?, UTC_TIMESTAMP())"+
+		" ON DUPLICATE KEY UPDATE seen_at = UTC_TIMESTAMP()", client.id, taskID, client.userID)
+	if err != nil {
+		log.Println("Failed to record task viewer:", err)
+	}
+}
+
+func (lm *LiveModel) snapshot(task entities.Task, client *liveClient) ([]byte, error) {
+	viewers, err := loadViewers(lm.Db, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := lm.recentComments(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(entities.LiveMessage{Type: "snapshot", Task: &task, Viewers: viewers, Comments: comments,
+		CanEdit: canUpdateTask(task, client.userID, client.managerID)})
+}
+
+func (lm *LiveModel) recentComments(taskID string) ([]entities.TaskComment, error) {
+	rows, err := lm.Db.Query(`SELECT c.id, c.task_id, c.user_id, CONCAT(u.first_name, ' ', u.last_name), c.body, c.created_at
+		FROM task_comments c JOIN users u ON u.id = c.user_id
+		WHERE c.task_id = ? ORDER BY c.created_at DESC, c.id LIMIT ?`, taskID, liveCommentsShown)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	comments := []entities.TaskComment{}
+	for rows.Next() {
+		var comment entities.TaskComment
+		err := rows.Scan(&comment.ID, &comment.TaskID, &comment.UserID, &comment.Author, &comment.Text, &comment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	// Oldest first, as they are shown
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+	return comments, rows.Err()
+}
+
+// handle carries out a request from a client, answering it with an error message when it fails
+func (lm *LiveModel) handle(r *http.Request, taskID string, client *liveClient, message []byte) {
+	var request entities.LiveRequest
+	err := json.Unmarshal(message, &request)
+	if err == nil {
+		switch request.Type {
+		case "checklist":
+			err = lm.tickChecklist(r, taskID, client, request.Index, request.Done)
+		case "comment":
+			err = lm.postComment(taskID, client, request.Text)
+		default:
+			err = inputError("type must be checklist or comment")
+		}
+	} else {
+		err = inputError("Messages must be JSON objects")
+	}
+	if err == nil {
+		return
+	}
+
+	var invalid inputError
+	if !errors.As(err, &invalid) {
+		log.Println("Live request failed:", err)
+		invalid = "Something went wrong"
+	}
+	reply, _ := json.Marshal(entities.LiveMessage{Type: "error", Message: string(invalid)})
+	lm.Hub.sendTo(taskID, client, reply)
+}
+
+// tickChecklist checks or unchecks a checklist item as UpdateTask would, recording a revision and an audit entry
+func (lm *LiveModel) tickChecklist(r *http.Request, taskID string, client *liveClient, index int, done bool) error {
+	task, err := findTaskWithDetails(lm.Db, taskID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && task.DeletedAt != "") {
+		return inputError("Task not found")
+	}
+	if err != nil {
+		return err
+	}
+	if !canUpdateTask(task, client.userID, client.managerID) {
+		return inputError("Only the task owner can update this task")
+	}
+	if index < 0 || index >= len(task.Checklist) {
+		return inputError("index must point to a checklist item")
+	}
+	if task.Checklist[index].Done == done {
+		return nil
+	}
+
+	checklist := append([]entities.ChecklistItem(nil), task.Checklist...)
+	checklist[index].Done = done
+
+	// The tick and its revision are stored together, so the history never misses a change
+	tx, err := lm.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec("UPDATE tasks SET version = version + 1 WHERE id = ? AND version = ?", task.ID, task.Version)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return inputError("Task was modified by someone else")
+	}
+	err = setTaskChecklist(tx, task.ID, checklist)
+	if err != nil {
+		return err
+	}
+
+	updated, err := findTaskWithDetails(tx, task.ID)
+	if err != nil {
+		return err
+	}
+	err = recordRevision(tx, task, updated, client.userID, "live", 0)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	recordAudit(lm.Db, r, "update", "task", task.ID, task, updated)
+	publishTaskEvent(lm.Events, taskUpdated, updated, client.userID)
+	return nil
+}
+
+// postComment stores a comment and shares it with everyone viewing the task
+func (lm *LiveModel) postComment(taskID string, client *liveClient, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" || len(text) > maxCommentLength {
+		return inputError("text must have between 1 and 2000 characters")
+	}
+
+	// Access is checked again, as the task may have been trashed or reassigned since the client joined
+	task, err := findTask(lm.Db, taskID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && task.DeletedAt != "") {
+		return inputError("Task not found")
+	}
+	if err != nil {
+		return err
+	}
+	allowed, err := canAccessTask(lm.Db, task, client.userID, client.managerID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return inputError("Access denied")
+	}
+
+	comment := entities.TaskComment{ID: uuid.New().String(), TaskID: taskID, UserID: client.userID, Text: text,
+		CreatedAt: time.Now().UTC().Format(mysqlDateTime)}
+	err = lm.Db.QueryRow("SELECT CONCAT(first_name, ' ', last_name) FROM users WHERE id = ?", client.userID).Scan(&comment.Author)
+	if err != nil {
+		return err
+	}
+	_, err = lm.Db.Exec("INSERT INTO task_comments (id, task_id, user_id, body, created_at) VALUES (?, ?, ?, ?, ?)",
+		comment.ID, comment.TaskID, comment.UserID, comment.Text, comment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entities.LiveMessage{Type: "comment", Comment: &comment})
+	if err != nil {
+		return err
+	}
+	lm.Events.Publish(liveComment, taskID, data)
+	return nil
+}
+
+// loadViewers lists the people with a live connection to the task on any instance
+func loadViewers(db *sql.DB, taskID string) ([]entities.LiveViewer, error) {
+	rows, err := db.Query(`SELECT DISTINCT u.id, CONCAT(u.first_name, ' ', u.last_name) AS name
+		FROM task_viewers v JOIN users u ON u.id = v.user_id
+		WHERE v.task_id = ? AND v.seen_at >= UTC_TIMESTAMP() - `+livePresenceWindow+`
+		ORDER BY name`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	viewers := []entities.LiveViewer{}
+	for rows.Next() {
+		var viewer entities.LiveViewer
+		err := rows.Scan(&viewer.UserID, &viewer.Name)
+		if err != nil {
+			return nil, err
+		}
+		viewers = append(viewers, viewer)
+	}
+	return viewers, rows.Err()
+}
+
+// LiveHub tracks the live connections of this instance, grouped by task, and delivers the events of
+// the bus to them. Viewers are kept in the database, so every instance knows who is connected to the
+// others, and the bus carries changes, comments and presence updates between instances.
+type LiveHub struct {
+	Db    *sql.DB
+	mu    sync.Mutex
+	rooms map[string]*liveRoom
+}
+
+// liveRoom holds the clients connected to one task and the task as they last saw it
+type liveRoom struct {
+	task    entities.Task
+	viewers string
+	clients map[*liveClient]struct{}
+}
+
+func NewLiveHub(db *sql.DB) *LiveHub {
+	return &LiveHub{
+		Db:    db,
+		rooms: make(map[string]*liveRoom),
+	}
+}
+
+// Run delivers the events of the bus to the connected clients and refreshes the viewers of every
+// task each interval, which also notices viewers whose instance went away
+func (h *LiveHub) Run(events *services.EventBus, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	subscription := events.Subscribe(0, liveHubBuffer)
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				log.Println("Live hub fell behind the event bus, resubscribing")
+				subscription = events.Subscribe(0, liveHubBuffer)
+				continue
+			}
+			h.dispatch(event)
+		case <-ticker.C:
+			h.refreshViewers()
+		}
+	}
+}
+
+func (h *LiveHub) dispatch(event services.Event) {
+	switch event.Type {
+	case taskCreated, taskUpdated, taskDeleted:
+		var taskEvent entities.TaskEvent
+		err := json.Unmarshal(event.Data, &taskEvent)
+		if err != nil {
+			log.Println("Failed to read task event:", err)
+			return
+		}
+		lost := h.lostAccess(event.Subject, taskEvent.Task)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		room := h.rooms[event.Subject]
+		if room == nil {
+			return
+		}
+		message, err := LiveTaskMessage(event.Type, room.task, taskEvent)
+		if err != nil {
+			log.Println("Failed to serialize live message:", err)
+			return
+		}
+		room.task = taskEvent.Task
+		for client := range room.clients {
+			if lost[client] {
+				h.deliver(room, client, liveAccessLost)
+				h.drop(room, client)
+				continue
+			}
+			h.deliver(room, client, message)
+			// A trashed task has no live channel, so its viewers are let go once they know
+			if event.Type == taskDeleted {
+				h.drop(room, client)
+			}
+		}
+		if len(room.clients) == 0 {
+			delete(h.rooms, event.Subject)
+		}
+	case liveComment:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if room := h.rooms[event.Subject]; room != nil {
+			h.broadcast(room, event.Data)
+		}
+	case livePresence:
+		h.sendViewers(event.Subject)
+	}
+}
+
+// liveAccessLost tells a client that its channel closes because it can no longer see the task
+var liveAccessLost, _ = json.Marshal(entities.LiveMessage{Type: "error", Message: "You no longer have access to this task"})
+
+// lostAccess checks again, after a change, which clients of the task's room may still see it, as
+// reassigning the task can take it out of their team
+func (h *LiveHub) lostAccess(taskID string, task entities.Task) map[*liveClient]bool {
+	h.mu.Lock()
+	var clients []*liveClient
+	if room := h.rooms[taskID]; room != nil {
+		for client := range room.clients {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.Unlock()
+
+	lost := make(map[*liveClient]bool)
+	for _, client := range clients {
+		allowed, err := canAccessTask(h.Db, task, client.userID, client.managerID)
+		if err != nil {
+			log.Println("Failed to check task access:", err)
+			continue
+		}
+		if !allowed {
+			lost[client] = true
+		}
+	}
+	return lost
+}
+
+// LiveTaskMessage tells the viewers of a task what changed since they last saw it
+func LiveTaskMessage(eventType string, before entities.Task, event entities.TaskEvent) ([]byte, error) {
+	changes, err := TaskChanges(before, event.Task)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(entities.LiveMessage{Type: eventType, Task: &event.Task, Changes: changes, ActorID: event.ActorID})
+}
+
+// sendViewers tells the clients connected to a task who is viewing it, unless that has not changed
+func (h *LiveHub) sendViewers(taskID string) {
+	h.mu.Lock()
+	_, ok := h.rooms[taskID]
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	viewers, err := loadViewers(h.Db, taskID)
+	if err != nil {
+		log.Println("Failed to load task viewers:", err)
+		return
+	}
+	message, err := json.Marshal(entities.LiveMessage{Type: "presence", Viewers: viewers})
+	if err != nil {
+		log.Println("Failed to serialize live message:", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room := h.rooms[taskID]
+	if room == nil || room.viewers == string(message) {
+		return
+	}
+	room.viewers = string(message)
+	h.broadcast(room, message)
+}
+
+func (h *LiveHub) refreshViewers() {
+	_, err := h.Db.Exec("DELETE FROM task_viewers WHERE seen_at < UTC_TIMESTAMP() - " + livePresenceWindow)
+	if err != nil {
+		log.Println("Failed to remove stale task viewers:", err)
+	}
+
+	h.mu.Lock()
+	taskIDs := make([]string, 0, len(h.rooms))
+	for taskID := range h.rooms {
+		taskIDs = append(taskIDs, taskID)
+	}
+	h.mu.Unlock()
+
+	for _, taskID := range taskIDs {
+		h.sendViewers(taskID)
+	}
+}
+
+func (h *LiveHub) join(task entities.Task, client *liveClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room := h.rooms[task.ID]
+	if room == nil {
+		room = &liveRoom{task: task, clients: make(map[*liveClient]struct{})}
+		h.rooms[task.ID] = room
+	}
+	room.clients[client] = struct{}{}
+}
+
+func (h *LiveHub) leave(taskID string, client *liveClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room := h.rooms[taskID]
+	if room == nil {
+		return
+	}
+	h.drop(room, client)
+	if len(room.clients) == 0 {
+		delete(h.rooms, taskID)
+	}
+}
+
+// sendTo sends a message to a single client
+func (h *LiveHub) sendTo(taskID string, client *liveClient, message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room := h.rooms[taskID]; room != nil {
+		if _, ok := room.clients[client]; ok {
+			h.deliver(room, client, message)
+		}
+	}
+}
+
+// broadcast sends a message to every client of a room. The caller holds the lock.
+func (h *LiveHub) broadcast(room *liveRoom, message []byte) {
+	for client := range room.clients {
+		h.deliver(room, client, message)
+	}
+}
+
+// deliver queues a message for a client, dropping the client when it cannot keep up. The caller holds the lock.
+func (h *LiveHub) deliver(room *liveRoom, client *liveClient, message []byte) {
+	select {
+	case client.send <- message:
+	default:
+		h.drop(room, client)
+	}
+}
+
+// drop closes a client's channel, which ends its connection. The caller holds the lock.
+func (h *LiveHub) drop(room *liveRoom, client *liveClient) {
+	if _, ok := room.clients[client]; ok {
+		delete(room.clients, client)
+		close(client.send)
+	}
+}
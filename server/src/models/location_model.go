@@ -0,0 +1,468 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const locationColumns = "id, name, kind, parent_id, created_at"
+
+// locationLevels orders the kinds of location from the top of the tree down
+var locationLevels = map[string]int{
+	"site":     0,
+	"building": 1,
+	"floor":    2,
+	"room":     3,
+}
+
+type LocationModel struct {
+	Db *sql.DB
+}
+
+func LocationHandler(db *sql.DB) *LocationModel {
+	return &LocationModel{
+		Db: db,
+	}
+}
+
+func (lm *LocationModel) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage locations"); !ok {
+		return
+	}
+
+	location := entities.Location{}
+	err := json.NewDecoder(r.Body).Decode(&location)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	location.ID = uuid.New().String()
+	if !lm.validateLocation(w, location) {
+		return
+	}
+
+	insertQuery := "INSERT INTO locations (id, name, kind, parent_id) VALUES (?, ?, ?, ?)"
+	_, err = lm.Db.Exec(insertQuery, location.ID, location.Name, location.Kind, nullString(location.ParentID))
+	if err != nil {
+		http.Error(w, "Location creation failed", http.StatusInternalServerError)
+		log.Println("Location creation failed:", err)
+		return
+	}
+
+	created, err := lm.findLocation(location.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve created location", http.StatusInternalServerError)
+		log.Println("Failed to retrieve created location:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetLocations lists locations; with parent_id only the given location's subtree is returned
+func (lm *LocationModel) GetLocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	locationsQuery := "SELECT " + locationColumns + " FROM locations"
+	var args []interface{}
+	if parentID := r.URL.Query().Get("parent_id"); parentID != "" {
+		locationsQuery += " WHERE " + locationSubtreeClause("id")
+		args = append(args, parentID)
+	}
+
+	rows, err := lm.Db.Query(locationsQuery+" ORDER BY kind, name", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving locations:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	locations := []entities.Location{}
+	for rows.Next() {
+		location, err := scanLocation(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		locations = append(locations, location)
+	}
+
+	writeJSON(w, http.StatusOK, locations)
+}
+
+func (lm *LocationModel) GetLocation(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	location, ok := lm.loadLocation(w, id)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, location)
+}
+
+func (lm *LocationModel) UpdateLocation(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage locations"); !ok {
+		return
+	}
+
+	location, ok := lm.loadLocation(w, id)
+	if !ok {
+		return
+	}
+
+	patchLocation := make(map[string]interface{})
+	err := json.NewDecoder(r.Body).Decode(&patchLocation)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Decoding failed:", err)
+		return
+	}
+
+	if name, ok := patchLocation["name"].(string); ok {
+		location.Name = name
+	}
+	if kind, ok := patchLocation["kind"].(string); ok {
+		location.Kind = kind
+	}
+	if parentID, ok := patchLocation["parent_id"]; ok {
+		location.ParentID, _ = parentID.(string)
+	}
+
+	if !lm.validateLocation(w, location) {
+		return
+	}
+
+	var children int
+	err = lm.Db.QueryRow("SELECT COUNT(*) FROM locations WHERE parent_id = ? AND "+lm.levelClause(), location.ID, location.Kind).Scan(&children)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to check child locations:", err)
+		return
+	}
+	if children > 0 {
+		http.Error(w, "A location must sit above its children in the hierarchy", http.StatusBadRequest)
+		return
+	}
+
+	_, err = lm.Db.Exec("UPDATE locations SET name = ?, kind = ?, parent_id = ? WHERE id = ?",
+		location.Name, location.Kind, nullString(location.ParentID), id)
+	if err != nil {
+		http.Error(w, "Location update failed", http.StatusInternalServerError)
+		log.Println("Failed to update location:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, location)
+}
+
+func (lm *LocationModel) DeleteLocation(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage locations"); !ok {
+		return
+	}
+
+	location, ok := lm.loadLocation(w, id)
+	if !ok {
+		return
+	}
+
+	var references int
+	err := lm.Db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM locations WHERE parent_id = ?) +
+		(SELECT COUNT(*) FROM tasks WHERE location_id = ?) +
+		(SELECT COUNT(*) FROM assets WHERE location_id = ?) +
+		(SELECT COUNT(*) FROM manager_sites WHERE location_id = ?)`,
+		location.ID, location.ID, location.ID, location.ID).Scan(&references)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to count location references:", err)
+		return
+	}
+	if references > 0 {
+		http.Error(w, "Location is still in use and cannot be deleted", http.StatusConflict)
+		return
+	}
+
+	_, err = lm.Db.Exec("DELETE FROM locations WHERE id = ?", location.ID)
+	if err != nil {
+		http.Error(w, "Location deletion failed", http.StatusInternalServerError)
+		log.Println("Location deletion failed:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{
+		Message: "Location deleted successfully",
+	})
+}
+
+// GetManagerSites lists the sites a Manager is restricted to; an empty list means no restriction
+func (lm *LocationModel) GetManagerSites(w http.ResponseWriter, r *http.Request, managerID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	if !lm.requireSelf(w, r, managerID) {
+		return
+	}
+
+	lm.writeManagerSites(w, managerID)
+}
+
+// SetManagerSites replaces the sites a Manager is restricted to
+func (lm *LocationModel) SetManagerSites(w http.ResponseWriter, r *http.Request, managerID string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !lm.requireSelf(w, r, managerID) {
+		return
+	}
+
+	var input struct {
+		LocationIDs []string `json:"location_ids"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	for _, locationID := range input.LocationIDs {
+		var kind string
+		err := lm.Db.QueryRow("SELECT kind FROM locations WHERE id = ?", locationID).Scan(&kind)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Location not found: "+locationID, http.StatusBadRequest)
+			} else {
+				http.Error(w, "Something went wrong", http.StatusInternalServerError)
+				log.Println("Failed to scan location:", err)
+			}
+			return
+		}
+		if kind != "site" {
+			http.Error(w, "Only sites can be assigned to managers: "+locationID, http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := lm.Db.Begin()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to begin transaction:", err)
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("DELETE FROM manager_sites WHERE manager_id = ?", managerID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to clear manager sites:", err)
+		return
+	}
+	for _, locationID := range input.LocationIDs {
+		_, err = tx.Exec("INSERT IGNORE INTO manager_sites (manager_id, location_id) VALUES (?, ?)", managerID, locationID)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to assign manager site:", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to commit manager sites:", err)
+		return
+	}
+
+	lm.writeManagerSites(w, managerID)
+}
+
+func (lm *LocationModel) writeManagerSites(w http.ResponseWriter, managerID string) {
+	rows, err := lm.Db.Query("SELECT l.id, l.name, l.kind, l.parent_id, l.created_at FROM locations l"+
+		" JOIN manager_sites ms ON ms.location_id = l.id WHERE ms.manager_id = ? ORDER BY l.name", managerID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving manager sites:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	sites := []entities.Location{}
+	for rows.Next() {
+		site, err := scanLocation(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		sites = append(sites, site)
+	}
+
+	writeJSON(w, http.StatusOK, sites)
+}
+
+// requireSelf only lets Managers read and change their own site assignments
+func (lm *LocationModel) requireSelf(w http.ResponseWriter, r *http.Request, managerID string) bool {
+	userID, mID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return false
+	}
+
+	if mID != "" || userID != managerID {
+		http.Error(w, "Managers can only manage their own sites", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (lm *LocationModel) validateLocation(w http.ResponseWriter, location entities.Location) bool {
+	if location.Name == "" {
+		http.Error(w, "Missing required fields: name", http.StatusBadRequest)
+		return false
+	}
+
+	level, ok := locationLevels[location.Kind]
+	if !ok {
+		http.Error(w, "kind must be one of site, building, floor or room", http.StatusBadRequest)
+		return false
+	}
+
+	if location.ParentID == "" {
+		if level != 0 {
+			http.Error(w, "Only sites can be top-level locations", http.StatusBadRequest)
+			return false
+		}
+		return true
+	}
+	if level == 0 {
+		http.Error(w, "Sites cannot have a parent location", http.StatusBadRequest)
+		return false
+	}
+
+	parent, err := lm.findLocation(location.ParentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Parent location not found", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan location:", err)
+		}
+		return false
+	}
+
+	// Children always sit on a deeper level than their parent, which also rules out cycles
+	if locationLevels[parent.Kind] >= level {
+		http.Error(w, "A "+location.Kind+" cannot be placed inside a "+parent.Kind, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// levelClause matches child locations whose kind is not deeper than the bound kind
+func (lm *LocationModel) levelClause() string {
+	return "FIELD(kind, 'site', 'building', 'floor', 'room') <= FIELD(?, 'site', 'building', 'floor', 'room')"
+}
+
+func (lm *LocationModel) loadLocation(w http.ResponseWriter, id string) (entities.Location, bool) {
+	location, err := lm.findLocation(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Location not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan location:", err)
+		}
+		return location, false
+	}
+	return location, true
+}
+
+func (lm *LocationModel) findLocation(id string) (entities.Location, error) {
+	return scanLocation(lm.Db.QueryRow("SELECT "+locationColumns+" FROM locations WHERE id = ?", id))
+}
+
+func scanLocation(row rowScanner) (entities.Location, error) {
+	var location entities.Location
+	var parentID sql.NullString
+	err := row.Scan(&location.ID, &location.Name, &location.Kind, &parentID, &location.CreatedAt)
+	location.ParentID = parentID.String
+	return location, err
+}
+
+func locationExists(db *sql.DB, id string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM locations WHERE id = ?", id).Scan(&count)
+	if err != nil {
+		log.Println("Failed to query location database:", err)
+		return false
+	}
+	return count > 0
+}
+
+// locationSubtreeClause matches column against a location and everything below it.
+// It expects the root location ID to be bound once.
+func locationSubtreeClause(column string) string {
+	return column + ` IN (
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM locations WHERE id = ?
+			UNION ALL
+			SELECT l.id FROM locations l JOIN subtree s ON l.parent_id = s.id
+		)
+		SELECT id FROM subtree)`
+}
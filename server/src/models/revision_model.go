@@ -0,0 +1,390 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+type RevisionModel struct {
+	Db     *sql.DB
+	Events *services.EventBus
+}
+
+func RevisionHandler(db *sql.DB, events *services.EventBus) *RevisionModel {
+	return &RevisionModel{
+		Db:     db,
+		Events: events,
+	}
+}
+
+// GetRevisions lists the revisions of a task, oldest first, with the fields each one changed
+func (rm *RevisionModel) GetRevisions(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, rm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	revisions, err := loadRevisions(rm.Db, task.ID, 0)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving revisions:", err)
+		return
+	}
+
+	// The listing only carries the changes; the full version is available per revision
+	for i := range revisions {
+		revisions[i].Task = nil
+	}
+
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+// GetRevision returns the task as it was at revision n together with the changes made by that revision
+func (rm *RevisionModel) GetRevision(w http.ResponseWriter, r *http.Request, taskID string, n string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, rm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	revision, ok := rm.loadRevision(w, task.ID, n)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revision)
+}
+
+// RevertTask restores the content of revision n as a new revision. The status is left as it is,
+// since reopening or completing a task goes through the normal update rules.
+func (rm *RevisionModel) RevertTask(w http.ResponseWriter, r *http.Request, taskID string, n string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	// Check if the user is a "Technician", who may only change their own tasks as in UpdateTask
+	if managerID == "" {
+		http.Error(w, "Only Technicians can revert their tasks", http.StatusForbidden)
+		return
+	}
+
+	current, err := findTaskWithDetails(rm.Db, taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan task:", err)
+		}
+		return
+	}
+	if current.UserID != userID {
+		http.Error(w, "Only the task owner can revert this task", http.StatusForbidden)
+		return
+	}
+
+	if !checkIfMatch(w, r, current) {
+		return
+	}
+
+	revision, ok := rm.loadRevision(w, current.ID, n)
+	if !ok {
+		return
+	}
+	target := *revision.Task
+
+	if target.AssetID != "" && !assetExists(rm.Db, target.AssetID) {
+		http.Error(w, "Revision "+n+" refers to an asset that no longer exists", http.StatusConflict)
+		return
+	}
+	if target.LocationID != "" && !locationExists(rm.Db, target.LocationID) {
+		http.Error(w, "Revision "+n+" refers to a location that no longer exists", http.StatusConflict)
+		return
+	}
+
+	fields, err := loadCustomFields(rm.Db)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load custom fields:", err)
+		return
+	}
+	values := make(map[string]interface{})
+	for name, value := range target.CustomFields {
+		// Fields deleted since the revision cannot be restored
+		if _, ok := fields[name]; ok {
+			values[name] = value
+		}
+	}
+	customFields, err := ValidateCustomFieldValues(fields, values, false)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Revision %s can no longer be applied: %v", n, err), http.StatusConflict)
+		return
+	}
+
+	// The revert and its revision are stored together so the history never misses a change
+	tx, err := rm.Db.Begin()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to begin transaction:", err)
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	updateQuery := `UPDATE tasks
+		SET summary = ?, date = ?, asset_id = ?, location_id = ?, due_date = ?, priority = ?, estimated_minutes = ?,
+		    version = version + 1
+		WHERE id = ? AND version = ?`
+	result, err := tx.Exec(updateQuery, target.Summary, target.Date, nullString(target.AssetID), nullString(target.LocationID),
+		nullString(target.DueDate), target.Priority, nullInt(target.EstimatedMinutes), current.ID, current.Version)
+	if err != nil {
+		http.Error(w, "Task revert failed", http.StatusInternalServerError)
+		log.Println("Failed to revert task:", err)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Task was modified by someone else", http.StatusPreconditionFailed)
+		return
+	}
+
+	err = setTaskTags(tx, current.ID, target.Tags)
+	if err == nil {
+		_, err = tx.Exec("DELETE FROM task_custom_field_values WHERE task_id = ?", current.ID)
+	}
+	if err == nil {
+		err = setTaskCustomFields(tx, current.ID, customFields)
+	}
+	if err == nil {
+		err = setTaskChecklist(tx, current.ID, target.Checklist)
+	}
+	if err != nil {
+		http.Error(w, "Task revert failed", http.StatusInternalServerError)
+		log.Println("Failed to revert task:", err)
+		return
+	}
+
+	reverted, err := findTaskWithDetails(tx, current.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to get reverted task:", err)
+		return
+	}
+
+	err = recordRevision(tx, current, reverted, userID, "revert", revision.Revision)
+	if err != nil {
+		http.Error(w, "Task revert failed", http.StatusInternalServerError)
+		log.Println("Failed to record task revision:", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		http.Error(w, "Task revert failed", http.StatusInternalServerError)
+		log.Println("Failed to commit task revert:", err)
+		return
+	}
+	recordAudit(rm.Db, r, "revert", "task", current.ID, current, reverted)
+	publishTaskEvent(rm.Events, taskUpdated, reverted, userID)
+
+	w.Header().Set("ETag", taskETag(reverted))
+	writeJSON(w, http.StatusOK, reverted)
+}
+
+func (rm *RevisionModel) loadRevision(w http.ResponseWriter, taskID string, n string) (entities.TaskRevision, bool) {
+	number, err := strconv.Atoi(n)
+	if err != nil || number < 1 {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return entities.TaskRevision{}, false
+	}
+
+	// The previous revision is loaded as well to compute the changes
+	revisions, err := loadRevisions(rm.Db, taskID, number)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving revision:", err)
+		return entities.TaskRevision{}, false
+	}
+	if len(revisions) == 0 || revisions[len(revisions)-1].Revision != number {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return entities.TaskRevision{}, false
+	}
+	return revisions[len(revisions)-1], true
+}
+
+// loadRevisions returns the revisions of a task in order with their changes. With upTo set only
+// that revision and the one before it are loaded.
+func loadRevisions(db *sql.DB, taskID string, upTo int) ([]entities.TaskRevision, error) {
+	query := "SELECT revision, source, reverted_from, changed_by, created_at, snapshot FROM task_revisions WHERE task_id = ?"
+	args := []interface{}{taskID}
+	if upTo > 0 {
+		query += " AND revision BETWEEN ? AND ?"
+		args = append(args, upTo-1, upTo)
+	}
+	rows, err := db.Query(query+" ORDER BY revision", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	revisions := []entities.TaskRevision{}
+	var previous *entities.Task
+	for rows.Next() {
+		var revision entities.TaskRevision
+		var revertedFrom sql.NullInt64
+		var snapshot []byte
+		err := rows.Scan(&revision.Revision, &revision.Source, &revertedFrom, &revision.ChangedBy, &revision.CreatedAt, &snapshot)
+		if err != nil {
+			return nil, err
+		}
+		revision.RevertedFrom = int(revertedFrom.Int64)
+
+		task := &entities.Task{}
+		err = json.Unmarshal(snapshot, task)
+		if err != nil {
+			return nil, err
+		}
+		revision.Task = task
+
+		var before interface{}
+		if previous != nil {
+			before = *previous
+		}
+		revision.Changes, err = TaskChanges(before, *task)
+		if err != nil {
+			return nil, err
+		}
+
+		previous = task
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// When only a window is requested the first entry is just there for the diff
+	if upTo > 1 && len(revisions) == 2 {
+		revisions = revisions[1:]
+	}
+	return revisions, nil
+}
+
+// recordRevision stores the task after a change as its next revision. Tasks created before revisions
+// were kept get their previous state stored first so the first change can be diffed and reverted.
+func recordRevision(db dbExecutor, before entities.Task, after entities.Task, changedBy string, source string, revertedFrom int) error {
+	changes, err := TaskChanges(before, after)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM task_revisions WHERE task_id = ?", before.ID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = insertRevision(db, before, before.UserID, "initial", 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return insertRevision(db, after, changedBy, source, revertedFrom)
+}
+
+func insertRevision(db dbExecutor, task entities.Task, changedBy string, source string, revertedFrom int) error {
+	snapshot, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	// Numbering in the same statement keeps revisions gapless; a concurrent writer fails on the primary key
+	_, err = db.Exec(`INSERT INTO task_revisions (task_id, revision, source, reverted_from, changed_by, snapshot)
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ? FROM task_revisions WHERE task_id = ?`,
+		task.ID, source, nullInt(revertedFrom), changedBy, snapshot, task.ID)
+	return err
+}
+
+// revisionIgnoredFields are not part of the content history
+var revisionIgnoredFields = map[string]bool{
+	"deleted_at": true,
+	"deleted_by": true,
+	"version":    true,
+}
+
+// TaskChanges compares two versions of a task field by field. before may be nil for a new task.
+func TaskChanges(before interface{}, after entities.Task) (map[string]entities.FieldChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]entities.FieldChange)
+	for key, value := range afterFields {
+		if revisionIgnoredFields[key] {
+			continue
+		}
+		previous := beforeFields[key]
+		if !reflect.DeepEqual(previous, value) {
+			changes[key] = entities.FieldChange{From: previous, To: value}
+		}
+	}
+	for key, previous := range beforeFields {
+		if _, ok := afterFields[key]; !ok && !revisionIgnoredFields[key] {
+			changes[key] = entities.FieldChange{From: previous, To: nil}
+		}
+	}
+	return changes, nil
+}
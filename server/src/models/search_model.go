@@ -0,0 +1,328 @@
+package models
+
+import (
+	"database/sql"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	// searchFilterBatch is how many index hits are checked against the filters and visibility rules per query
+	searchFilterBatch = 1000
+)
+
+type SearchModel struct {
+	Db    *sql.DB
+	Index *services.SearchIndex
+}
+
+// SearchHandler builds the search model. Without an embedded index searches use the MySQL FULLTEXT index.
+func SearchHandler(db *sql.DB, index *services.SearchIndex) *SearchModel {
+	return &SearchModel{
+		Db:    db,
+		Index: index,
+	}
+}
+
+// Search finds the visible tasks whose summary matches q, best match first. It accepts the task listing
+// filters (from, to, status, tag, ...) plus user_id, and limit and offset for paging.
+func (sm *SearchModel) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	terms := services.Tokenize(q)
+	if len(terms) == 0 {
+		http.Error(w, "q must contain at least one word", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := pageParams(query.Get("limit"), query.Get("offset"), defaultSearchPageSize, maxSearchPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Results are limited to the tasks GetAllTasksByUserID and the manager listings would show
+	where, args, err := taskFilters(query, "t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filterUserID := query.Get("user_id"); filterUserID != "" {
+		where = append(where, "t.user_id = ?")
+		args = append(args, filterUserID)
+	}
+	visible, visibleArgs := visibleTasksFilter("t", userID)
+	where = append([]string{visible}, where...)
+	args = append(visibleArgs, args...)
+
+	var tasks []entities.Task
+	var scores map[string]float64
+	var total int
+	if sm.Index != nil {
+		tasks, scores, total, err = sm.searchIndex(q, where, args, limit, offset)
+	} else {
+		tasks, scores, total, err = sm.searchFullText(q, where, args, limit, offset)
+	}
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error searching tasks:", err)
+		return
+	}
+
+	err = loadTaskDetails(sm.Db, tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load task details:", err)
+		return
+	}
+
+	results := entities.SearchResults{Query: q, Total: total, Results: []entities.SearchResult{}}
+	for _, task := range tasks {
+		results.Results = append(results.Results, entities.SearchResult{
+			Task:      task,
+			Score:     scores[task.ID],
+			Highlight: HighlightSummary(task.Summary, terms),
+		})
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// searchFullText ranks and pages the matches in MySQL
+func (sm *SearchModel) searchFullText(q string, where []string, args []interface{}, limit int, offset int) ([]entities.Task, map[string]float64, int, error) {
+	match := "MATCH(t.summary) AGAINST (? IN NATURAL LANGUAGE MODE)"
+	condition := strings.Join(append([]string{match}, where...), " AND ")
+	conditionArgs := append([]interface{}{q}, args...)
+
+	var total int
+	err := sm.Db.QueryRow("SELECT COUNT(*) FROM tasks t WHERE "+condition, conditionArgs...).Scan(&total)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	queryArgs := append(append([]interface{}{q}, conditionArgs...), limit, offset)
+	rows, err := sm.Db.Query("SELECT "+prefixColumns("t", taskColumns)+", "+match+" AS score FROM tasks t WHERE "+condition+
+		" ORDER BY score DESC, t.id LIMIT ? OFFSET ?", queryArgs...)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks := []entities.Task{}
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var score float64
+		task, err := scanTask(scoredRow{rows: rows, score: &score})
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		tasks = append(tasks, task)
+		scores[task.ID] = score
+	}
+	return tasks, scores, total, rows.Err()
+}
+
+// searchIndex ranks with the embedded index and leaves the filtering to MySQL. Every hit is checked,
+// a batch at a time, so the total and the pages only count the tasks the filters let through.
+func (sm *SearchModel) searchIndex(q string, where []string, args []interface{}, limit int, offset int) ([]entities.Task, map[string]float64, int, error) {
+	hits := sm.Index.Search(q, 0)
+	if len(hits) == 0 {
+		return []entities.Task{}, nil, 0, nil
+	}
+
+	scores := make(map[string]float64, len(hits))
+	var matching []string
+	for start := 0; start < len(hits); start += searchFilterBatch {
+		end := start + searchFilterBatch
+		if end > len(hits) {
+			end = len(hits)
+		}
+		ids := make([]string, 0, end-start)
+		for _, hit := range hits[start:end] {
+			scores[hit.ID] = hit.Score
+			ids = append(ids, hit.ID)
+		}
+		allowed, err := sm.filterTaskIDs(ids, where, args)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		// Hits come best first, so keeping their order keeps the ranking
+		for _, id := range ids {
+			if allowed[id] {
+				matching = append(matching, id)
+			}
+		}
+	}
+
+	total := len(matching)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	page := matching[offset:end]
+	if len(page) == 0 {
+		return []entities.Task{}, scores, total, nil
+	}
+
+	placeholders := make([]string, len(page))
+	pageArgs := make([]interface{}, len(page))
+	for i, id := range page {
+		placeholders[i] = "?"
+		pageArgs[i] = id
+	}
+	rows, err := sm.Db.Query("SELECT "+taskColumns+" FROM tasks t WHERE t.id IN ("+strings.Join(placeholders, ", ")+")", pageArgs...)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks := []entities.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if scores[tasks[i].ID] != scores[tasks[j].ID] {
+			return scores[tasks[i].ID] > scores[tasks[j].ID]
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, scores, total, nil
+}
+
+// filterTaskIDs returns which of the task IDs pass the search filters
+func (sm *SearchModel) filterTaskIDs(ids []string, where []string, args []interface{}) (map[string]bool, error) {
+	placeholders := make([]string, len(ids))
+	queryArgs := append([]interface{}{}, args...)
+	for i, id := range ids {
+		placeholders[i] = "?"
+		queryArgs = append(queryArgs, id)
+	}
+	condition := strings.Join(append(append([]string{}, where...), "t.id IN ("+strings.Join(placeholders, ", ")+")"), " AND ")
+
+	rows, err := sm.Db.Query("SELECT t.id FROM tasks t WHERE "+condition, queryArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	allowed := make(map[string]bool, len(ids))
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		allowed[id] = true
+	}
+	return allowed, rows.Err()
+}
+
+// LoadSearchDocuments returns the summaries of all tasks outside the trash for the embedded index
+func LoadSearchDocuments(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query("SELECT id, summary FROM tasks WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	documents := make(map[string]string)
+	for rows.Next() {
+		var id, summary string
+		err := rows.Scan(&id, &summary)
+		if err != nil {
+			return nil, err
+		}
+		documents[id] = summary
+	}
+	return documents, rows.Err()
+}
+
+// HighlightSummary HTML-escapes a summary and wraps the words matching any of the terms in <mark> tags
+func HighlightSummary(summary string, terms []string) string {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	var highlighted strings.Builder
+	runes := []rune(summary)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && isWord(runes[end]) == isWord(runes[start]) {
+			end++
+		}
+		segment := string(runes[start:end])
+		if isWord(runes[start]) && wanted[strings.ToLower(segment)] {
+			highlighted.WriteString("<mark>" + html.EscapeString(segment) + "</mark>")
+		} else {
+			highlighted.WriteString(html.EscapeString(segment))
+		}
+		start = end
+	}
+	return highlighted.String()
+}
+
+// scoredRow reads a task row followed by a relevance score
+type scoredRow struct {
+	rows  *sql.Rows
+	score *float64
+}
+
+func (sr scoredRow) Scan(dest ...interface{}) error {
+	return sr.rows.Scan(append(dest, sr.score)...)
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const maxTagLength = 50
+
+type TagModel struct {
+	Db *sql.DB
+}
+
+func TagHandler(db *sql.DB) *TagModel {
+	return &TagModel{
+		Db: db,
+	}
+}
+
+// GetTags suggests existing tags starting with the prefix parameter, most used first
+func (tm *TagModel) GetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	// Only suggest tags used on tasks the user can see so names do not leak across teams
+	visibleClause, args := visibleTasksFilter("t", userID)
+	prefix := normalizeTag(r.URL.Query().Get("prefix"))
+	args = append(args, escapeLike(prefix)+"%")
+
+	rows, err := tm.Db.Query("SELECT tt.tag, COUNT(*) FROM task_tags tt JOIN tasks t ON t.id = tt.task_id"+
+		" WHERE "+visibleClause+" AND tt.tag LIKE ? GROUP BY tt.tag ORDER BY COUNT(*) DESC, tt.tag LIMIT 20", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving tags:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	tags := []entities.Tag{}
+	for rows.Next() {
+		var tag entities.Tag
+		err := rows.Scan(&tag.Name, &tag.Count)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		tags = append(tags, tag)
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// normalizeTags lower-cases, trims and de-duplicates tags, rejecting empty or overly long ones
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("tags cannot be empty")
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// setTaskTags replaces the tags of a task
+func setTaskTags(db dbExecutor, taskID string, tags []string) error {
+	_, err := db.Exec("DELETE FROM task_tags WHERE task_id = ?", taskID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		_, err = db.Exec("INSERT INTO task_tags (task_id, tag) VALUES (?, ?)", taskID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interfaceStrings converts a decoded JSON array into strings
+func interfaceStrings(value interface{}) ([]string, bool) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, str)
+	}
+	return values, true
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// customFieldFilterPrefix marks query parameters that filter on a custom field, e.g. field.trade=electrical
+const customFieldFilterPrefix = "field."
+
+// taskFilters turns the listing query parameters shared by the task endpoints into SQL conditions
+// on the tasks table aliased as alias. Supported parameters are from, to, status and priority (comma
+// separated), asset_id, location_id, tag (repeatable, all must match) and field.<name> for custom fields.
+func taskFilters(query url.Values, alias string) ([]string, []interface{}, error) {
+	var where []string
+	var args []interface{}
+
+	if from := query.Get("from"); from != "" {
+		if !isDate(from) {
+			return nil, nil, errors.New("from must be a date in YYYY-MM-DD format")
+		}
+		where = append(where, alias+".date >= ?")
+		args = append(args, from)
+	}
+	if to := query.Get("to"); to != "" {
+		if !isDate(to) {
+			return nil, nil, errors.New("to must be a date in YYYY-MM-DD format")
+		}
+		where = append(where, alias+".date <= ?")
+		args = append(args, to)
+	}
+	if status := query.Get("status"); status != "" {
+		var placeholders []string
+		for _, s := range strings.Split(status, ",") {
+			if !taskStatuses[s] {
+				return nil, nil, errors.New("status must be a list of open, in_progress and done")
+			}
+			placeholders = append(placeholders, "?")
+			args = append(args, s)
+		}
+		where = append(where, alias+".status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if priority := query.Get("priority"); priority != "" {
+		var placeholders []string
+		for _, p := range strings.Split(priority, ",") {
+			if !taskPriorities[p] {
+				return nil, nil, errors.New("priority must be a list of low, normal, high and urgent")
+			}
+			placeholders = append(placeholders, "?")
+			args = append(args, p)
+		}
+		where = append(where, alias+".priority IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if assetID := query.Get("asset_id"); assetID != "" {
+		where = append(where, alias+".asset_id = ?")
+		args = append(args, assetID)
+	}
+	if locationID := query.Get("location_id"); locationID != "" {
+		where = append(where, locationSubtreeClause(alias+".location_id"))
+		args = append(args, locationID)
+	}
+
+	for _, tag := range query["tag"] {
+		where = append(where, "EXISTS (SELECT 1 FROM task_tags tt WHERE tt.task_id = "+alias+".id AND tt.tag = ?)")
+		args = append(args, normalizeTag(tag))
+	}
+
+	for param, values := range query {
+		if !strings.HasPrefix(param, customFieldFilterPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(param, customFieldFilterPrefix)
+		for _, value := range values {
+			where = append(where, "EXISTS (SELECT 1 FROM task_custom_field_values cv JOIN custom_fields cf ON cf.id = cv.field_id"+
+				" WHERE cv.task_id = "+alias+".id AND cf.name = ? AND cv.value = ?)")
+			args = append(args, name, value)
+		}
+	}
+
+	return where, args, nil
+}
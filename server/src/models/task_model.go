@@ -7,19 +7,35 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/christianotieno/tasks-traker-app/server/src/entities"
 	"github.com/christianotieno/tasks-traker-app/server/src/services"
 	"github.com/google/uuid"
 )
 
+const taskColumns = "id, summary, date, user_id, asset_id, location_id, status, due_date, completed_at, priority, estimated_minutes, template_id, deleted_at, deleted_by, version"
+
+// taskStatuses lists the states a task moves through
+var taskStatuses = map[string]bool{
+	"open":        true,
+	"in_progress": true,
+	"done":        true,
+}
+
 type TaskModel struct {
-	Db *sql.DB
+	Db     *sql.DB
+	Events *services.EventBus
+	// Producer, when set, announces new tasks on Kafka
+	Producer *services.KafkaProducer
 }
 
-func TaskHandler(db *sql.DB) *TaskModel {
+func TaskHandler(db *sql.DB, events *services.EventBus) *TaskModel {
 	return &TaskModel{
-		Db: db,
+		Db:     db,
+		Events: events,
 	}
 }
 
@@ -56,46 +72,136 @@ func (tm *TaskModel) CreateTask(w http.ResponseWriter, r *http.Request) {
 		log.Println("Bad Input", err)
 		return
 	}
+
+	// Retried requests carrying the same Idempotency-Key get the original response instead of a new task
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		withIdempotencyKey(tm.Db, w, r, userID, key, body, func(w http.ResponseWriter) {
+			tm.createTask(w, r, userID, body)
+		})
+		return
+	}
+
+	tm.createTask(w, r, userID, body)
+}
+
+// announceTaskCreated sends the new task to Kafka in the background, as a typed JSON message that
+// consumers of the topic can handle
+func announceTaskCreated(producer *services.KafkaProducer, task entities.Task) {
+	if producer == nil {
+		return
+	}
+	message, err := json.Marshal(struct {
+		Type string        `json:"type"`
+		Task entities.Task `json:"task"`
+	}{taskCreated, task})
+	if err != nil {
+		log.Println("Failed to serialize Kafka message:", err)
+		return
+	}
+
+	go func() {
+		err := producer.SendMessage(message)
+		if err != nil {
+			log.Println("Failed to send Kafka message:", err)
+		}
+	}()
+}
+
+func (tm *TaskModel) createTask(w http.ResponseWriter, r *http.Request, userID string, body []byte) {
+	var source struct {
+		TemplateID string `json:"template_id"`
+	}
+	err := json.Unmarshal(body, &source)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusBadRequest)
+		log.Println("Unmarshalling failed:", err)
+		return
+	}
+
+	// Start from the template defaults; fields present in the request override them
 	task := entities.Task{}
-	task.UserID = userID
+	if source.TemplateID != "" {
+		template, err := findTemplate(tm.Db, source.TemplateID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Template not found", http.StatusBadRequest)
+			} else {
+				http.Error(w, "Something went wrong", http.StatusInternalServerError)
+				log.Println("Failed to scan template:", err)
+			}
+			return
+		}
+		task = newTaskFromTemplate(template)
+	}
 	err = json.Unmarshal(body, &task)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusBadRequest)
 		log.Println("Unmarshalling failed:", err)
 		return
 	}
+	task.UserID = userID
+	// completed_at is set by the server when the task is done
+	task.CompletedAt = ""
 
-	id := uuid.New().String()
-	// Save the task to the database
-	insertQuery := "INSERT INTO tasks (id, summary, date, user_id) VALUES (?, ?, ?, ?)"
-	_, err = tm.Db.Exec(insertQuery, id, task.Summary, task.Date, userID)
+	fields, err := loadCustomFields(tm.Db)
 	if err != nil {
-		http.Error(w, "Task creation failed", http.StatusInternalServerError)
-		log.Println("Task creation failed:", err)
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load custom fields:", err)
+		return
+	}
+	tags, customFields, err := validateNewTask(tm.Db, &task, fields)
+	if err != nil {
+		var invalid inputError
+		if errors.As(err, &invalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to validate task:", err)
+		}
 		return
 	}
 
-	kafkaProducer, err := services.NewKafkaProducer([]string{"localhost:9092"})
+	// The task, its details and its first revision are stored together, so a failure leaves no
+	// half-built task behind
+	tx, err := tm.Db.Begin()
 	if err != nil {
-		log.Println("Failed to initialize Kafka producer:", err)
-	} else {
-		go func() {
-			message := "New task created: " + task.Summary
-			err = kafkaProducer.SendMessage([]byte(message))
-			if err != nil {
-				log.Println("Failed to send Kafka message:", err)
-			}
-		}()
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to begin transaction:", err)
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	id, err := insertTask(tx, task, tags, customFields)
+	if err != nil {
+		http.Error(w, "Task creation failed", http.StatusInternalServerError)
+		log.Println("Task creation failed:", err)
+		return
 	}
 
 	// Retrieve the created task
-	row := tm.Db.QueryRow("SELECT * FROM tasks WHERE id = ?", id)
-	err = row.Scan(&task.ID, &task.Summary, &task.Date, &task.UserID)
+	task, err = findTaskWithDetails(tx, id)
 	if err != nil {
 		http.Error(w, "Failed to retrieve created task", http.StatusInternalServerError)
 		log.Println("Failed to retrieve created task:", err)
 		return
 	}
+	err = insertRevision(tx, task, userID, "create", 0)
+	if err != nil {
+		http.Error(w, "Task creation failed", http.StatusInternalServerError)
+		log.Println("Failed to record task revision:", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		http.Error(w, "Task creation failed", http.StatusInternalServerError)
+		log.Println("Failed to commit task creation:", err)
+		return
+	}
+	recordAudit(tm.Db, r, "create", "task", id, nil, task)
+	publishTaskEvent(tm.Events, taskCreated, task, userID)
+	announceTaskCreated(tm.Producer, task)
 
 	// Serialize the created task to JSON
 	responseJSON, err := json.Marshal(task)
@@ -105,6 +211,7 @@ func (tm *TaskModel) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", taskETag(task))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	_, err = w.Write(responseJSON)
@@ -115,16 +222,51 @@ func (tm *TaskModel) CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetTask returns a single task with its ETag. A matching If-None-Match header is answered with 304.
+func (tm *TaskModel) GetTask(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, tm.Db, id, userID, managerID)
+	if !ok {
+		return
+	}
+
+	etag := taskETag(task)
+	w.Header().Set("ETag", etag)
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	tasks := []entities.Task{task}
+	err = loadTaskDetails(tm.Db, tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load task details:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tasks[0])
+}
+
 func (tm *TaskModel) DeleteTask(w http.ResponseWriter, r *http.Request, id string) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	tasksRow := tm.Db.QueryRow("SELECT * FROM tasks WHERE id = ?", id)
-
-	var task entities.Task
-	taskErr := tasksRow.Scan(&task.ID, &task.Summary, &task.Date, &task.UserID)
+	task, taskErr := findTask(tm.Db, id)
 	if taskErr != nil {
 		if errors.Is(taskErr, sql.ErrNoRows) {
 			http.Error(w, "Task not found", http.StatusNotFound)
@@ -149,36 +291,31 @@ func (tm *TaskModel) DeleteTask(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 
-	// fetch managerID from the database
-	managerRow := tm.Db.QueryRow("SELECT manager_id FROM managers WHERE technician_id = ?", task.UserID)
-	var mID string
-	managerErr := managerRow.Scan(&mID)
-	if managerErr != nil {
-		if errors.Is(managerErr, sql.ErrNoRows) {
-			http.Error(w, "Manager not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Something went wrong", http.StatusInternalServerError)
-		}
-	}
-
 	// Check if the user is a "Manager" and is manager of the user who created the task
-	if !(managerID == "" && userID == mID) {
+	allowed, err := canDeleteTask(tm.Db, task, userID, managerID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to query managers:", err)
+		return
+	}
+	if !allowed {
 		http.Error(w, "Only Managers associated with this user can delete this task", http.StatusForbidden)
 		return
 	}
 
-	// Delete the task from the database
-	_, err := tm.Db.Exec("DELETE FROM tasks WHERE id = ?", id)
+	deleted, err := trashTask(tm.Db, task, userID)
 	if err != nil {
 		http.Error(w, "Task deletion failed", http.StatusInternalServerError)
 		log.Println("Task deletion failed:", err)
 		return
 	}
+	recordAudit(tm.Db, r, "delete", "task", id, task, deleted)
+	publishTaskEvent(tm.Events, taskDeleted, deleted, userID)
 
 	response := struct {
 		Message string `json:"message"`
 	}{
-		Message: "Task deleted successfully",
+		Message: "Task moved to trash",
 	}
 
 	// Serialize the response to JSON
@@ -203,10 +340,7 @@ func (tm *TaskModel) UpdateTask(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 
-	tasksRow := tm.Db.QueryRow("SELECT * FROM tasks WHERE id = ?", id)
-
-	var task entities.Task
-	taskErr := tasksRow.Scan(&task.ID, &task.Summary, &task.Date, &task.UserID)
+	task, taskErr := findTaskWithDetails(tm.Db, id)
 	if taskErr != nil {
 		if errors.Is(taskErr, sql.ErrNoRows) {
 			http.Error(w, "Task not found", http.StatusNotFound)
@@ -216,6 +350,7 @@ func (tm *TaskModel) UpdateTask(w http.ResponseWriter, r *http.Request, id strin
 		}
 		return
 	}
+	before := task
 
 	managerID, ok := r.Context().Value("managerID").(string)
 	if !ok {
@@ -243,6 +378,10 @@ func (tm *TaskModel) UpdateTask(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 
+	if !checkIfMatch(w, r, task) {
+		return
+	}
+
 	decoder := json.NewDecoder(r.Body)
 	patchTask := make(map[string]interface{})
 	err := decoder.Decode(&patchTask)
@@ -267,23 +406,187 @@ func (tm *TaskModel) UpdateTask(w http.ResponseWriter, r *http.Request, id strin
 		task.Date = date
 	}
 
+	if assetID, ok := patchTask["asset_id"]; ok {
+		// A null asset_id detaches the task from its asset
+		task.AssetID, _ = assetID.(string)
+		if task.AssetID != "" && !assetExists(tm.Db, task.AssetID) {
+			http.Error(w, "Asset not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if locationID, ok := patchTask["location_id"]; ok {
+		task.LocationID, _ = locationID.(string)
+		if task.LocationID != "" && !locationExists(tm.Db, task.LocationID) {
+			http.Error(w, "Location not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if dueDate, ok := patchTask["due_date"]; ok {
+		// A null due_date removes the deadline
+		task.DueDate, _ = dueDate.(string)
+		if task.DueDate != "" && !isDate(task.DueDate) {
+			http.Error(w, "due_date must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if status, ok := patchTask["status"].(string); ok && status != task.Status {
+		if !taskStatuses[status] {
+			http.Error(w, "status must be one of open, in_progress or done", http.StatusBadRequest)
+			return
+		}
+
+		// A task cannot be started or finished while the tasks blocking it are still open
+		if status != "open" {
+			blockers, err := openBlockers(tm.Db, id)
+			if err != nil {
+				http.Error(w, "Something went wrong", http.StatusInternalServerError)
+				log.Println("Failed to check blockers:", err)
+				return
+			}
+			if len(blockers) > 0 {
+				http.Error(w, "Task is blocked by open tasks: "+strings.Join(blockers, ", "), http.StatusConflict)
+				return
+			}
+		}
+		task.Status = status
+	}
+
+	if priority, ok := patchTask["priority"].(string); ok {
+		if !taskPriorities[priority] {
+			http.Error(w, "priority must be one of low, normal, high or urgent", http.StatusBadRequest)
+			return
+		}
+		task.Priority = priority
+	}
+
+	if estimate, ok := patchTask["estimated_minutes"]; ok {
+		// A null estimate removes it
+		minutes, _ := estimate.(float64)
+		if minutes < 0 {
+			http.Error(w, "estimated_minutes cannot be negative", http.StatusBadRequest)
+			return
+		}
+		task.EstimatedMinutes = int(minutes)
+	}
+
+	var checklist []entities.ChecklistItem
+	if rawChecklist, ok := patchTask["checklist"]; ok {
+		// The checklist is replaced as a whole, which is also how items are ticked off
+		encoded, _ := json.Marshal(rawChecklist)
+		err = json.Unmarshal(encoded, &checklist)
+		if err != nil || !validChecklist(checklist) {
+			http.Error(w, "checklist must be a list of items with a text", http.StatusBadRequest)
+			return
+		}
+		if checklist == nil {
+			checklist = []entities.ChecklistItem{}
+		}
+	}
+
+	var tags []string
+	if rawTags, ok := patchTask["tags"]; ok {
+		patchTags, ok := interfaceStrings(rawTags)
+		if !ok {
+			http.Error(w, "tags must be a list of strings", http.StatusBadRequest)
+			return
+		}
+		tags, err = normalizeTags(patchTags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var customFields map[string]*string
+	if rawFields, ok := patchTask["custom_fields"]; ok {
+		patchFields, ok := rawFields.(map[string]interface{})
+		if !ok {
+			http.Error(w, "custom_fields must be an object", http.StatusBadRequest)
+			return
+		}
+		fields, err := loadCustomFields(tm.Db)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to load custom fields:", err)
+			return
+		}
+		customFields, err = ValidateCustomFieldValues(fields, patchFields, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The task, its tags, custom fields, checklist and revision are stored together or not at all
+	tx, err := tm.Db.Begin()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to begin transaction:", err)
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	// Update the task in the database
-	_, err = tm.Db.Exec("UPDATE tasks SET summary = ?, date = ? WHERE id = ?", task.Summary, task.Date, id)
+	// completed_at is assigned before status so the CASE still sees the previous status.
+	// The version check makes the write fail if someone else changed the task since it was read.
+	updateQuery := `UPDATE tasks
+		SET summary = ?, date = ?, asset_id = ?, location_id = ?, due_date = ?, priority = ?, estimated_minutes = ?,
+		    completed_at = CASE WHEN ? <> 'done' THEN NULL WHEN status = 'done' THEN completed_at ELSE UTC_TIMESTAMP() END,
+		    status = ?, version = version + 1
+		WHERE id = ? AND version = ?`
+	result, err := tx.Exec(updateQuery, task.Summary, task.Date, nullString(task.AssetID), nullString(task.LocationID),
+		nullString(task.DueDate), task.Priority, nullInt(task.EstimatedMinutes), task.Status, task.Status, id, task.Version)
 	if err != nil {
 		http.Error(w, "Task update failed", http.StatusInternalServerError)
 		log.Println("Failed to update task:", err)
 		return
 	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Task was modified by someone else", http.StatusPreconditionFailed)
+		return
+	}
 
-	row := tm.Db.QueryRow("SELECT * FROM tasks WHERE id = ?", id)
+	if tags != nil {
+		err = setTaskTags(tx, id, tags)
+	}
+	if err == nil {
+		err = setTaskCustomFields(tx, id, customFields)
+	}
+	if err == nil && checklist != nil {
+		err = setTaskChecklist(tx, id, checklist)
+	}
+	if err != nil {
+		http.Error(w, "Task update failed", http.StatusInternalServerError)
+		log.Println("Failed to update task tags, custom fields and checklist:", err)
+		return
+	}
 
-	var updatedTask entities.Task
-	err = row.Scan(&updatedTask.ID, &updatedTask.UserID, &updatedTask.Summary, &updatedTask.Date)
+	updatedTask, err := findTaskWithDetails(tx, id)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		log.Println("Failed to get updated Task:", err)
 		return
 	}
+	err = recordRevision(tx, before, updatedTask, userID, "update", 0)
+	if err != nil {
+		http.Error(w, "Task update failed", http.StatusInternalServerError)
+		log.Println("Failed to record task revision:", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		http.Error(w, "Task update failed", http.StatusInternalServerError)
+		log.Println("Failed to commit task update:", err)
+		return
+	}
+	recordAudit(tm.Db, r, "update", "task", id, before, updatedTask)
+	publishTaskEvent(tm.Events, taskUpdated, updatedTask, userID)
 
 	responseJSON, err := json.Marshal(updatedTask)
 	if err != nil {
@@ -292,6 +595,7 @@ func (tm *TaskModel) UpdateTask(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 
+	w.Header().Set("ETag", taskETag(updatedTask))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(responseJSON)
@@ -301,3 +605,230 @@ func (tm *TaskModel) UpdateTask(w http.ResponseWriter, r *http.Request, id strin
 		return
 	}
 }
+
+// trashTask moves a task to the trash; it is only removed for good by the retention purge
+func trashTask(db dbExecutor, task entities.Task, userID string) (entities.Task, error) {
+	deletedAt := time.Now().UTC().Format(mysqlDateTime)
+	_, err := db.Exec("UPDATE tasks SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE id = ?", deletedAt, userID, task.ID)
+	if err != nil {
+		return task, err
+	}
+	task.DeletedAt = deletedAt
+	task.DeletedBy = userID
+	task.Version++
+	return task, nil
+}
+
+// validateNewTask applies the rules for creating a task, filling in the default status and priority.
+// It returns the normalized tags and custom field values, or an inputError when the task is invalid.
+func validateNewTask(db *sql.DB, task *entities.Task, fields map[string]entities.CustomField) ([]string, map[string]*string, error) {
+	if task.AssetID != "" && !assetExists(db, task.AssetID) {
+		return nil, nil, inputError("Asset not found")
+	}
+
+	if task.LocationID != "" && !locationExists(db, task.LocationID) {
+		return nil, nil, inputError("Location not found")
+	}
+
+	if task.Status == "" {
+		task.Status = "open"
+	}
+	if !taskStatuses[task.Status] {
+		return nil, nil, inputError("status must be one of open, in_progress or done")
+	}
+
+	if task.DueDate != "" && !isDate(task.DueDate) {
+		return nil, nil, inputError("due_date must be a date in YYYY-MM-DD format")
+	}
+
+	if task.Priority == "" {
+		task.Priority = "normal"
+	}
+	if !taskPriorities[task.Priority] {
+		return nil, nil, inputError("priority must be one of low, normal, high or urgent")
+	}
+
+	if task.EstimatedMinutes < 0 {
+		return nil, nil, inputError("estimated_minutes cannot be negative")
+	}
+
+	if !validChecklist(task.Checklist) {
+		return nil, nil, inputError("checklist items need a text")
+	}
+
+	tags, err := normalizeTags(task.Tags)
+	if err != nil {
+		return nil, nil, inputError(err.Error())
+	}
+
+	customFields, err := ValidateCustomFieldValues(fields, task.CustomFields, true)
+	if err != nil {
+		return nil, nil, inputError(err.Error())
+	}
+	return tags, customFields, nil
+}
+
+// insertTask saves a validated task with its tags, custom fields and checklist and returns its ID.
+// A done task without completed_at is completed now.
+func insertTask(db dbExecutor, task entities.Task, tags []string, customFields map[string]*string) (string, error) {
+	id := uuid.New().String()
+	insertQuery := `INSERT INTO tasks (id, summary, date, user_id, asset_id, location_id, status, due_date, completed_at,
+			priority, estimated_minutes, template_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, IF(? = 'done', COALESCE(?, UTC_TIMESTAMP()), NULL), ?, ?, ?)`
+	_, err := db.Exec(insertQuery, id, task.Summary, task.Date, task.UserID, nullString(task.AssetID), nullString(task.LocationID),
+		task.Status, nullString(task.DueDate), task.Status, nullString(task.CompletedAt), task.Priority, nullInt(task.EstimatedMinutes),
+		nullString(task.TemplateID))
+	if err != nil {
+		return "", err
+	}
+
+	err = setTaskTags(db, id, tags)
+	if err == nil {
+		err = setTaskCustomFields(db, id, customFields)
+	}
+	if err == nil {
+		err = setTaskChecklist(db, id, task.Checklist)
+	}
+	return id, err
+}
+
+func scanTask(row rowScanner) (entities.Task, error) {
+	var task entities.Task
+	var assetID, locationID, dueDate, completedAt, templateID, deletedAt, deletedBy sql.NullString
+	var estimate sql.NullInt64
+	err := row.Scan(&task.ID, &task.Summary, &task.Date, &task.UserID, &assetID, &locationID,
+		&task.Status, &dueDate, &completedAt, &task.Priority, &estimate, &templateID, &deletedAt, &deletedBy, &task.Version)
+	task.AssetID = assetID.String
+	task.LocationID = locationID.String
+	task.DueDate = dueDate.String
+	task.CompletedAt = completedAt.String
+	task.EstimatedMinutes = int(estimate.Int64)
+	task.TemplateID = templateID.String
+	task.DeletedAt = deletedAt.String
+	task.DeletedBy = deletedBy.String
+	return task, err
+}
+
+// findTaskWithDetails loads a task together with its tags, custom fields and checklist
+func findTaskWithDetails(db dbExecutor, id string) (entities.Task, error) {
+	task, err := findTask(db, id)
+	if err != nil {
+		return task, err
+	}
+	tasks := []entities.Task{task}
+	err = loadTaskDetails(db, tasks)
+	return tasks[0], err
+}
+
+// loadTaskDetails fills in the tags, custom field values and checklists of the given tasks
+func loadTaskDetails(db dbExecutor, tasks []entities.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(tasks))
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]interface{}, 0, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+		tasks[i].Tags = []string{}
+		tasks[i].CustomFields = map[string]interface{}{}
+		tasks[i].Checklist = []entities.ChecklistItem{}
+		placeholders = append(placeholders, "?")
+		args = append(args, tasks[i].ID)
+	}
+	in := "(" + strings.Join(placeholders, ", ") + ")"
+
+	tagRows, err := db.Query("SELECT task_id, tag FROM task_tags WHERE task_id IN "+in+" ORDER BY tag", args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(tagRows)
+	for tagRows.Next() {
+		var taskID, tag string
+		err := tagRows.Scan(&taskID, &tag)
+		if err != nil {
+			return err
+		}
+		tasks[index[taskID]].Tags = append(tasks[index[taskID]].Tags, tag)
+	}
+
+	fieldRows, err := db.Query("SELECT v.task_id, f.name, f.type, v.value FROM task_custom_field_values v"+
+		" JOIN custom_fields f ON f.id = v.field_id WHERE v.task_id IN "+in, args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(fieldRows)
+	for fieldRows.Next() {
+		var taskID, name, fieldType, value string
+		err := fieldRows.Scan(&taskID, &name, &fieldType, &value)
+		if err != nil {
+			return err
+		}
+		var typed interface{} = value
+		if fieldType == "number" {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				typed = number
+			}
+		}
+		tasks[index[taskID]].CustomFields[name] = typed
+	}
+
+	checklistRows, err := db.Query("SELECT task_id, text, done FROM task_checklist_items WHERE task_id IN "+in+
+		" ORDER BY task_id, position", args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(checklistRows)
+	for checklistRows.Next() {
+		var taskID string
+		var item entities.ChecklistItem
+		err := checklistRows.Scan(&taskID, &item.Text, &item.Done)
+		if err != nil {
+			return err
+		}
+		tasks[index[taskID]].Checklist = append(tasks[index[taskID]].Checklist, item)
+	}
+
+	return nil
+}
+
+// setTaskChecklist replaces the checklist of a task, keeping the item order
+func setTaskChecklist(db dbExecutor, taskID string, checklist []entities.ChecklistItem) error {
+	_, err := db.Exec("DELETE FROM task_checklist_items WHERE task_id = ?", taskID)
+	if err != nil {
+		return err
+	}
+	for position, item := range checklist {
+		_, err = db.Exec("INSERT INTO task_checklist_items (task_id, position, text, done) VALUES (?, ?, ?, ?)",
+			taskID, position, item.Text, item.Done)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validChecklist(checklist []entities.ChecklistItem) bool {
+	for _, item := range checklist {
+		if strings.TrimSpace(item.Text) == "" || len(item.Text) > 255 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,403 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const templateColumns = "id, name, summary, checklist, tags, priority, estimated_minutes, created_at"
+
+// taskPriorities lists the priorities a task or template can have
+var taskPriorities = map[string]bool{
+	"low":    true,
+	"normal": true,
+	"high":   true,
+	"urgent": true,
+}
+
+type TemplateModel struct {
+	Db *sql.DB
+}
+
+func TemplateHandler(db *sql.DB) *TemplateModel {
+	return &TemplateModel{
+		Db: db,
+	}
+}
+
+func (tm *TemplateModel) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage templates"); !ok {
+		return
+	}
+
+	template := entities.TaskTemplate{}
+	err := json.NewDecoder(r.Body).Decode(&template)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	if template.Priority == "" {
+		template.Priority = "normal"
+	}
+	template, ok := tm.validateTemplate(w, template)
+	if !ok {
+		return
+	}
+
+	checklist, tags, err := marshalTemplateLists(template)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize template:", err)
+		return
+	}
+
+	template.ID = uuid.New().String()
+	insertQuery := "INSERT INTO task_templates (id, name, summary, checklist, tags, priority, estimated_minutes) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, err = tm.Db.Exec(insertQuery, template.ID, template.Name, template.Summary, checklist, tags, template.Priority,
+		nullInt(template.EstimatedMinutes))
+	if err != nil {
+		http.Error(w, "Template creation failed", http.StatusInternalServerError)
+		log.Println("Template creation failed:", err)
+		return
+	}
+
+	created, err := findTemplate(tm.Db, template.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve created template", http.StatusInternalServerError)
+		log.Println("Failed to retrieve created template:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (tm *TemplateModel) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	rows, err := tm.Db.Query("SELECT " + templateColumns + " FROM task_templates ORDER BY name")
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving templates:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	templates := []entities.TaskTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	writeJSON(w, http.StatusOK, templates)
+}
+
+func (tm *TemplateModel) GetTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	template, ok := tm.loadTemplate(w, id)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+func (tm *TemplateModel) UpdateTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage templates"); !ok {
+		return
+	}
+
+	template, ok := tm.loadTemplate(w, id)
+	if !ok {
+		return
+	}
+
+	patchTemplate := make(map[string]interface{})
+	err := json.NewDecoder(r.Body).Decode(&patchTemplate)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Decoding failed:", err)
+		return
+	}
+
+	if name, ok := patchTemplate["name"].(string); ok {
+		template.Name = name
+	}
+	if summary, ok := patchTemplate["summary"].(string); ok {
+		template.Summary = summary
+	}
+	if priority, ok := patchTemplate["priority"].(string); ok {
+		template.Priority = priority
+	}
+	if estimate, ok := patchTemplate["estimated_minutes"]; ok {
+		// A null estimate removes it
+		minutes, _ := estimate.(float64)
+		template.EstimatedMinutes = int(minutes)
+	}
+	if rawChecklist, ok := patchTemplate["checklist"]; ok {
+		template.Checklist, ok = interfaceStrings(rawChecklist)
+		if !ok {
+			http.Error(w, "checklist must be a list of strings", http.StatusBadRequest)
+			return
+		}
+	}
+	if rawTags, ok := patchTemplate["tags"]; ok {
+		template.Tags, ok = interfaceStrings(rawTags)
+		if !ok {
+			http.Error(w, "tags must be a list of strings", http.StatusBadRequest)
+			return
+		}
+	}
+
+	template, ok = tm.validateTemplate(w, template)
+	if !ok {
+		return
+	}
+
+	checklist, tags, err := marshalTemplateLists(template)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize template:", err)
+		return
+	}
+
+	updateQuery := "UPDATE task_templates SET name = ?, summary = ?, checklist = ?, tags = ?, priority = ?, estimated_minutes = ? WHERE id = ?"
+	_, err = tm.Db.Exec(updateQuery, template.Name, template.Summary, checklist, tags, template.Priority,
+		nullInt(template.EstimatedMinutes), id)
+	if err != nil {
+		http.Error(w, "Template update failed", http.StatusInternalServerError)
+		log.Println("Failed to update template:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+func (tm *TemplateModel) DeleteTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage templates"); !ok {
+		return
+	}
+
+	// Tasks created from the template keep their copied values; only the link is cleared
+	result, err := tm.Db.Exec("DELETE FROM task_templates WHERE id = ?", id)
+	if err != nil {
+		http.Error(w, "Template deletion failed", http.StatusInternalServerError)
+		log.Println("Template deletion failed:", err)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{
+		Message: "Template deleted successfully",
+	})
+}
+
+// GetTemplateStats reports how often each template is used, how often its summary is changed
+// and how the logged time of its tasks compares to the estimate
+func (tm *TemplateModel) GetTemplateStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	if _, ok := requireManager(w, r, "Only Managers can manage templates"); !ok {
+		return
+	}
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	// Only the Manager's own team counts towards the figures
+	clause, args := visibleTasksFilter("t", userID)
+	rows, err := tm.Db.Query(`SELECT tt.id, tt.name, tt.estimated_minutes,
+			COUNT(t.id),
+			COALESCE(SUM(t.status = 'done'), 0),
+			COALESCE(SUM(t.summary <> tt.summary), 0),
+			COALESCE(AVG(logged.minutes), 0),
+			MAX(t.date)
+		FROM task_templates tt
+		LEFT JOIN tasks t ON t.template_id = tt.id AND `+clause+`
+		LEFT JOIN (
+			SELECT task_id, SUM(duration_minutes) AS minutes FROM worklogs WHERE ended_at IS NOT NULL GROUP BY task_id
+		) logged ON logged.task_id = t.id
+		GROUP BY tt.id, tt.name, tt.estimated_minutes
+		ORDER BY COUNT(t.id) DESC, tt.name`, args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving template statistics:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	stats := []entities.TemplateStats{}
+	for rows.Next() {
+		var stat entities.TemplateStats
+		var estimate sql.NullInt64
+		var lastUsed sql.NullString
+		err := rows.Scan(&stat.TemplateID, &stat.Name, &estimate, &stat.Uses, &stat.Completed, &stat.SummaryOverridden,
+			&stat.AvgLoggedMinutes, &lastUsed)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		stat.EstimatedMinutes = int(estimate.Int64)
+		stat.LastUsedAt = lastUsed.String
+		stats = append(stats, stat)
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// validateTemplate checks the template and returns it with its tags normalized
+func (tm *TemplateModel) validateTemplate(w http.ResponseWriter, template entities.TaskTemplate) (entities.TaskTemplate, bool) {
+	if template.Name == "" {
+		http.Error(w, "Missing required fields: name", http.StatusBadRequest)
+		return template, false
+	}
+	if !taskPriorities[template.Priority] {
+		http.Error(w, "priority must be one of low, normal, high or urgent", http.StatusBadRequest)
+		return template, false
+	}
+	if template.EstimatedMinutes < 0 {
+		http.Error(w, "estimated_minutes cannot be negative", http.StatusBadRequest)
+		return template, false
+	}
+	for _, item := range template.Checklist {
+		if item == "" {
+			http.Error(w, "checklist items cannot be empty", http.StatusBadRequest)
+			return template, false
+		}
+	}
+
+	tags, err := normalizeTags(template.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return template, false
+	}
+	template.Tags = tags
+	if template.Checklist == nil {
+		template.Checklist = []string{}
+	}
+	return template, true
+}
+
+func (tm *TemplateModel) loadTemplate(w http.ResponseWriter, id string) (entities.TaskTemplate, bool) {
+	template, err := findTemplate(tm.Db, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Template not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan template:", err)
+		}
+		return template, false
+	}
+	return template, true
+}
+
+func findTemplate(db *sql.DB, id string) (entities.TaskTemplate, error) {
+	return scanTemplate(db.QueryRow("SELECT "+templateColumns+" FROM task_templates WHERE id = ?", id))
+}
+
+func scanTemplate(row rowScanner) (entities.TaskTemplate, error) {
+	var template entities.TaskTemplate
+	var checklist, tags []byte
+	var estimate sql.NullInt64
+	err := row.Scan(&template.ID, &template.Name, &template.Summary, &checklist, &tags, &template.Priority, &estimate,
+		&template.CreatedAt)
+	if err != nil {
+		return template, err
+	}
+	template.EstimatedMinutes = int(estimate.Int64)
+	template.Checklist = []string{}
+	template.Tags = []string{}
+	if len(checklist) > 0 {
+		err = json.Unmarshal(checklist, &template.Checklist)
+	}
+	if err == nil && len(tags) > 0 {
+		err = json.Unmarshal(tags, &template.Tags)
+	}
+	return template, err
+}
+
+func marshalTemplateLists(template entities.TaskTemplate) ([]byte, []byte, error) {
+	checklist, err := json.Marshal(template.Checklist)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := json.Marshal(template.Tags)
+	return checklist, tags, err
+}
+
+// newTaskFromTemplate prefills a task with the defaults of a template
+func newTaskFromTemplate(template entities.TaskTemplate) entities.Task {
+	task := entities.Task{
+		Summary:          template.Summary,
+		Priority:         template.Priority,
+		EstimatedMinutes: template.EstimatedMinutes,
+		TemplateID:       template.ID,
+		Tags:             append([]string{}, template.Tags...),
+	}
+	for _, item := range template.Checklist {
+		task.Checklist = append(task.Checklist, entities.ChecklistItem{Text: item})
+	}
+	return task
+}
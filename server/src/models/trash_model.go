@@ -0,0 +1,199 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+type TrashModel struct {
+	Db     *sql.DB
+	Events *services.EventBus
+}
+
+func TrashHandler(db *sql.DB, events *services.EventBus) *TrashModel {
+	return &TrashModel{
+		Db:     db,
+		Events: events,
+	}
+}
+
+// GetTrash lists the trashed tasks of the manager's technicians within their sites, most recently deleted first
+func (tm *TrashModel) GetTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage the trash")
+	if !ok {
+		return
+	}
+
+	clause, args := teamTasksFilter("t", userID)
+	rows, err := tm.Db.Query("SELECT "+taskColumns+" FROM tasks t WHERE t.deleted_at IS NOT NULL AND "+clause+
+		" ORDER BY t.deleted_at DESC", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving trash:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks := []entities.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		tasks = append(tasks, task)
+	}
+
+	err = loadTaskDetails(tm.Db, tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving task details:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// RestoreTask moves a trashed task back into the normal listings
+func (tm *TrashModel) RestoreTask(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage the trash")
+	if !ok {
+		return
+	}
+
+	task, err := scanTask(tm.Db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ? AND deleted_at IS NOT NULL", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Task not found in trash", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan task:", err)
+		}
+		return
+	}
+
+	// Only the technician's own manager may restore the task, as for deletion
+	allowed, err := canDeleteTask(tm.Db, task, userID, "")
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to query managers:", err)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Only Managers associated with this user can restore this task", http.StatusForbidden)
+		return
+	}
+
+	_, err = tm.Db.Exec("UPDATE tasks SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ?", task.ID)
+	if err != nil {
+		http.Error(w, "Task restore failed", http.StatusInternalServerError)
+		log.Println("Task restore failed:", err)
+		return
+	}
+
+	restored, err := findTaskWithDetails(tm.Db, task.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to retrieve restored task:", err)
+		return
+	}
+	recordAudit(tm.Db, r, "restore", "task", task.ID, task, restored)
+	// A restored task reappears in the listings, so streams see it as created again
+	publishTaskEvent(tm.Events, taskCreated, restored, userID)
+
+	writeJSON(w, http.StatusOK, restored)
+}
+
+// PurgeTrash permanently deletes the tasks that have been in the trash for longer than
+// retentionDays, together with their attachment files, and returns how many were removed
+func PurgeTrash(ctx context.Context, db *sql.DB, store services.BlobStore, retentionDays int64) (int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id FROM tasks WHERE deleted_at < UTC_TIMESTAMP() - INTERVAL ? DAY", retentionDays)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		keys, err := attachmentKeys(ctx, db, id)
+		if err != nil {
+			return purged, err
+		}
+
+		// Dependent rows such as worklogs, tags and attachments are removed by the foreign keys
+		_, err = db.ExecContext(ctx, "DELETE FROM tasks WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return purged, err
+		}
+		purged++
+
+		// Files are removed after the rows so a failure leaves orphaned files rather than broken links
+		for _, key := range keys {
+			err := store.Delete(ctx, key)
+			if err != nil && !errors.Is(err, services.ErrBlobNotFound) {
+				log.Println("Failed to delete attachment file", key+":", err)
+			}
+		}
+	}
+	return purged, nil
+}
+
+func attachmentKeys(ctx context.Context, db *sql.DB, taskID string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT storage_key FROM task_attachments WHERE task_id = ?", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		err := rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -60,13 +61,21 @@ func (tm *UserModel) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Insert user details into the database
-	userID, err := tm.insertUser(user.FirstName, user.LastName, user.Email, hashedPassword, user.ManagerID)
+	userID, err := insertUser(tm.Db, user.FirstName, user.LastName, user.Email, hashedPassword, user.ManagerID)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		log.Fatal("Account creation failed:", err)
 		return
 	}
 
+	recordAudit(tm.Db, r, "create", "user", userID, nil, entities.User{
+		ID:        userID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		ManagerID: user.ManagerID,
+	})
+
 	// Generate JWT token
 	tokenString, err := tm.generateToken(userID, user.ManagerID)
 	if err != nil {
@@ -122,12 +131,13 @@ func (tm *UserModel) hashPassword(password []byte) ([]byte, error) {
 	return bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
 }
 
-func (tm *UserModel) insertUser(firstName, lastName, email string, hashedPassword []byte, managerID string) (string, error) {
+// insertUser creates the account and, for technicians, links it to the manager
+func insertUser(db dbExecutor, firstName, lastName, email string, hashedPassword []byte, managerID string) (string, error) {
 	userID := uuid.New().String()
 
 	// Insert the technician or manager
 	query := "INSERT INTO users (id, first_name, last_name, email, password) VALUES (?, ?, ?, ?, ?)"
-	_, err := tm.Db.Exec(query, userID, firstName, lastName, email, hashedPassword)
+	_, err := db.Exec(query, userID, firstName, lastName, email, hashedPassword)
 	if err != nil {
 		return "", err
 	}
@@ -138,7 +148,7 @@ func (tm *UserModel) insertUser(firstName, lastName, email string, hashedPasswor
 			return "", fmt.Errorf("invalid managerID")
 		}
 		managerExistsQuery := "SELECT id FROM users WHERE id = ?"
-		row := tm.Db.QueryRow(managerExistsQuery, managerID)
+		row := db.QueryRow(managerExistsQuery, managerID)
 		var mID string
 		err = row.Scan(&mID)
 		fmt.Printf("managerID: %v\n", managerID)
@@ -150,7 +160,7 @@ func (tm *UserModel) insertUser(firstName, lastName, email string, hashedPasswor
 		if managerID != "" {
 			id := uuid.New().String()
 			relationshipQuery := "INSERT INTO managers (id, manager_id, technician_id) VALUES (?, ?, ?)"
-			_, err = tm.Db.Exec(relationshipQuery, id, managerID, userID)
+			_, err = db.Exec(relationshipQuery, id, managerID, userID)
 			if err != nil {
 				return "", err
 			}
@@ -189,8 +199,15 @@ func (tm *UserModel) GetAllTasksByUserID(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	// Fetch tasks from the database based on the user ID
-	rows, err := tm.Db.Query("SELECT id, summary, date, user_id FROM tasks WHERE user_id = ?", userID)
+	// Fetch tasks from the database based on the user ID and the listing filters
+	where, args, err := taskFilters(r.URL.Query(), "t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	where = append([]string{"t.user_id = ?", "t.deleted_at IS NULL"}, where...)
+	args = append([]interface{}{userID}, args...)
+	rows, err := tm.Db.Query("SELECT "+taskColumns+" FROM tasks t WHERE "+strings.Join(where, " AND "), args...)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 		log.Println("Error retrieving data", err)
@@ -206,8 +223,7 @@ func (tm *UserModel) GetAllTasksByUserID(w http.ResponseWriter, r *http.Request,
 	var tasks []entities.Task
 
 	for rows.Next() {
-		task := entities.Task{}
-		err := rows.Scan(&task.ID, &task.Summary, &task.Date, &task.UserID)
+		task, err := scanTask(rows)
 		if err != nil {
 			http.Error(w, "Something went wrong", http.StatusInternalServerError)
 			log.Println(err)
@@ -223,6 +239,13 @@ func (tm *UserModel) GetAllTasksByUserID(w http.ResponseWriter, r *http.Request,
 		tasks = append(tasks, task)
 	}
 
+	err = loadTaskDetails(tm.Db, tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load task details:", err)
+		return
+	}
+
 	response, err := json.Marshal(tasks)
 	if err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
@@ -292,7 +315,7 @@ func (tm *UserModel) GetAllUsersAndAllTasks(w http.ResponseWriter, r *http.Reque
 	}
 
 	for i := range users {
-		rows, userErr := tm.Db.Query("SELECT id, summary, date FROM tasks WHERE user_id = ?", users[i].ID)
+		rows, userErr := tm.Db.Query("SELECT "+taskColumns+" FROM tasks WHERE user_id = ? AND deleted_at IS NULL", users[i].ID)
 		if userErr != nil {
 			http.Error(w, "Something went wrong", http.StatusInternalServerError)
 			log.Println(userErr)
@@ -302,8 +325,7 @@ func (tm *UserModel) GetAllUsersAndAllTasks(w http.ResponseWriter, r *http.Reque
 		var tasks []entities.Task
 
 		for rows.Next() {
-			task := entities.Task{}
-			err := rows.Scan(&task.ID, &task.Summary, &task.Date)
+			task, err := scanTask(rows)
 			if err != nil {
 				err := rows.Close()
 				if err != nil {
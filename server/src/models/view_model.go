@@ -0,0 +1,556 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const viewColumns = "v.id, v.owner_id, v.name, v.filters, v.shared, v.created_at, d.view_id IS NOT NULL"
+
+// viewFilterParams are the listing parameters a saved view may hold, besides field.<name>
+var viewFilterParams = map[string]bool{
+	"from":        true,
+	"to":          true,
+	"status":      true,
+	"priority":    true,
+	"asset_id":    true,
+	"location_id": true,
+	"tag":         true,
+	"user_id":     true,
+}
+
+type ViewModel struct {
+	Db *sql.DB
+}
+
+func ViewHandler(db *sql.DB) *ViewModel {
+	return &ViewModel{
+		Db: db,
+	}
+}
+
+func (vm *ViewModel) CreateView(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	view := entities.SavedView{}
+	err = json.NewDecoder(r.Body).Decode(&view)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+	if !validateView(w, view) {
+		return
+	}
+
+	filters, err := json.Marshal(view.Filters)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize view filters:", err)
+		return
+	}
+
+	view.ID = uuid.New().String()
+	_, err = vm.Db.Exec("INSERT INTO saved_views (id, owner_id, name, filters, shared) VALUES (?, ?, ?, ?, ?)",
+		view.ID, userID, view.Name, filters, view.Shared)
+	if err != nil {
+		writeViewError(w, err, "View creation failed")
+		return
+	}
+	if view.IsDefault {
+		err = setDefaultView(vm.Db, userID, view.ID)
+		if err != nil {
+			http.Error(w, "View creation failed", http.StatusInternalServerError)
+			log.Println("Failed to set default view:", err)
+			return
+		}
+	}
+
+	created, err := findView(vm.Db, view.ID, userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve created view", http.StatusInternalServerError)
+		log.Println("Failed to retrieve created view:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetViews lists the user's own views and the views shared with their team, the default first
+func (vm *ViewModel) GetViews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	visible, args := visibleViewsClause(userID)
+	rows, err := vm.Db.Query("SELECT "+viewColumns+" FROM saved_views v"+
+		" LEFT JOIN saved_view_defaults d ON d.view_id = v.id AND d.user_id = ?"+
+		" WHERE "+visible+" ORDER BY d.view_id IS NULL, v.name", append([]interface{}{userID}, args...)...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving views:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	views := []entities.SavedView{}
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		views = append(views, view)
+	}
+
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (vm *ViewModel) GetView(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	view, ok := vm.loadView(w, id, userID)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, view)
+}
+
+// UpdateView changes the name, filters or sharing of a view; only its owner may do so
+func (vm *ViewModel) UpdateView(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	view, ok := vm.loadOwnView(w, id, userID)
+	if !ok {
+		return
+	}
+
+	var patchView struct {
+		Name    *string               `json:"name"`
+		Filters *entities.ViewFilters `json:"filters"`
+		Shared  *bool                 `json:"shared"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&patchView)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Decoding failed:", err)
+		return
+	}
+	if patchView.Name != nil {
+		view.Name = *patchView.Name
+	}
+	if patchView.Filters != nil {
+		view.Filters = *patchView.Filters
+	}
+	if patchView.Shared != nil {
+		view.Shared = *patchView.Shared
+	}
+	if !validateView(w, view) {
+		return
+	}
+
+	filters, err := json.Marshal(view.Filters)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to serialize view filters:", err)
+		return
+	}
+
+	_, err = vm.Db.Exec("UPDATE saved_views SET name = ?, filters = ?, shared = ? WHERE id = ?", view.Name, filters, view.Shared, id)
+	if err != nil {
+		writeViewError(w, err, "View update failed")
+		return
+	}
+	if !view.Shared {
+		// Team members can no longer see the view, so it stops being their default
+		_, err = vm.Db.Exec("DELETE FROM saved_view_defaults WHERE view_id = ? AND user_id <> ?", id, userID)
+		if err != nil {
+			log.Println("Failed to clear team defaults:", err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (vm *ViewModel) DeleteView(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	if _, ok := vm.loadOwnView(w, id, userID); !ok {
+		return
+	}
+
+	// Defaults pointing at the view are removed by the foreign key
+	_, err = vm.Db.Exec("DELETE FROM saved_views WHERE id = ?", id)
+	if err != nil {
+		http.Error(w, "View deletion failed", http.StatusInternalServerError)
+		log.Println("View deletion failed:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{
+		Message: "View deleted successfully",
+	})
+}
+
+// SetDefaultView makes a visible view the user's default, replacing the previous one
+func (vm *ViewModel) SetDefaultView(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	view, ok := vm.loadView(w, id, userID)
+	if !ok {
+		return
+	}
+
+	err = setDefaultView(vm.Db, userID, view.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to set default view:", err)
+		return
+	}
+	view.IsDefault = true
+
+	writeJSON(w, http.StatusOK, view)
+}
+
+// ClearDefaultView removes the view as the user's default
+func (vm *ViewModel) ClearDefaultView(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	view, ok := vm.loadView(w, id, userID)
+	if !ok {
+		return
+	}
+
+	_, err = vm.Db.Exec("DELETE FROM saved_view_defaults WHERE user_id = ? AND view_id = ?", userID, view.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to clear default view:", err)
+		return
+	}
+	view.IsDefault = false
+
+	writeJSON(w, http.StatusOK, view)
+}
+
+// GetViewTasks runs a saved view as the current user. Relative dates are resolved at the time of
+// the request, and the usual visibility rules apply, so a shared view only shows what the caller may see.
+func (vm *ViewModel) GetViewTasks(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	view, ok := vm.loadView(w, id, userID)
+	if !ok {
+		return
+	}
+
+	where, args, err := viewConditions(view.Filters, userID, time.Now().UTC())
+	if err != nil {
+		// Filters are checked when saved, but a custom field or status may have changed since
+		http.Error(w, "View can no longer be applied: "+err.Error(), http.StatusConflict)
+		return
+	}
+	visible, visibleArgs := visibleTasksFilter("t", userID)
+	where = append([]string{visible}, where...)
+	args = append(visibleArgs, args...)
+
+	rows, err := vm.Db.Query("SELECT "+taskColumns+" FROM tasks t WHERE "+strings.Join(where, " AND ")+" ORDER BY t.date, t.id", args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving view tasks:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	tasks := []entities.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		tasks = append(tasks, task)
+	}
+
+	err = loadTaskDetails(vm.Db, tasks)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load task details:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (vm *ViewModel) loadView(w http.ResponseWriter, id string, userID string) (entities.SavedView, bool) {
+	view, err := findView(vm.Db, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "View not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan view:", err)
+		}
+		return view, false
+	}
+	return view, true
+}
+
+func (vm *ViewModel) loadOwnView(w http.ResponseWriter, id string, userID string) (entities.SavedView, bool) {
+	view, ok := vm.loadView(w, id, userID)
+	if ok && view.OwnerID != userID {
+		http.Error(w, "Only the owner can change this view", http.StatusForbidden)
+		return view, false
+	}
+	return view, ok
+}
+
+// findView loads a view the user may see, marking whether it is their default
+func findView(db *sql.DB, id string, userID string) (entities.SavedView, error) {
+	visible, args := visibleViewsClause(userID)
+	args = append([]interface{}{userID, id}, args...)
+	return scanView(db.QueryRow("SELECT "+viewColumns+" FROM saved_views v"+
+		" LEFT JOIN saved_view_defaults d ON d.view_id = v.id AND d.user_id = ?"+
+		" WHERE v.id = ? AND "+visible, args...))
+}
+
+func scanView(row rowScanner) (entities.SavedView, error) {
+	var view entities.SavedView
+	var filters []byte
+	err := row.Scan(&view.ID, &view.OwnerID, &view.Name, &filters, &view.Shared, &view.CreatedAt, &view.IsDefault)
+	if err != nil {
+		return view, err
+	}
+	err = json.Unmarshal(filters, &view.Filters)
+	return view, err
+}
+
+// visibleViewsClause restricts saved_views aliased as v to the user's own views and those shared
+// within their team: their manager and fellow technicians, or a manager's technicians
+func visibleViewsClause(userID string) (string, []interface{}) {
+	clause := `(v.owner_id = ? OR (v.shared AND v.owner_id IN (
+		SELECT manager_id FROM managers WHERE technician_id = ?
+		UNION
+		SELECT technician_id FROM managers WHERE manager_id = ?
+			OR manager_id IN (SELECT manager_id FROM managers WHERE technician_id = ?))))`
+	return clause, []interface{}{userID, userID, userID, userID}
+}
+
+func setDefaultView(db dbExecutor, userID string, viewID string) error {
+	_, err := db.Exec("INSERT INTO saved_view_defaults (user_id, view_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE view_id = VALUES(view_id)",
+		userID, viewID)
+	return err
+}
+
+func validateView(w http.ResponseWriter, view entities.SavedView) bool {
+	if strings.TrimSpace(view.Name) == "" {
+		http.Error(w, "Missing required fields: name", http.StatusBadRequest)
+		return false
+	}
+	if len(view.Name) > 100 {
+		http.Error(w, "name must be at most 100 characters", http.StatusBadRequest)
+		return false
+	}
+	if err := ValidateViewFilters(view.Filters); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeViewError(w http.ResponseWriter, err error, message string) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		http.Error(w, "You already have a view with this name", http.StatusConflict)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+	log.Println(message+":", err)
+}
+
+// ValidateViewFilters checks that the filters only use listing parameters and would be accepted by the listing
+func ValidateViewFilters(filters entities.ViewFilters) error {
+	for param := range filters {
+		if !viewFilterParams[param] && !strings.HasPrefix(param, customFieldFilterPrefix) {
+			return fmt.Errorf("unknown filter %q", param)
+		}
+	}
+	_, _, err := viewConditions(filters, "", time.Now().UTC())
+	return err
+}
+
+// viewConditions turns saved filters into SQL conditions for the user running the view.
+// user_id may be "me" for the current user.
+func viewConditions(filters entities.ViewFilters, userID string, now time.Time) ([]string, []interface{}, error) {
+	query := url.Values{}
+	for param, values := range filters {
+		for _, value := range values {
+			if param == "from" || param == "to" {
+				date, ok := ResolveRelativeDate(value, now)
+				if !ok {
+					return nil, nil, fmt.Errorf("%s must be a date in YYYY-MM-DD format or a relative date such as today-7 or week_start", param)
+				}
+				value = date
+			}
+			query.Add(param, value)
+		}
+	}
+
+	where, args, err := taskFilters(query, "t")
+	if err != nil {
+		return nil, nil, err
+	}
+	if filterUserID := query.Get("user_id"); filterUserID != "" {
+		if filterUserID == "me" {
+			filterUserID = userID
+		}
+		where = append(where, "t.user_id = ?")
+		args = append(args, filterUserID)
+	}
+	return where, args, nil
+}
+
+// ResolveRelativeDate turns today, yesterday, tomorrow, today+N, today-N, week_start, week_end,
+// month_start and month_end into a date relative to now. Weeks start on Monday. Plain dates are
+// returned unchanged.
+func ResolveRelativeDate(value string, now time.Time) (string, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var date time.Time
+	switch value {
+	case "today":
+		date = today
+	case "yesterday":
+		date = today.AddDate(0, 0, -1)
+	case "tomorrow":
+		date = today.AddDate(0, 0, 1)
+	case "week_start", "week_end":
+		weekday := (int(today.Weekday()) + 6) % 7
+		date = today.AddDate(0, 0, -weekday)
+		if value == "week_end" {
+			date = date.AddDate(0, 0, 6)
+		}
+	case "month_start":
+		date = today.AddDate(0, 0, 1-today.Day())
+	case "month_end":
+		date = today.AddDate(0, 1, -today.Day())
+	default:
+		if offset := strings.TrimPrefix(value, "today"); offset != value && len(offset) > 1 && (offset[0] == '+' || offset[0] == '-') {
+			days, err := strconv.Atoi(offset)
+			if err != nil {
+				return "", false
+			}
+			date = today.AddDate(0, 0, days)
+			break
+		}
+		return value, isDate(value)
+	}
+	return date.Format(mysqlDate), true
+}
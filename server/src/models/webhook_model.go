@@ -0,0 +1,733 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+const (
+	defaultWebhookPageSize = 20
+	maxWebhookPageSize     = 100
+	maxWebhookURLLength    = 2048
+	webhookBatchSize       = 50
+	webhookQueueBuffer     = 1024
+	// webhookClaim is how long a delivery is reserved for the instance sending it
+	webhookClaim      = 5 * time.Minute
+	webhookFirstRetry = 30 * time.Second
+	webhookMaxRetry   = 6 * time.Hour
+)
+
+// webhookEventTypes are the events webhooks can receive
+var webhookEventTypes = map[string]bool{taskCreated: true, taskUpdated: true, taskDeleted: true}
+
+var webhookDeliveryStatuses = map[string]bool{"pending": true, "delivered": true, "dead": true}
+
+type WebhookModel struct {
+	Db *sql.DB
+}
+
+func WebhookHandler(db *sql.DB) *WebhookModel {
+	return &WebhookModel{
+		Db: db,
+	}
+}
+
+// CreateWebhook registers a URL to receive the manager's team task events. The response is the only
+// time the signing secret is shown.
+func (wm *WebhookModel) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	webhook := entities.Webhook{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&webhook)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	err = ValidateWebhook(webhook)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhook.ID = uuid.New().String()
+	webhook.CreatedAt = time.Now().UTC().Format(mysqlDateTime)
+	webhook.Secret, err = newWebhookSecret()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to generate webhook secret:", err)
+		return
+	}
+
+	_, err = wm.Db.Exec("INSERT INTO webhooks (id, manager_id, url, description, event_types, secret, active, created_at)"+
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?)", webhook.ID, userID, webhook.URL, webhook.Description, strings.Join(webhook.EventTypes, ","),
+		webhook.Secret, webhook.Active, webhook.CreatedAt)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to save webhook:", err)
+		return
+	}
+
+	audited := webhook
+	audited.Secret = ""
+	recordAudit(wm.Db, r, "create", "webhook", webhook.ID, nil, audited)
+	writeJSON(w, http.StatusCreated, webhook)
+}
+
+// GetWebhooks lists the manager's webhooks, oldest first
+func (wm *WebhookModel) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	rows, err := wm.Db.Query("SELECT id, url, description, event_types, active, created_at FROM webhooks WHERE manager_id = ?"+
+		" ORDER BY created_at, id", userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error fetching webhooks:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	webhooks := []entities.Webhook{}
+	for rows.Next() {
+		var webhook entities.Webhook
+		var eventTypes string
+		err := rows.Scan(&webhook.ID, &webhook.URL, &webhook.Description, &eventTypes, &webhook.Active, &webhook.CreatedAt)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan webhook:", err)
+			return
+		}
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error fetching webhooks:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+// GetWebhook returns one of the manager's webhooks
+func (wm *WebhookModel) GetWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	webhook, ok := wm.findWebhook(w, id, userID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// UpdateWebhook changes a webhook's URL, description, event types or whether it is active. Fields
+// left out keep their value.
+func (wm *WebhookModel) UpdateWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	before, ok := wm.findWebhook(w, id, userID)
+	if !ok {
+		return
+	}
+	webhook := before
+	err := json.NewDecoder(r.Body).Decode(&webhook)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	webhook.ID, webhook.Secret, webhook.CreatedAt = before.ID, "", before.CreatedAt
+	err = ValidateWebhook(webhook)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = wm.Db.Exec("UPDATE webhooks SET url = ?, description = ?, event_types = ?, active = ? WHERE id = ? AND manager_id = ?",
+		webhook.URL, webhook.Description, strings.Join(webhook.EventTypes, ","), webhook.Active, id, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to update webhook:", err)
+		return
+	}
+	recordAudit(wm.Db, r, "update", "webhook", id, before, webhook)
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook removes a webhook along with its delivery log
+func (wm *WebhookModel) DeleteWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	webhook, ok := wm.findWebhook(w, id, userID)
+	if !ok {
+		return
+	}
+	_, err := wm.Db.Exec("DELETE FROM webhooks WHERE id = ? AND manager_id = ?", id, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to delete webhook:", err)
+		return
+	}
+	recordAudit(wm.Db, r, "delete", "webhook", id, webhook, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateWebhookSecret replaces a webhook's signing secret and returns the webhook with the new one.
+// Deliveries sent from then on, including retries, are signed with it.
+func (wm *WebhookModel) RotateWebhookSecret(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	webhook, ok := wm.findWebhook(w, id, userID)
+	if !ok {
+		return
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to generate webhook secret:", err)
+		return
+	}
+	_, err = wm.Db.Exec("UPDATE webhooks SET secret = ? WHERE id = ? AND manager_id = ?", secret, id, userID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to rotate webhook secret:", err)
+		return
+	}
+	recordAudit(wm.Db, r, "rotate_secret", "webhook", id, nil, nil)
+
+	webhook.Secret = secret
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// GetWebhookDeliveries lists a webhook's deliveries, newest first, optionally only those with the given status
+func (wm *WebhookModel) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, offset, err := pageParams(query.Get("limit"), query.Get("offset"), defaultWebhookPageSize, maxWebhookPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status := query.Get("status")
+	if status != "" && !webhookDeliveryStatuses[status] {
+		http.Error(w, "status must be pending, delivered or dead", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := wm.findWebhook(w, id, userID); !ok {
+		return
+	}
+
+	sqlQuery := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE webhook_id = ?"
+	args := []interface{}{id}
+	if status != "" {
+		sqlQuery += " AND status = ?"
+		args = append(args, status)
+	}
+	rows, err := wm.Db.Query(sqlQuery+" ORDER BY created_at DESC, event_id DESC LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error fetching webhook deliveries:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	deliveries := []entities.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to scan webhook delivery:", err)
+			return
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error fetching webhook deliveries:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// GetWebhookDelivery returns a delivery with its payload and every attempt made to send it
+func (wm *WebhookModel) GetWebhookDelivery(w http.ResponseWriter, r *http.Request, id string, deliveryID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+	if _, ok := wm.findWebhook(w, id, userID); !ok {
+		return
+	}
+
+	delivery, ok := wm.findDelivery(w, id, deliveryID)
+	if !ok {
+		return
+	}
+	err := wm.Db.QueryRow("SELECT payload FROM webhook_deliveries WHERE id = ?", deliveryID).Scan(&delivery.Payload)
+	if err == nil {
+		delivery.AttemptLog, err = loadWebhookAttempts(wm.Db, deliveryID)
+	}
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to load webhook delivery:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// RedeliverWebhook sends a delivery again, whatever its status, with a fresh set of retries. The
+// receiver gets the same delivery ID, so it can tell a redelivery from a new event.
+func (wm *WebhookModel) RedeliverWebhook(w http.ResponseWriter, r *http.Request, id string, deliveryID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, ok := requireManager(w, r, "Only Managers can manage webhooks")
+	if !ok {
+		return
+	}
+	webhook, ok := wm.findWebhook(w, id, userID)
+	if !ok {
+		return
+	}
+	if !webhook.Active {
+		http.Error(w, "Activate the webhook before redelivering", http.StatusConflict)
+		return
+	}
+	if _, ok := wm.findDelivery(w, id, deliveryID); !ok {
+		return
+	}
+
+	_, err := wm.Db.Exec("UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = ?, error = ''"+
+		" WHERE id = ? AND webhook_id = ?", time.Now().UTC().Format(mysqlDateTime), deliveryID, id)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to queue webhook redelivery:", err)
+		return
+	}
+
+	delivery, ok := wm.findDelivery(w, id, deliveryID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusAccepted, delivery)
+}
+
+// findWebhook loads one of the manager's webhooks, writing the error response when that fails
+func (wm *WebhookModel) findWebhook(w http.ResponseWriter, id string, managerID string) (entities.Webhook, bool) {
+	var webhook entities.Webhook
+	var eventTypes string
+	err := wm.Db.QueryRow("SELECT id, url, description, event_types, active, created_at FROM webhooks WHERE id = ? AND manager_id = ?",
+		id, managerID).Scan(&webhook.ID, &webhook.URL, &webhook.Description, &eventTypes, &webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to load webhook:", err)
+		}
+		return webhook, false
+	}
+	webhook.EventTypes = strings.Split(eventTypes, ",")
+	return webhook, true
+}
+
+// findDelivery loads a delivery of a webhook, writing the error response when that fails
+func (wm *WebhookModel) findDelivery(w http.ResponseWriter, webhookID string, deliveryID string) (entities.WebhookDelivery, bool) {
+	delivery, err := scanWebhookDelivery(wm.Db.QueryRow("SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries"+
+		" WHERE id = ? AND webhook_id = ?", deliveryID, webhookID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to load webhook delivery:", err)
+		}
+		return delivery, false
+	}
+	return delivery, true
+}
+
+const webhookDeliveryColumns = "id, webhook_id, event_id, event_type, task_id, status, attempts, next_attempt_at, response_code, error," +
+	" delivered_at, created_at"
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (entities.WebhookDelivery, error) {
+	var delivery entities.WebhookDelivery
+	var nextAttemptAt, deliveredAt sql.NullString
+	var responseCode sql.NullInt64
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.TaskID, &delivery.Status,
+		&delivery.Attempts, &nextAttemptAt, &responseCode, &delivery.Error, &deliveredAt, &delivery.CreatedAt)
+	delivery.NextAttemptAt = nextAttemptAt.String
+	delivery.ResponseCode = int(responseCode.Int64)
+	delivery.DeliveredAt = deliveredAt.String
+	return delivery, err
+}
+
+func loadWebhookAttempts(db *sql.DB, deliveryID string) ([]entities.WebhookAttempt, error) {
+	rows, err := db.Query("SELECT attempted_at, response_code, response, error, duration_ms FROM webhook_attempts"+
+		" WHERE delivery_id = ? ORDER BY attempted_at, id", deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	attempts := []entities.WebhookAttempt{}
+	for rows.Next() {
+		var attempt entities.WebhookAttempt
+		var responseCode sql.NullInt64
+		err := rows.Scan(&attempt.AttemptedAt, &responseCode, &attempt.Response, &attempt.Error, &attempt.DurationMs)
+		if err != nil {
+			return nil, err
+		}
+		attempt.ResponseCode = int(responseCode.Int64)
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// ValidateWebhook checks that a webhook has an absolute http or https URL and at least one known event type
+func ValidateWebhook(webhook entities.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if len(webhook.URL) > maxWebhookURLLength {
+		return errors.New("url must be at most 2048 characters")
+	}
+	if len(webhook.Description) > 255 {
+		return errors.New("description must be at most 255 characters")
+	}
+	if len(webhook.EventTypes) == 0 {
+		return errors.New("event_types must list task.created, task.updated or task.deleted")
+	}
+	seen := make(map[string]bool)
+	for _, eventType := range webhook.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+		if seen[eventType] {
+			return fmt.Errorf("event type %q is listed twice", eventType)
+		}
+		seen[eventType] = true
+	}
+	return nil
+}
+
+// WebhookBackoff returns how long to wait after a delivery failed attempts times: 30 seconds after
+// the first failure, doubling with each one, up to 6 hours
+func WebhookBackoff(attempts int) time.Duration {
+	delay := webhookFirstRetry
+	for i := 1; i < attempts && delay < webhookMaxRetry; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetry {
+		delay = webhookMaxRetry
+	}
+	return delay
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// QueueWebhookDeliveries records a delivery for every webhook that should receive the task events
+// published on this instance. Events relayed from other instances are queued there. It runs until
+// the process exits.
+func QueueWebhookDeliveries(db *sql.DB, events *services.EventBus) {
+	subscription := events.Subscribe(0, webhookQueueBuffer)
+	for {
+		event, ok := <-subscription.Events
+		if !ok {
+			log.Println("Webhook queue fell behind the event bus, resubscribing")
+			subscription = events.Subscribe(0, webhookQueueBuffer)
+			continue
+		}
+		if event.Origin != events.Origin() || !webhookEventTypes[event.Type] {
+			continue
+		}
+		err := queueWebhookEvent(db, event, time.Now())
+		if err != nil {
+			log.Printf("Failed to queue webhooks for event %d: %v\n", event.ID, err)
+		}
+	}
+}
+
+// queueWebhookEvent queues the event for the active webhooks subscribed to its type whose manager can
+// see the task
+func queueWebhookEvent(db *sql.DB, event services.Event, now time.Time) error {
+	type candidate struct {
+		id        string
+		managerID string
+	}
+
+	rows, err := db.Query(`SELECT w.id, w.manager_id, w.event_types FROM webhooks w JOIN tasks t ON t.id = ?
+		WHERE w.active = TRUE AND (w.manager_id = t.user_id
+			OR EXISTS (SELECT 1 FROM managers m WHERE m.manager_id = w.manager_id AND m.technician_id = t.user_id))`, event.Subject)
+	if err != nil {
+		return err
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		var eventTypes string
+		err := rows.Scan(&c.id, &c.managerID, &eventTypes)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		for _, eventType := range strings.Split(eventTypes, ",") {
+			if eventType == event.Type {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	due := now.UTC().Format(mysqlDateTime)
+	for _, c := range candidates {
+		visible, err := taskVisibleTo(db, event.Subject, c.managerID)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			continue
+		}
+		_, err = db.Exec("INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, task_id, payload, next_attempt_at, created_at)"+
+			" VALUES (?, ?, ?, ?, ?, ?, ?, ?)", uuid.New().String(), c.id, event.ID, event.Type, event.Subject, event.Data, due, due)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliverDueWebhooks sends the pending deliveries whose next attempt is due and returns how many
+// were delivered. A delivery that fails is retried with WebhookBackoff until maxAttempts have been
+// made, then marked dead. Each delivery is claimed before it is sent, so concurrent servers do not
+// send it twice.
+func DeliverDueWebhooks(ctx context.Context, db *sql.DB, sender *services.WebhookSender, now time.Time, maxAttempts int) (int, error) {
+	delivered := 0
+	for {
+		due := now.UTC().Format(mysqlDateTime)
+		rows, err := db.Query("SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= ?"+
+			" ORDER BY next_attempt_at LIMIT ?", due, webhookBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return delivered, err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Close(); err != nil {
+			return delivered, err
+		}
+		if err := rows.Err(); err != nil {
+			return delivered, err
+		}
+		if len(ids) == 0 {
+			return delivered, nil
+		}
+
+		for _, id := range ids {
+			// Claimed deliveries are no longer due, so every batch moves on to new ones
+			result, err := db.Exec("UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id = ? AND status = 'pending' AND next_attempt_at <= ?",
+				now.Add(webhookClaim).UTC().Format(mysqlDateTime), id, due)
+			if err != nil {
+				return delivered, err
+			}
+			if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+				continue
+			}
+
+			ok, err := attemptWebhook(ctx, db, sender, id, now, maxAttempts)
+			if err != nil {
+				log.Printf("Failed to record webhook delivery %s: %v\n", id, err)
+				continue
+			}
+			if ok {
+				delivered++
+			}
+		}
+	}
+}
+
+// attemptWebhook sends a claimed delivery once and records the outcome
+func attemptWebhook(ctx context.Context, db *sql.DB, sender *services.WebhookSender, id string, now time.Time, maxAttempts int) (bool, error) {
+	var request services.WebhookRequest
+	var attempts int
+	var active bool
+	err := db.QueryRow("SELECT d.event_type, d.payload, d.attempts, w.url, w.secret, w.active FROM webhook_deliveries d"+
+		" JOIN webhooks w ON w.id = d.webhook_id WHERE d.id = ?", id).Scan(&request.Event, &request.Payload, &attempts, &request.URL,
+		&request.Secret, &active)
+	if err != nil {
+		return false, err
+	}
+	request.DeliveryID = id
+
+	if !active {
+		_, err = db.Exec("UPDATE webhook_deliveries SET status = 'dead', next_attempt_at = NULL, error = 'The webhook was deactivated' WHERE id = ?", id)
+		return false, err
+	}
+
+	start := time.Now()
+	response, sendErr := sender.Send(ctx, request, now)
+	duration := time.Since(start).Milliseconds()
+	attempts++
+
+	var responseCode interface{}
+	failure := ""
+	if sendErr != nil {
+		failure = sendErr.Error()
+	} else {
+		responseCode = response.StatusCode
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			failure = fmt.Sprintf("The receiver answered %d", response.StatusCode)
+		}
+	}
+	failure = truncateText(failure, 500)
+	attemptedAt := now.UTC().Format(mysqlDateTime)
+
+	_, err = db.Exec("INSERT INTO webhook_attempts (delivery_id, attempted_at, response_code, response, error, duration_ms) VALUES (?, ?, ?, ?, ?, ?)",
+		id, attemptedAt, responseCode, truncateText(strings.ToValidUTF8(response.Body, ""), 1024), failure, duration)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case failure == "":
+		_, err = db.Exec("UPDATE webhook_deliveries SET status = 'delivered', attempts = ?, next_attempt_at = NULL, response_code = ?, error = '',"+
+			" delivered_at = ? WHERE id = ?", attempts, responseCode, attemptedAt, id)
+		return err == nil, err
+	case attempts >= maxAttempts:
+		_, err = db.Exec("UPDATE webhook_deliveries SET status = 'dead', attempts = ?, next_attempt_at = NULL, response_code = ?, error = ?"+
+			" WHERE id = ?", attempts, responseCode, failure, id)
+	default:
+		_, err = db.Exec("UPDATE webhook_deliveries SET attempts = ?, next_attempt_at = ?, response_code = ?, error = ? WHERE id = ?",
+			attempts, now.Add(WebhookBackoff(attempts)).UTC().Format(mysqlDateTime), responseCode, failure, id)
+	}
+	return false, err
+}
+
+// truncateText shortens text to at most limit characters
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return text
+}
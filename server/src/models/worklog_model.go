@@ -0,0 +1,442 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+)
+
+const (
+	mysqlDateTime = "2006-01-02 15:04:05"
+	mysqlDate     = "2006-01-02"
+
+	worklogColumns = "id, task_id, user_id, started_at, ended_at, duration_minutes, notes"
+)
+
+// worklogGroups maps the supported group_by dimensions to their SQL expressions
+var worklogGroups = map[string]string{
+	"task":       "w.task_id",
+	"technician": "w.user_id",
+	"day":        "DATE(w.started_at)",
+}
+
+type WorklogModel struct {
+	Db *sql.DB
+}
+
+func WorklogHandler(db *sql.DB) *WorklogModel {
+	return &WorklogModel{
+		Db: db,
+	}
+}
+
+func (wm *WorklogModel) CreateWorklog(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := wm.loadOwnTask(w, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		StartedAt       string `json:"started_at"`
+		EndedAt         string `json:"ended_at"`
+		DurationMinutes int64  `json:"duration_minutes"`
+		Notes           string `json:"notes"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, input.StartedAt)
+	if err != nil {
+		http.Error(w, "started_at must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	var endedAt time.Time
+	switch {
+	case input.EndedAt != "":
+		endedAt, err = time.Parse(time.RFC3339, input.EndedAt)
+		if err != nil {
+			http.Error(w, "ended_at must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		if !endedAt.After(startedAt) {
+			http.Error(w, "ended_at must be after started_at", http.StatusBadRequest)
+			return
+		}
+	case input.DurationMinutes > 0:
+		endedAt = startedAt.Add(time.Duration(input.DurationMinutes) * time.Minute)
+	default:
+		http.Error(w, "Missing required fields: ended_at or duration_minutes", http.StatusBadRequest)
+		return
+	}
+
+	worklog := entities.Worklog{
+		ID:              uuid.New().String(),
+		TaskID:          task.ID,
+		UserID:          userID,
+		StartedAt:       startedAt.UTC().Format(mysqlDateTime),
+		EndedAt:         endedAt.UTC().Format(mysqlDateTime),
+		DurationMinutes: int64(endedAt.Sub(startedAt).Round(time.Minute) / time.Minute),
+		Notes:           input.Notes,
+	}
+
+	insertQuery := "INSERT INTO worklogs (id, task_id, user_id, started_at, ended_at, duration_minutes, notes) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, err = wm.Db.Exec(insertQuery, worklog.ID, worklog.TaskID, worklog.UserID, worklog.StartedAt, worklog.EndedAt,
+		worklog.DurationMinutes, worklog.Notes)
+	if err != nil {
+		http.Error(w, "Worklog creation failed", http.StatusInternalServerError)
+		log.Println("Worklog creation failed:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, worklog)
+}
+
+func (wm *WorklogModel) ListWorklogs(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := loadAccessibleTask(w, wm.Db, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	rows, err := wm.Db.Query("SELECT "+worklogColumns+" FROM worklogs WHERE task_id = ? ORDER BY started_at", task.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving worklogs:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	worklogs := []entities.Worklog{}
+	for rows.Next() {
+		worklog, err := scanWorklog(rows)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+		worklogs = append(worklogs, worklog)
+	}
+
+	writeJSON(w, http.StatusOK, worklogs)
+}
+
+func (wm *WorklogModel) StartTimer(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := wm.loadOwnTask(w, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	blockers, err := openBlockers(wm.Db, task.ID)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to check blocking tasks:", err)
+		return
+	}
+	if len(blockers) > 0 {
+		http.Error(w, "Task is blocked by open tasks: "+strings.Join(blockers, ", "), http.StatusConflict)
+		return
+	}
+
+	running, err := wm.runningWorklog(userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to check running timer:", err)
+		return
+	}
+	if err == nil {
+		http.Error(w, "A timer is already running on task "+running.TaskID, http.StatusConflict)
+		return
+	}
+
+	id := uuid.New().String()
+	_, err = wm.Db.Exec("INSERT INTO worklogs (id, task_id, user_id, started_at) VALUES (?, ?, ?, UTC_TIMESTAMP())",
+		id, task.ID, userID)
+	if err != nil {
+		// The unique index on running_user_id catches a concurrent start
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			http.Error(w, "A timer is already running", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to start timer", http.StatusInternalServerError)
+		log.Println("Failed to start timer:", err)
+		return
+	}
+
+	worklog, err := scanWorklog(wm.Db.QueryRow("SELECT "+worklogColumns+" FROM worklogs WHERE id = ?", id))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to retrieve started timer:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, worklog)
+}
+
+func (wm *WorklogModel) StopTimer(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, managerID, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	task, ok := wm.loadOwnTask(w, taskID, userID, managerID)
+	if !ok {
+		return
+	}
+
+	running, err := wm.runningWorklog(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "No timer is running", http.StatusConflict)
+		} else {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println("Failed to check running timer:", err)
+		}
+		return
+	}
+	if running.TaskID != task.ID {
+		http.Error(w, "The running timer belongs to task "+running.TaskID, http.StatusConflict)
+		return
+	}
+
+	var notes struct {
+		Notes string `json:"notes"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&notes)
+	if err != nil && err != io.EOF {
+		http.Error(w, "Bad Input", http.StatusBadRequest)
+		log.Println("Bad Input:", err)
+		return
+	}
+	if notes.Notes == "" {
+		notes.Notes = running.Notes
+	}
+
+	updateQuery := `UPDATE worklogs
+		SET ended_at = UTC_TIMESTAMP(),
+		    duration_minutes = GREATEST(1, CEIL(TIMESTAMPDIFF(SECOND, started_at, UTC_TIMESTAMP()) / 60)),
+		    notes = ?
+		WHERE id = ? AND ended_at IS NULL`
+	_, err = wm.Db.Exec(updateQuery, notes.Notes, running.ID)
+	if err != nil {
+		http.Error(w, "Failed to stop timer", http.StatusInternalServerError)
+		log.Println("Failed to stop timer:", err)
+		return
+	}
+
+	worklog, err := scanWorklog(wm.Db.QueryRow("SELECT "+worklogColumns+" FROM worklogs WHERE id = ?", running.ID))
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Failed to retrieve stopped timer:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+// GetWorklogSummary aggregates logged hours by any combination of task, technician and day.
+// Technicians see their own hours, Managers see the hours of their technicians.
+func (wm *WorklogModel) GetWorklogSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		log.Println("Method not allowed")
+		return
+	}
+
+	userID, _, err := userFromContext(r)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	query := r.URL.Query()
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = "technician,day"
+	}
+
+	var dimensions []string
+	var selects []string
+	for _, group := range strings.Split(groupBy, ",") {
+		expr, ok := worklogGroups[strings.TrimSpace(group)]
+		if !ok {
+			http.Error(w, "group_by must be a list of task, technician and day", http.StatusBadRequest)
+			return
+		}
+		dimensions = append(dimensions, strings.TrimSpace(group))
+		selects = append(selects, expr)
+	}
+
+	visibleClause, args := visibleTasksFilter("t", userID)
+	where := []string{"w.ended_at IS NOT NULL", visibleClause}
+	for param, clause := range map[string]string{
+		"from":          "w.started_at >= ?",
+		"to":            "w.started_at < DATE_ADD(?, INTERVAL 1 DAY)",
+		"task_id":       "w.task_id = ?",
+		"technician_id": "w.user_id = ?",
+	} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		if (param == "from" || param == "to") && !isDate(value) {
+			http.Error(w, param+" must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		where = append(where, clause)
+		args = append(args, value)
+	}
+
+	summaryQuery := "SELECT " + strings.Join(selects, ", ") + ", SUM(w.duration_minutes), COUNT(*) FROM worklogs w JOIN tasks t ON t.id = w.task_id" +
+		" WHERE " + strings.Join(where, " AND ") +
+		" GROUP BY " + strings.Join(selects, ", ") +
+		" ORDER BY " + strings.Join(selects, ", ")
+	rows, err := wm.Db.Query(summaryQuery, args...)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		log.Println("Error retrieving worklog summary:", err)
+		return
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Println("Error closing rows:", err)
+		}
+	}(rows)
+
+	summaries := []entities.WorklogSummary{}
+	for rows.Next() {
+		var summary entities.WorklogSummary
+		var minutes int64
+		keys := make([]string, len(dimensions))
+		dest := make([]interface{}, 0, len(dimensions)+2)
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		dest = append(dest, &minutes, &summary.Entries)
+
+		err := rows.Scan(dest...)
+		if err != nil {
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			log.Println(err)
+			return
+		}
+
+		for i, dimension := range dimensions {
+			switch dimension {
+			case "task":
+				summary.TaskID = keys[i]
+			case "technician":
+				summary.TechnicianID = keys[i]
+			case "day":
+				summary.Day = keys[i]
+			}
+		}
+		summary.Hours = float64(minutes) / 60
+		summaries = append(summaries, summary)
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// loadOwnTask loads a task the current Technician may log time against
+func (wm *WorklogModel) loadOwnTask(w http.ResponseWriter, taskID string, userID string, managerID string) (entities.Task, bool) {
+	// Check if the user is a "Technician"
+	if managerID == "" {
+		http.Error(w, "Only Technicians can log time", http.StatusForbidden)
+		return entities.Task{}, false
+	}
+
+	return loadAccessibleTask(w, wm.Db, taskID, userID, managerID)
+}
+
+func (wm *WorklogModel) runningWorklog(userID string) (entities.Worklog, error) {
+	return scanWorklog(wm.Db.QueryRow("SELECT "+worklogColumns+" FROM worklogs WHERE user_id = ? AND ended_at IS NULL", userID))
+}
+
+func scanWorklog(row rowScanner) (entities.Worklog, error) {
+	var worklog entities.Worklog
+	var endedAt, notes sql.NullString
+	var duration sql.NullInt64
+	err := row.Scan(&worklog.ID, &worklog.TaskID, &worklog.UserID, &worklog.StartedAt, &endedAt, &duration, &notes)
+	if err != nil {
+		return worklog, err
+	}
+	worklog.EndedAt = endedAt.String
+	worklog.DurationMinutes = duration.Int64
+	worklog.Notes = notes.String
+	worklog.Running = !endedAt.Valid
+	return worklog, nil
+}
+
+func isDate(value string) bool {
+	_, err := time.Parse(mysqlDate, value)
+	return err == nil
+}
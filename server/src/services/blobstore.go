@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+)
+
+// ErrBlobNotFound is returned when a key does not exist in the blob store
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores binary objects such as task attachments under opaque keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStoreFromEnv builds the blob store selected by the BLOB_STORE environment variable
+func NewBlobStoreFromEnv() (BlobStore, error) {
+	switch driver := config.Getenv("BLOB_STORE", "local"); driver {
+	case "local":
+		return NewLocalBlobStore(config.Getenv("BLOB_DIR", "./uploads"))
+	case "s3":
+		return NewS3BlobStore(
+			config.Getenv("S3_ENDPOINT", "http://localhost:9000"),
+			config.Getenv("S3_BUCKET", "task-attachments"),
+			config.Getenv("S3_REGION", "us-east-1"),
+			config.Getenv("S3_ACCESS_KEY", ""),
+			config.Getenv("S3_SECRET_KEY", ""),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", driver)
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+)
+
+// relayBuffer is how many events may wait to be relayed before new ones are dropped
+const relayBuffer = 1024
+
+// Event is a change announced on an EventBus. Subject identifies what changed, such as a task ID, and
+// Data is the JSON payload sent to clients. Origin identifies the bus it was first published on.
+type Event struct {
+	ID      int64
+	Type    string
+	Subject string
+	Data    []byte
+	Origin  string
+}
+
+// EventRelay carries events between the buses of several server instances
+type EventRelay interface {
+	Send(ctx context.Context, event Event) error
+	Receive(ctx context.Context) (Event, error)
+	Close() error
+}
+
+// EventBus fans events out to subscribers within the process. It keeps the most recent events so
+// that a subscriber which reconnects can catch up on what it missed.
+type EventBus struct {
+	mu          sync.Mutex
+	origin      string
+	outbox      chan Event
+	next        int64
+	size        int
+	recent      []Event
+	subscribers map[chan Event]struct{}
+}
+
+// Subscription receives the events published after it was opened. Missed holds the buffered events
+// published after the ID it resumed from. Complete is false, and Missed empty, when some of those
+// are no longer buffered. LastID is the ID of the latest event published before it was opened.
+type Subscription struct {
+	Events   <-chan Event
+	Missed   []Event
+	Complete bool
+	LastID   int64
+	bus      *EventBus
+	channel  chan Event
+}
+
+// NewEventBus keeps up to size recent events. IDs start from the current time in microseconds, so
+// they keep increasing across restarts and an ID from before a restart is never mistaken for a new one.
+func NewEventBus(size int) *EventBus {
+	return &EventBus{
+		origin:      uuid.New().String(),
+		next:        time.Now().UnixMicro(),
+		size:        size,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns an ID to the event and hands it to every subscriber, and to the other instances
+// when a relay is attached. A subscriber whose buffer is full is dropped and its channel closed, so
+// a slow client never holds up the others. Publishing on a nil bus does nothing.
+func (b *EventBus) Publish(eventType string, subject string, data []byte) {
+	if b == nil {
+		return
+	}
+
+	event := b.deliver(Event{Type: eventType, Subject: subject, Data: data, Origin: b.origin})
+	if b.outbox != nil {
+		select {
+		case b.outbox <- event:
+		default:
+			log.Println("Event relay is falling behind, dropping event", event.ID)
+		}
+	}
+}
+
+// Relay connects the bus to the buses of other instances: events published here are sent through
+// the relay and events received from it are delivered here. It must be called before publishing.
+func (b *EventBus) Relay(relay EventRelay) {
+	b.outbox = make(chan Event, relayBuffer)
+
+	go func() {
+		for event := range b.outbox {
+			err := relay.Send(context.Background(), event)
+			if err != nil {
+				log.Println("Failed to relay event:", err)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			event, err := relay.Receive(context.Background())
+			if err != nil {
+				log.Println("Stopped receiving relayed events:", err)
+				return
+			}
+			if event.Origin != b.origin {
+				b.deliver(event)
+			}
+		}
+	}()
+}
+
+// deliver numbers an event, keeps it for resuming subscribers and hands it to the current ones
+func (b *EventBus) deliver(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event.ID = b.next
+	b.next++
+	if b.size > 0 {
+		if len(b.recent) == b.size {
+			b.recent = append(b.recent[:0], b.recent[1:]...)
+		}
+		b.recent = append(b.recent, event)
+	}
+
+	for channel := range b.subscribers {
+		select {
+		case channel <- event:
+		default:
+			delete(b.subscribers, channel)
+			close(channel)
+		}
+	}
+	return event
+}
+
+// Origin identifies this bus among the instances sharing a relay
+func (b *EventBus) Origin() string {
+	return b.origin
+}
+
+// NewEventRelayFromEnv builds the relay selected by the EVENT_RELAY environment variable. With "none"
+// no relay is returned and events stay within the instance.
+func NewEventRelayFromEnv() (EventRelay, error) {
+	switch driver := config.Getenv("EVENT_RELAY", "none"); driver {
+	case "none":
+		return nil, nil
+	case "kafka":
+		brokers := strings.Split(config.Getenv("KAFKA_BROKERS", "localhost:9092"), ",")
+		return NewKafkaEventRelay(brokers, config.Getenv("EVENT_RELAY_TOPIC", "task-live-events")), nil
+	default:
+		return nil, fmt.Errorf("unknown event relay %q", driver)
+	}
+}
+
+// Subscribe opens a subscription that buffers up to buffer events. When lastID is not 0, the
+// buffered events published after it are returned as missed.
+func (b *EventBus) Subscribe(lastID int64, buffer int) *Subscription {
+	channel := make(chan Event, buffer)
+	subscription := &Subscription{Events: channel, Complete: true, bus: b, channel: channel}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[channel] = struct{}{}
+	subscription.LastID = b.next - 1
+	if lastID == 0 {
+		return subscription
+	}
+
+	oldest := b.next
+	if len(b.recent) > 0 {
+		oldest = b.recent[0].ID
+	}
+	// An ID past the latest event was not issued by this bus, so what was missed is unknown
+	subscription.Complete = lastID >= oldest-1 && lastID <= subscription.LastID
+	if !subscription.Complete {
+		return subscription
+	}
+	for _, event := range b.recent {
+		if event.ID > lastID {
+			subscription.Missed = append(subscription.Missed, event)
+		}
+	}
+	return subscription
+}
+
+// Close stops the subscription. It is safe to call after the bus dropped the subscriber.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subscribers[s.channel]; ok {
+		delete(s.bus.subscribers, s.channel)
+		close(s.channel)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaRelayRetry is how long the relay waits before looking up the partitions of its topic again
+const kafkaRelayRetry = 5 * time.Second
+
+// KafkaEventRelay relays events through a Kafka topic. Every instance reads each partition of the
+// topic from the newest message without joining a consumer group, so restarts leave no groups or
+// committed offsets behind.
+type KafkaEventRelay struct {
+	brokers  []string
+	topic    string
+	writer   *kafka.Writer
+	start    sync.Once
+	messages chan kafka.Message
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	readers  []*kafka.Reader
+}
+
+// kafkaRelayMessage is how an event is written to the topic
+type kafkaRelayMessage struct {
+	Origin  string `json:"origin"`
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+	Data    []byte `json:"data"`
+}
+
+func NewKafkaEventRelay(brokers []string, topic string) *KafkaEventRelay {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &KafkaEventRelay{
+		brokers: brokers,
+		topic:   topic,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		messages: make(chan kafka.Message),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Send writes the event to the topic, keyed by subject so the events of a task stay in order
+func (kr *KafkaEventRelay) Send(ctx context.Context, event Event) error {
+	value, err := json.Marshal(kafkaRelayMessage{Origin: event.Origin, Type: event.Type, Subject: event.Subject, Data: event.Data})
+	if err != nil {
+		return err
+	}
+	return kr.writer.WriteMessages(ctx, kafka.Message{Key: []byte(event.Subject), Value: value})
+}
+
+// Receive waits for the next event on the topic. The partitions are read from the first call on.
+func (kr *KafkaEventRelay) Receive(ctx context.Context) (Event, error) {
+	kr.start.Do(func() { go kr.readPartitions() })
+	for {
+		var msg kafka.Message
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-kr.ctx.Done():
+			return Event{}, kr.ctx.Err()
+		case msg = <-kr.messages:
+		}
+		var message kafkaRelayMessage
+		if json.Unmarshal(msg.Value, &message) != nil {
+			// Skip messages this relay did not write
+			continue
+		}
+		return Event{Type: message.Type, Subject: message.Subject, Data: message.Data, Origin: message.Origin}, nil
+	}
+}
+
+// readPartitions starts a reader at the end of every partition of the topic, waiting for the topic
+// while the brokers cannot be reached. Partitions added later are not read until the next start.
+func (kr *KafkaEventRelay) readPartitions() {
+	var partitions []kafka.Partition
+	for attempt := 0; ; attempt++ {
+		var err error
+		broker := kr.brokers[attempt%len(kr.brokers)]
+		partitions, err = kafka.DefaultDialer.LookupPartitions(kr.ctx, "tcp", broker, kr.topic)
+		if err == nil {
+			break
+		}
+		log.Println("Failed to look up the event relay partitions:", err)
+		select {
+		case <-kr.ctx.Done():
+			return
+		case <-time.After(kafkaRelayRetry):
+		}
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if kr.ctx.Err() != nil {
+		return
+	}
+	for _, partition := range partitions {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   kr.brokers,
+			Topic:     kr.topic,
+			Partition: partition.ID,
+		})
+		if err := reader.SetOffset(kafka.LastOffset); err != nil {
+			log.Println("Failed to start reading the event relay:", err)
+		}
+		kr.readers = append(kr.readers, reader)
+
+		go func() {
+			for {
+				msg, err := reader.ReadMessage(kr.ctx)
+				if err != nil {
+					if kr.ctx.Err() == nil {
+						log.Println("Stopped reading an event relay partition:", err)
+					}
+					return
+				}
+				select {
+				case kr.messages <- msg:
+				case <-kr.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+func (kr *KafkaEventRelay) Close() error {
+	kr.cancel()
+	err := kr.writer.Close()
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	for _, reader := range kr.readers {
+		if readerErr := reader.Close(); err == nil {
+			err = readerErr
+		}
+	}
+	return err
+}
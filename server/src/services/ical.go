@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// icalLineLimit is the longest content line, in octets, before it has to be folded
+const icalLineLimit = 75
+
+// ICalProperty is a single content line. Name may carry parameters, such as DTSTART;VALUE=DATE,
+// and Value is written as is, so text values have to be escaped with ICalText first.
+type ICalProperty struct {
+	Name  string
+	Value string
+}
+
+// ICalComponent is a calendar component such as VEVENT or VTODO
+type ICalComponent struct {
+	Name       string
+	Properties []ICalProperty
+}
+
+// ICalText escapes a TEXT value
+func ICalText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+// WriteICalendar writes an iCalendar object holding the components. Lines end in CRLF and are
+// folded at 75 octets without splitting UTF-8 characters.
+func WriteICalendar(w io.Writer, name string, components []ICalComponent) error {
+	out := bufio.NewWriter(w)
+	writeLine := func(line string) {
+		limit := icalLineLimit
+		for len(line) > limit {
+			cut := limit
+			for !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			out.WriteString(line[:cut] + "\r\n ")
+			line = line[cut:]
+			// The space that starts a continuation line counts towards its length
+			limit = icalLineLimit - 1
+		}
+		out.WriteString(line + "\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Tasks Tracker//Tasks//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("X-WR-CALNAME:" + ICalText(name))
+	for _, component := range components {
+		writeLine("BEGIN:" + component.Name)
+		for _, property := range component.Properties {
+			writeLine(property.Name + ":" + property.Value)
+		}
+		writeLine("END:" + component.Name)
+	}
+	writeLine("END:VCALENDAR")
+	return out.Flush()
+}
@@ -11,10 +11,10 @@ type KafkaProducer struct {
 	writer *kafka.Writer
 }
 
-func NewKafkaProducer(brokers []string) (*KafkaProducer, error) {
+func NewKafkaProducer(brokers []string, topic string) (*KafkaProducer, error) {
 	writer := &kafka.Writer{
 		Addr:     kafka.TCP(brokers...),
-		Topic:    "maintenance-task-events",
+		Topic:    topic,
 		Balancer: &kafka.LeastBytes{},
 	}
 
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers added to a message written to the dead-letter topic. The original topic, partition and
+// offset tell a replay where the message came from.
+const (
+	DeadLetterError     = "x-dlq-error"
+	DeadLetterAttempts  = "x-dlq-attempts"
+	DeadLetterTopic     = "x-dlq-topic"
+	DeadLetterPartition = "x-dlq-partition"
+	DeadLetterOffset    = "x-dlq-offset"
+	DeadLetterFailedAt  = "x-dlq-failed-at"
+	DeadLetterReplays   = "x-dlq-replays"
+)
+
+// maxDeadLetterError keeps the failure reason within a reasonable header size
+const maxDeadLetterError = 1000
+
+// KafkaMessageReader fetches messages and commits them once handled, as *kafka.Reader does
+type KafkaMessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaMessageWriter writes messages, as *kafka.Writer does
+type KafkaMessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaMessageHandler processes one message. An error is retried, unless it was wrapped with Permanent.
+type KafkaMessageHandler func(ctx context.Context, msg kafka.Message) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix, such as a malformed message, so the message
+// goes to the dead-letter topic straight away
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// KafkaConsumer hands each message of a topic to a handler and commits it only once it has been
+// handled or, after MaxAttempts failed tries, written to the dead-letter topic with the reason. A
+// message is never committed before one of those happened, so a crash means it is read again.
+type KafkaConsumer struct {
+	Reader      KafkaMessageReader
+	DeadLetters KafkaMessageWriter
+	Handler     KafkaMessageHandler
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubling with each one
+	Backoff time.Duration
+	closers []func() error
+}
+
+func NewKafkaConsumer(brokers []string, topic string, group string, deadLetterTopic string, handler KafkaMessageHandler,
+	maxAttempts int, backoff time.Duration) *KafkaConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		GroupID: group,
+		Topic:   topic,
+	})
+	writer := &kafka.Writer{
+		Addr:     kafka.TCP(brokers...),
+		Topic:    deadLetterTopic,
+		Balancer: &kafka.Hash{},
+	}
+	return &KafkaConsumer{
+		Reader:      reader,
+		DeadLetters: writer,
+		Handler:     handler,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+		closers:     []func() error{reader.Close, writer.Close},
+	}
+}
+
+// Run consumes messages until ctx is done
+func (c *KafkaConsumer) Run(ctx context.Context) error {
+	for {
+		msg, err := c.Reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Println("Error reading Kafka message:", err)
+			if !sleepContext(ctx, c.Backoff) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		err = c.Process(ctx, msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Process handles a message, dead-letters it when that fails and commits it. It only returns an
+// error when ctx is done before the message could be committed.
+func (c *KafkaConsumer) Process(ctx context.Context, msg kafka.Message) error {
+	attempts, err := c.handle(ctx, msg)
+	if err != nil {
+		log.Printf("Sending Kafka message %s/%d/%d to the dead-letter topic after %d attempts: %v\n",
+			msg.Topic, msg.Partition, msg.Offset, attempts, err)
+		deadLetter := DeadLetterMessage(msg, err, attempts, time.Now())
+		err = c.retry(ctx, "write dead letter", func() error { return c.DeadLetters.WriteMessages(ctx, deadLetter) })
+		if err != nil {
+			return err
+		}
+	}
+	return c.retry(ctx, "commit Kafka message", func() error { return c.Reader.CommitMessages(ctx, msg) })
+}
+
+// handle runs the handler until it succeeds, fails permanently or has been tried MaxAttempts times.
+// A panic counts as a permanent failure.
+func (c *KafkaConsumer) handle(ctx context.Context, msg kafka.Message) (int, error) {
+	delay := c.Backoff
+	for attempt := 1; ; attempt++ {
+		err := c.call(ctx, msg)
+		var permanent permanentError
+		if err == nil || errors.As(err, &permanent) || attempt >= c.MaxAttempts {
+			return attempt, err
+		}
+		log.Printf("Kafka message %s/%d/%d failed (attempt %d of %d): %v\n", msg.Topic, msg.Partition, msg.Offset, attempt,
+			c.MaxAttempts, err)
+		if !sleepContext(ctx, delay) {
+			return attempt, err
+		}
+		delay *= 2
+	}
+}
+
+func (c *KafkaConsumer) call(ctx context.Context, msg kafka.Message) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = Permanent(fmt.Errorf("panic: %v", recovered))
+		}
+	}()
+	return c.Handler(ctx, msg)
+}
+
+// retry repeats an operation the consumer cannot go on without until it succeeds or ctx is done
+func (c *KafkaConsumer) retry(ctx context.Context, what string, operation func() error) error {
+	for {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to %s: %v\n", what, err)
+		if !sleepContext(ctx, c.Backoff) {
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *KafkaConsumer) Close() error {
+	var err error
+	for _, closer := range c.closers {
+		if closeErr := closer(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// DeadLetterMessage copies a message for the dead-letter topic, recording where it came from and
+// why handling it failed
+func DeadLetterMessage(msg kafka.Message, reason error, attempts int, now time.Time) kafka.Message {
+	message := reason.Error()
+	if len(message) > maxDeadLetterError {
+		message = message[:maxDeadLetterError]
+	}
+
+	headers := withoutDeadLetterHeaders(msg.Headers)
+	headers = append(headers,
+		kafka.Header{Key: DeadLetterError, Value: []byte(message)},
+		kafka.Header{Key: DeadLetterAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: DeadLetterTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: DeadLetterPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: DeadLetterOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: DeadLetterFailedAt, Value: []byte(now.UTC().Format(time.RFC3339))},
+		kafka.Header{Key: DeadLetterReplays, Value: []byte(strconv.Itoa(deadLetterReplays(msg)))},
+	)
+	return kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers}
+}
+
+// ReplayMessage turns a dead letter back into a message for the topic it came from. The replay
+// count is kept, so a message that keeps failing can be told apart.
+func ReplayMessage(deadLetter kafka.Message) (kafka.Message, error) {
+	topic := DeadLetterHeader(deadLetter, DeadLetterTopic)
+	if topic == "" {
+		return kafka.Message{}, errors.New("the message has no " + DeadLetterTopic + " header")
+	}
+	headers := append(withoutDeadLetterHeaders(deadLetter.Headers),
+		kafka.Header{Key: DeadLetterReplays, Value: []byte(strconv.Itoa(deadLetterReplays(deadLetter) + 1))})
+	return kafka.Message{Topic: topic, Key: deadLetter.Key, Value: deadLetter.Value, Headers: headers}, nil
+}
+
+// ReplayOptions control ReplayDeadLetters. Limit stops after that many messages when not 0. Idle
+// is how long to wait for another message before taking the topic as drained. DryRun only reports
+// the messages, leaving them in the dead-letter topic.
+type ReplayOptions struct {
+	Limit  int
+	Idle   time.Duration
+	DryRun bool
+	Report func(deadLetter kafka.Message)
+}
+
+// ReplayDeadLetters writes dead letters back to their original topic, committing each one once it
+// has been written, and returns how many were replayed
+func ReplayDeadLetters(ctx context.Context, reader KafkaMessageReader, writer KafkaMessageWriter, options ReplayOptions) (int, error) {
+	replayed := 0
+	for options.Limit == 0 || replayed < options.Limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, options.Idle)
+		deadLetter, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				return replayed, nil
+			}
+			return replayed, err
+		}
+
+		if options.Report != nil {
+			options.Report(deadLetter)
+		}
+		if !options.DryRun {
+			msg, err := ReplayMessage(deadLetter)
+			if err != nil {
+				return replayed, fmt.Errorf("dead letter at offset %d: %w", deadLetter.Offset, err)
+			}
+			err = writer.WriteMessages(ctx, msg)
+			if err != nil {
+				return replayed, err
+			}
+			err = reader.CommitMessages(ctx, deadLetter)
+			if err != nil {
+				return replayed, err
+			}
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// DeadLetterHeader returns the value of a header, or "" when the message does not have it
+func DeadLetterHeader(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func deadLetterReplays(msg kafka.Message) int {
+	replays, _ := strconv.Atoi(DeadLetterHeader(msg, DeadLetterReplays))
+	return replays
+}
+
+func withoutDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	kept := make([]kafka.Header, 0, len(headers)+7)
+	for _, header := range headers {
+		if !strings.HasPrefix(header.Key, "x-dlq-") {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+// sleepContext waits for d, returning false when ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
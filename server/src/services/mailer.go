@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/config"
+)
+
+// MailMessage is an email with a plain text body and an optional HTML alternative
+type MailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers email
+type Mailer interface {
+	Send(ctx context.Context, message MailMessage) error
+}
+
+// NewMailerFromEnv builds the mailer selected by the MAILER environment variable. With "none" no
+// mailer is returned and nothing is sent.
+func NewMailerFromEnv() (Mailer, error) {
+	switch driver := config.Getenv("MAILER", "none"); driver {
+	case "none":
+		return nil, nil
+	case "log":
+		return LogMailer{}, nil
+	case "smtp":
+		port, err := strconv.Atoi(config.Getenv("SMTP_PORT", "587"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+		}
+		return NewSMTPMailer(
+			config.Getenv("SMTP_HOST", "localhost"),
+			port,
+			config.Getenv("SMTP_USERNAME", ""),
+			config.Getenv("SMTP_PASSWORD", ""),
+			config.Getenv("MAIL_FROM", "tasks@localhost"),
+			time.Duration(config.GetenvInt("SMTP_TIMEOUT_SECONDS", 30))*time.Second,
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", driver)
+	}
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer writes emails to the log instead of sending them, for development
+type LogMailer struct{}
+
+func (LogMailer) Send(_ context.Context, message MailMessage) error {
+	log.Printf("Mail to %s: %s\n%s\n", message.To, message.Subject, message.Text)
+	return nil
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	// Decoders for the logo formats accepted next to JPEG
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// A4 portrait in points
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// PDFFont is one of the standard PDF fonts, which every reader provides, so no font data is embedded
+type PDFFont int
+
+const (
+	PDFHelvetica PDFFont = iota
+	PDFHelveticaBold
+)
+
+// pdfFontStyles are the gofpdf styles of the fonts
+var pdfFontStyles = []string{"", "B"}
+
+// pdfFontWidths are the glyph widths of the printable ASCII characters in thousandths of the font size
+var pdfFontWidths = [][95]int{
+	{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// pdfWinAnsi maps the characters outside Latin-1 that WinAnsiEncoding has
+var pdfWinAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// PDFColor is an RGB color with components between 0 and 1
+type PDFColor struct {
+	R, G, B float64
+}
+
+var (
+	PDFBlack = PDFColor{}
+	PDFGray  = PDFColor{R: 0.4, G: 0.4, B: 0.4}
+)
+
+// ParsePDFColor reads a color written as #rrggbb
+func ParsePDFColor(value string) (PDFColor, error) {
+	if len(value) != 7 || value[0] != '#' {
+		return PDFColor{}, errors.New("color must look like #rrggbb")
+	}
+	rgb, err := strconv.ParseUint(value[1:], 16, 32)
+	if err != nil {
+		return PDFColor{}, errors.New("color must look like #rrggbb")
+	}
+	return PDFColor{R: float64(rgb>>16) / 255, G: float64(rgb>>8&0xff) / 255, B: float64(rgb&0xff) / 255}, nil
+}
+
+// PDFImage is an image ready to be placed in a document. JPEG data is embedded as is and other
+// formats are stored losslessly as PNG.
+type PDFImage struct {
+	Width     int
+	Height    int
+	imageType string
+	data      []byte
+}
+
+// NewPDFImage prepares a JPEG, PNG or GIF image. Transparent areas turn white.
+func NewPDFImage(data []byte) (*PDFImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format == "jpeg" {
+		// Progressive and otherwise unusual JPEGs are checked by decoding them once
+		if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		return &PDFImage{Width: config.Width, Height: config.Height, imageType: "JPG", data: data}, nil
+	}
+
+	// Other images are flattened onto white and written as a plain PNG, which gofpdf embeds
+	// without the interlacing and palette quirks it does not support
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := decoded.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), decoded, bounds.Min, draw.Over)
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, canvas); err != nil {
+		return nil, err
+	}
+	return &PDFImage{Width: bounds.Dx(), Height: bounds.Dy(), imageType: "PNG", data: encoded.Bytes()}, nil
+}
+
+// PDFDocument builds a PDF page by page on gofpdf, using only the standard fonts. Positions are in
+// points from the top left corner of the page.
+type PDFDocument struct {
+	pdf    *gofpdf.Fpdf
+	images map[*PDFImage]string
+}
+
+func NewPDFDocument(title string) *PDFDocument {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{OrientationStr: "P", UnitStr: "pt", SizeStr: "A4"})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	// Plain ASCII titles stay readable in the file, others are stored as UTF-16
+	pdf.SetTitle(title, strings.IndexFunc(title, func(r rune) bool { return r > 0x7e }) >= 0)
+	pdf.SetProducer("Tasks Tracker", false)
+	return &PDFDocument{pdf: pdf, images: make(map[*PDFImage]string)}
+}
+
+// AddPage starts a new page and makes it the current one
+func (d *PDFDocument) AddPage() {
+	d.pdf.AddPage()
+}
+
+func (d *PDFDocument) PageCount() int {
+	return d.pdf.PageCount()
+}
+
+// SetPage makes an earlier page, counted from 1, the current one again
+func (d *PDFDocument) SetPage(number int) {
+	d.pdf.SetPage(number)
+}
+
+// Text draws a single line of text with its baseline at y
+func (d *PDFDocument) Text(x, y float64, font PDFFont, size float64, textColor PDFColor, text string) {
+	d.pdf.SetFont("Helvetica", pdfFontStyles[font], size)
+	d.pdf.SetTextColor(pdfRGB(textColor))
+	d.pdf.Text(x, y, string(pdfEncode(text)))
+}
+
+// Line draws a straight line
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64, lineColor PDFColor) {
+	d.pdf.SetDrawColor(pdfRGB(lineColor))
+	d.pdf.SetLineWidth(width)
+	d.pdf.Line(x1, y1, x2, y2)
+}
+
+// Rect fills a rectangle whose top left corner is at x, y
+func (d *PDFDocument) Rect(x, y, width, height float64, fillColor PDFColor) {
+	d.pdf.SetFillColor(pdfRGB(fillColor))
+	d.pdf.Rect(x, y, width, height, "F")
+}
+
+// Image draws an image scaled to width by height with its top left corner at x, y. Each image is
+// embedded once however often it is drawn.
+func (d *PDFDocument) Image(img *PDFImage, x, y, width, height float64) {
+	options := gofpdf.ImageOptions{ImageType: img.imageType}
+	name, ok := d.images[img]
+	if !ok {
+		name = fmt.Sprintf("image%d", len(d.images))
+		d.images[img] = name
+		d.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(img.data))
+	}
+	d.pdf.ImageOptions(name, x, y, width, height, false, options, 0, "")
+}
+
+// Write serializes the document
+func (d *PDFDocument) Write(w io.Writer) error {
+	if d.pdf.PageCount() == 0 {
+		d.AddPage()
+	}
+	return d.pdf.Output(w)
+}
+
+// PDFTextWidth measures text set in font at size points
+func PDFTextWidth(font PDFFont, size float64, text string) float64 {
+	width := 0
+	for _, c := range pdfEncode(text) {
+		switch {
+		case c >= 32 && c <= 126:
+			width += pdfFontWidths[font][c-32]
+		case c == 0xa0:
+			width += 278
+		default:
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// PDFWrapText breaks text into lines no wider than width, at spaces where possible. Line breaks in
+// the text are kept.
+func PDFWrapText(font PDFFont, size float64, text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if PDFTextWidth(font, size, candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Words longer than a line are split wherever they overflow
+			line = ""
+			for _, r := range word {
+				if line != "" && PDFTextWidth(font, size, line+string(r)) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfEncode converts text to WinAnsiEncoding (code page 1252), which the standard fonts use, replacing
+// characters they lack
+func pdfEncode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			encoded = append(encoded, ' ')
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		case pdfWinAnsi[r] != 0:
+			encoded = append(encoded, pdfWinAnsi[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// pdfRGB converts a color to the 0 to 255 components gofpdf takes
+func pdfRGB(c PDFColor) (int, int, int) {
+	return int(c.R*255 + 0.5), int(c.G*255 + 0.5), int(c.B*255 + 0.5)
+}
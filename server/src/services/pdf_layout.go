@@ -0,0 +1,248 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	pdfMargin     = 50.0
+	pdfFooterTop  = PDFPageHeight - 40
+	pdfBodySize   = 10.0
+	pdfLineHeight = 13.0
+	pdfCellPad    = 4.0
+	pdfLogoWidth  = 140.0
+	pdfLogoHeight = 50.0
+)
+
+var pdfRuleColor = PDFColor{R: 0.8, G: 0.8, B: 0.8}
+var pdfHeaderFill = PDFColor{R: 0.93, G: 0.93, B: 0.93}
+
+// PDFBranding is the company identity printed on every page of a report
+type PDFBranding struct {
+	CompanyName string
+	Details     []string
+	Footer      string
+	Accent      PDFColor
+	Logo        *PDFImage
+}
+
+// PDFColumn is a table column. Width is a share of the page width; all shares of a table add up to 1.
+type PDFColumn struct {
+	Title      string
+	Width      float64
+	AlignRight bool
+}
+
+// PDFRow is a table row. Bold rows stand out, for totals.
+type PDFRow struct {
+	Cells []string
+	Bold  bool
+}
+
+// PDFReport lays out a branded document from top to bottom, starting new pages as content runs over
+type PDFReport struct {
+	doc      *PDFDocument
+	title    string
+	branding PDFBranding
+	y        float64
+}
+
+// NewPDFReport starts a report on its first page
+func NewPDFReport(title string, branding PDFBranding) *PDFReport {
+	report := &PDFReport{doc: NewPDFDocument(title), title: title, branding: branding}
+	report.newPage()
+	return report
+}
+
+func (pr *PDFReport) contentWidth() float64 {
+	return PDFPageWidth - 2*pdfMargin
+}
+
+// newPage starts a page with the company header and the report title
+func (pr *PDFReport) newPage() {
+	pr.doc.AddPage()
+	pr.doc.Rect(0, 0, PDFPageWidth, 8, pr.branding.Accent)
+
+	top := 30.0
+	if logo := pr.branding.Logo; logo != nil && logo.Width > 0 && logo.Height > 0 {
+		scale := pdfLogoWidth / float64(logo.Width)
+		if heightScale := pdfLogoHeight / float64(logo.Height); heightScale < scale {
+			scale = heightScale
+		}
+		width, height := float64(logo.Width)*scale, float64(logo.Height)*scale
+		pr.doc.Image(logo, PDFPageWidth-pdfMargin-width, top, width, height)
+	}
+
+	pr.y = top + 16
+	pr.doc.Text(pdfMargin, pr.y, PDFHelveticaBold, 16, pr.branding.Accent, pr.branding.CompanyName)
+	for _, detail := range pr.branding.Details {
+		pr.y += 12
+		pr.doc.Text(pdfMargin, pr.y, PDFHelvetica, 9, PDFGray, detail)
+	}
+	if pr.y < top+pdfLogoHeight {
+		pr.y = top + pdfLogoHeight
+	}
+
+	pr.y += 28
+	pr.doc.Text(pdfMargin, pr.y, PDFHelveticaBold, 14, PDFBlack, pr.title)
+	pr.y += 8
+	pr.doc.Line(pdfMargin, pr.y, PDFPageWidth-pdfMargin, pr.y, 0.75, pr.branding.Accent)
+	pr.y += 18
+}
+
+// ensure starts a new page unless height points still fit above the footer
+func (pr *PDFReport) ensure(height float64) bool {
+	if pr.y+height <= pdfFooterTop-12 {
+		return false
+	}
+	pr.newPage()
+	return true
+}
+
+// Space leaves a vertical gap
+func (pr *PDFReport) Space(height float64) {
+	pr.y += height
+}
+
+// Heading starts a section, keeping it on the same page as at least a few lines of its content
+func (pr *PDFReport) Heading(text string) {
+	pr.ensure(16 + 4*pdfLineHeight)
+	pr.y += 6
+	pr.doc.Text(pdfMargin, pr.y, PDFHelveticaBold, 12, pr.branding.Accent, text)
+	pr.y += 4
+	pr.doc.Line(pdfMargin, pr.y, PDFPageWidth-pdfMargin, pr.y, 0.5, pdfRuleColor)
+	pr.y += pdfLineHeight + 2
+}
+
+// Paragraph writes wrapped text
+func (pr *PDFReport) Paragraph(text string) {
+	for _, line := range PDFWrapText(PDFHelvetica, pdfBodySize, text, pr.contentWidth()) {
+		pr.ensure(pdfLineHeight)
+		pr.doc.Text(pdfMargin, pr.y, PDFHelvetica, pdfBodySize, PDFBlack, line)
+		pr.y += pdfLineHeight
+	}
+	pr.y += 4
+}
+
+// Fields writes label and value pairs in two columns, leaving out empty values
+func (pr *PDFReport) Fields(fields [][2]string) {
+	labelWidth := 130.0
+	for _, field := range fields {
+		if strings.TrimSpace(field[1]) == "" {
+			continue
+		}
+		lines := PDFWrapText(PDFHelvetica, pdfBodySize, field[1], pr.contentWidth()-labelWidth)
+		pr.ensure(float64(len(lines)) * pdfLineHeight)
+		pr.doc.Text(pdfMargin, pr.y, PDFHelveticaBold, pdfBodySize, PDFBlack, field[0])
+		for _, line := range lines {
+			pr.ensure(pdfLineHeight)
+			pr.doc.Text(pdfMargin+labelWidth, pr.y, PDFHelvetica, pdfBodySize, PDFBlack, line)
+			pr.y += pdfLineHeight
+		}
+	}
+	pr.y += 6
+}
+
+// Table writes rows under a header, which is repeated on every page the table continues on
+func (pr *PDFReport) Table(columns []PDFColumn, rows []PDFRow) {
+	widths := make([]float64, len(columns))
+	for i, column := range columns {
+		widths[i] = column.Width * pr.contentWidth()
+	}
+
+	header := func() {
+		height := pdfLineHeight + 2*pdfCellPad
+		pr.doc.Rect(pdfMargin, pr.y-pdfLineHeight+2-pdfCellPad, pr.contentWidth(), height, pdfHeaderFill)
+		x := pdfMargin
+		for i, column := range columns {
+			pr.cell(x, widths[i], PDFHelveticaBold, column.Title, column.AlignRight)
+			x += widths[i]
+		}
+		pr.y += height
+	}
+
+	pr.ensure(3 * (pdfLineHeight + 2*pdfCellPad))
+	header()
+	for _, row := range rows {
+		font := PDFHelvetica
+		if row.Bold {
+			font = PDFHelveticaBold
+		}
+
+		cells := make([][]string, len(columns))
+		lines := 1
+		for i := range columns {
+			value := ""
+			if i < len(row.Cells) {
+				value = row.Cells[i]
+			}
+			cells[i] = PDFWrapText(font, pdfBodySize, value, widths[i]-2*pdfCellPad)
+			if len(cells[i]) > lines {
+				lines = len(cells[i])
+			}
+		}
+
+		height := float64(lines)*pdfLineHeight + pdfCellPad
+		if pr.ensure(height) {
+			header()
+		}
+		for line := 0; line < lines; line++ {
+			x := pdfMargin
+			for i, column := range columns {
+				if line < len(cells[i]) {
+					pr.cell(x, widths[i], font, cells[i][line], column.AlignRight)
+				}
+				x += widths[i]
+			}
+			pr.y += pdfLineHeight
+		}
+		pr.doc.Line(pdfMargin, pr.y-pdfLineHeight+pdfCellPad, PDFPageWidth-pdfMargin, pr.y-pdfLineHeight+pdfCellPad, 0.25, pdfRuleColor)
+		pr.y += pdfCellPad
+	}
+	pr.y += 8
+}
+
+func (pr *PDFReport) cell(x float64, width float64, font PDFFont, text string, alignRight bool) {
+	if alignRight {
+		x += width - pdfCellPad - PDFTextWidth(font, pdfBodySize, text)
+	} else {
+		x += pdfCellPad
+	}
+	pr.doc.Text(x, pr.y, font, pdfBodySize, PDFBlack, text)
+}
+
+// Signatures draws a signature line with name and date for every label, side by side
+func (pr *PDFReport) Signatures(labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+	pr.ensure(110)
+	gap := 30.0
+	width := (pr.contentWidth() - gap*float64(len(labels)-1)) / float64(len(labels))
+	pr.y += 40
+	for i, label := range labels {
+		x := pdfMargin + float64(i)*(width+gap)
+		pr.doc.Line(x, pr.y, x+width, pr.y, 0.75, PDFBlack)
+		pr.doc.Text(x, pr.y+12, PDFHelveticaBold, 9, PDFBlack, label)
+		pr.doc.Line(x, pr.y+40, x+width, pr.y+40, 0.5, PDFGray)
+		pr.doc.Text(x, pr.y+52, PDFHelvetica, 9, PDFGray, "Name and date")
+	}
+	pr.y += 60
+}
+
+// Write adds the footer with page numbers to every page and serializes the report
+func (pr *PDFReport) Write(w io.Writer) error {
+	pages := pr.doc.PageCount()
+	for page := 1; page <= pages; page++ {
+		pr.doc.SetPage(page)
+		pr.doc.Line(pdfMargin, pdfFooterTop, PDFPageWidth-pdfMargin, pdfFooterTop, 0.5, pdfRuleColor)
+		if pr.branding.Footer != "" {
+			pr.doc.Text(pdfMargin, pdfFooterTop+14, PDFHelvetica, 8, PDFGray, pr.branding.Footer)
+		}
+		number := fmt.Sprintf("Page %d of %d", page, pages)
+		pr.doc.Text(PDFPageWidth-pdfMargin-PDFTextWidth(PDFHelvetica, 8, number), pdfFooterTop+14, PDFHelvetica, 8, PDFGray, number)
+	}
+	return pr.doc.Write(w)
+}
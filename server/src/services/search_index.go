@@ -0,0 +1,128 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// BM25 parameters controlling term frequency saturation and length normalization
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SearchHit is a document matching a query together with its relevance
+type SearchHit struct {
+	ID    string
+	Score float64
+}
+
+// SearchIndex is an in-memory inverted index over short texts such as task summaries. Documents are
+// ranked with BM25, so rarer terms and shorter documents weigh more.
+type SearchIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]int
+	lengths  map[string]int
+	total    int
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		postings: make(map[string]map[string]int),
+		lengths:  make(map[string]int),
+	}
+}
+
+// Rebuild replaces the indexed documents, keyed by ID
+func (si *SearchIndex) Rebuild(documents map[string]string) {
+	postings := make(map[string]map[string]int)
+	lengths := make(map[string]int, len(documents))
+	total := 0
+	for id, text := range documents {
+		terms := Tokenize(text)
+		for _, term := range terms {
+			if postings[term] == nil {
+				postings[term] = make(map[string]int)
+			}
+			postings[term][id]++
+		}
+		lengths[id] = len(terms)
+		total += len(terms)
+	}
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.postings = postings
+	si.lengths = lengths
+	si.total = total
+}
+
+// Len returns the number of indexed documents
+func (si *SearchIndex) Len() int {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	return len(si.lengths)
+}
+
+// Search returns up to limit documents containing any of the query terms, best match first
+func (si *SearchIndex) Search(query string, limit int) []SearchHit {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	documents := float64(len(si.lengths))
+	if documents == 0 {
+		return nil
+	}
+	averageLength := float64(si.total) / documents
+
+	scores := make(map[string]float64)
+	for _, term := range uniqueTerms(Tokenize(query)) {
+		matches := si.postings[term]
+		if len(matches) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (documents-float64(len(matches))+0.5)/(float64(len(matches))+0.5))
+		for id, frequency := range matches {
+			tf := float64(frequency)
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(si.lengths[id])/averageLength)
+			scores[id] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, SearchHit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Tokenize splits text into lower-cased words of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool)
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// webhookResponseLimit is how much of a receiver's response is kept for the delivery log
+const webhookResponseLimit = 1024
+
+// WebhookRequest is an event payload to POST to a webhook URL, signed with the webhook's secret
+type WebhookRequest struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Payload    []byte
+}
+
+// ErrWebhookAddressBlocked is returned for a receiver that resolves to a non-public address
+var ErrWebhookAddressBlocked = errors.New("webhook receiver address is not public")
+
+// webhookBlockedNetworks are the ranges beside loopback, private, link-local, unspecified and multicast
+// addresses that never belong to a public receiver
+var webhookBlockedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("240.0.0.0/4"),
+}
+
+func mustParseCIDR(value string) *net.IPNet {
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// WebhookResponse is what the receiver answered. Body holds at most the first kilobyte.
+type WebhookResponse struct {
+	StatusCode int
+	Body       string
+}
+
+// WebhookSender delivers webhook requests over HTTP
+type WebhookSender struct {
+	Client *http.Client
+}
+
+// NewWebhookSender gives receivers timeout to answer. Redirects are not followed, so a signed
+// payload only ever goes to the registered URL. Connections to loopback, private, link-local and
+// other non-public addresses are refused unless they are within allowed, so a webhook cannot reach
+// internal services. The address is checked as the connection is made, after the host name is
+// resolved, so a name that later resolves elsewhere is caught too.
+func NewWebhookSender(timeout time.Duration, allowed []*net.IPNet) *WebhookSender {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			return checkWebhookAddress(address, allowed)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect to the receiver on the sender's behalf, out of reach of the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookSender{
+		Client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// checkWebhookAddress refuses a host:port that is not a public address, unless it is allowed
+func checkWebhookAddress(address string, allowed []*net.IPNet) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, host)
+	}
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+
+	blocked := ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+	for _, network := range webhookBlockedNetworks {
+		blocked = blocked || network.Contains(ip)
+	}
+	if blocked {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, ip)
+	}
+	return nil
+}
+
+// Send POSTs the payload with its signature. An error means no response was received; any
+// response, whatever its status, is returned for the caller to judge.
+func (s *WebhookSender) Send(ctx context.Context, request WebhookRequest, now time.Time) (WebhookResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Payload))
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tasks-tracker-webhooks")
+	req.Header.Set("X-Webhook-Event", request.Event)
+	req.Header.Set("X-Webhook-Delivery", request.DeliveryID)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhook(request.Secret, timestamp, request.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	// Draining the rest lets the connection be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return WebhookResponse{StatusCode: resp.StatusCode, Body: string(body)}, nil
+}
+
+// SignWebhook computes the X-Webhook-Signature header: the HMAC-SHA256 of the timestamp, a dot and
+// the payload, keyed with the webhook's secret. Including the timestamp lets receivers reject
+// replayed requests.
+func SignWebhook(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestGetAnalyticsMetric(t *testing.T) {
+	cases := map[string]struct {
+		metric    string
+		target    string
+		managerID string
+		expected  int
+	}{
+		"Technician":    {"throughput", "/analytics/throughput", "456", http.StatusForbidden},
+		"UnknownMetric": {"velocity", "/analytics/velocity", "", http.StatusNotFound},
+		"InvalidBucket": {"throughput", "/analytics/throughput?bucket=month", "", http.StatusBadRequest},
+		"TooManyDays":   {"throughput", "/analytics/throughput?from=2020-01-01&to=2023-01-01", "", http.StatusBadRequest},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			analyticsModel := models.AnalyticsHandler(nil)
+			req := httptest.NewRequest(http.MethodGet, c.target, nil)
+			ctx := context.WithValue(req.Context(), "userID", "123")
+			ctx = context.WithValue(ctx, "managerID", c.managerID)
+			rr := httptest.NewRecorder()
+
+			// When
+			analyticsModel.GetMetric(rr, req.WithContext(ctx), c.metric)
+
+			// Then
+			assert.Equal(t, c.expected, rr.Code)
+		})
+	}
+}
+
+func TestAnalyticsRange(t *testing.T) {
+	// Wednesday 4 January 2023
+	now := time.Date(2023, 1, 4, 15, 0, 0, 0, time.UTC)
+
+	bucket, from, to, err := models.AnalyticsRange(url.Values{}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"day", "2022-12-06", "2023-01-04"}, []string{bucket, from, to})
+
+	bucket, from, to, err = models.AnalyticsRange(url.Values{"bucket": {"week"}, "from": {"2022-12-14"}}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"week", "2022-12-12", "2023-01-08"}, []string{bucket, from, to})
+
+	_, _, _, err = models.AnalyticsRange(url.Values{"from": {"2023-02-01"}}, now)
+	assert.Error(t, err)
+}
+
+func TestAnalyticsPeriods(t *testing.T) {
+	periods, err := models.AnalyticsPeriods("week", "2022-12-12", "2023-01-08")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2022-12-12", "2022-12-19", "2022-12-26", "2023-01-02"}, periods)
+
+	periods, err = models.AnalyticsPeriods("day", "2023-01-30", "2023-02-01")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2023-01-30", "2023-01-31", "2023-02-01"}, periods)
+}
@@ -0,0 +1,78 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestCreateAsset(t *testing.T) {
+	t.Run("InvalidRequestMethod", func(t *testing.T) {
+		// Given
+		db := setupTestDB(t)
+		am := &models.AssetModel{Db: db}
+		req, err := http.NewRequest("GET", "/assets", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		// When
+		am.CreateAsset(rr, req)
+
+		// Then
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status code %d, but got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+	})
+
+	t.Run("TechniciansCannotCreateAssets", func(t *testing.T) {
+		// Given
+		db := setupTestDB(t)
+		am := &models.AssetModel{Db: db}
+		reqBody := `{"name": "Boiler #3", "type": "boiler"}`
+		req, err := http.NewRequest("POST", "/assets", strings.NewReader(reqBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		req = req.WithContext(ctx)
+
+		// When
+		am.CreateAsset(rr, req)
+
+		// Then
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status code %d, but got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+
+	t.Run("MissingName", func(t *testing.T) {
+		// Given
+		db := setupTestDB(t)
+		am := &models.AssetModel{Db: db}
+		reqBody := `{"type": "boiler"}`
+		req, err := http.NewRequest("POST", "/assets", strings.NewReader(reqBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		req = req.WithContext(ctx)
+
+		// When
+		am.CreateAsset(rr, req)
+
+		// Then
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package models_tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// pngFile is the start of a PNG, enough for content sniffing
+var pngFile = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// uploadBody writes the file part before the sha256 field
+func uploadBody(t *testing.T, checksum string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	file, err := writer.CreateFormFile("file", "photo.png")
+	assert.NoError(t, err)
+	_, _ = file.Write(pngFile)
+	assert.NoError(t, writer.WriteField("sha256", checksum))
+	assert.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestUploadAttachmentChecksumAfterFile(t *testing.T) {
+	db := setupIntegrationDB(t)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	taskID := insertTestTask(t, db, technicianID)
+	store, err := services.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	attachmentModel := models.AttachmentHandler(db, store)
+	sum := sha256.Sum256(pngFile)
+
+	t.Run("Mismatch", func(t *testing.T) {
+		// Given
+		body, contentType := uploadBody(t, "0000")
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/attachments", body)
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+
+		// When
+		attachmentModel.UploadAttachment(rr, withUser(req, technicianID, managerID), taskID)
+
+		// Then
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		// Given
+		body, contentType := uploadBody(t, hex.EncodeToString(sum[:]))
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/attachments", body)
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+
+		// When
+		attachmentModel.UploadAttachment(rr, withUser(req, technicianID, managerID), taskID)
+
+		// Then
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("DownloadNotModified", func(t *testing.T) {
+		// Given
+		body, contentType := uploadBody(t, hex.EncodeToString(sum[:]))
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/attachments", body)
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		attachmentModel.UploadAttachment(rr, withUser(req, technicianID, managerID), taskID)
+		var attachment entities.Attachment
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &attachment))
+
+		req = httptest.NewRequest(http.MethodGet, "/tasks/"+taskID+"/attachments/"+attachment.ID, nil)
+		req.Header.Set("If-None-Match", `W/"`+attachment.Checksum+`"`)
+		rr = httptest.NewRecorder()
+
+		// When
+		attachmentModel.DownloadAttachment(rr, withUser(req, managerID, ""), taskID, attachment.ID)
+
+		// Then
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Equal(t, `"`+attachment.Checksum+`"`, rr.Header().Get("ETag"))
+		assert.Empty(t, rr.Body.String())
+	})
+}
@@ -0,0 +1,137 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestAuditDiff(t *testing.T) {
+	t.Run("OnlyChangedFields", func(t *testing.T) {
+		// Given
+		before := entities.Task{ID: "1", Summary: "Replace filter", Date: "2023-07-05", Status: "open", Tags: []string{"hvac"}}
+		after := before
+		after.Summary = "Replace filter and belt"
+		after.Tags = []string{"hvac", "belt"}
+
+		// When
+		beforeJSON, afterJSON, err := models.AuditDiff(before, after)
+
+		// Then
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"summary": "Replace filter", "tags": ["hvac"]}`, string(beforeJSON))
+		assert.JSONEq(t, `{"summary": "Replace filter and belt", "tags": ["hvac", "belt"]}`, string(afterJSON))
+	})
+
+	t.Run("Creation", func(t *testing.T) {
+		beforeJSON, afterJSON, err := models.AuditDiff(nil, entities.User{ID: "1", FirstName: "Ada"})
+
+		assert.NoError(t, err)
+		assert.Nil(t, beforeJSON)
+		assert.Contains(t, string(afterJSON), `"first_name":"Ada"`)
+	})
+}
+
+func TestGetAuditLog(t *testing.T) {
+	auditModel := models.AuditHandler(nil)
+
+	t.Run("TechnicianForbidden", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		rr := httptest.NewRecorder()
+
+		// When
+		auditModel.GetAuditLog(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("InvalidPaging", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodGet, "/audit?limit=100000", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		auditModel.GetAuditLog(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestExportAuditLogEscapesFormulas(t *testing.T) {
+	db := setupIntegrationDB(t)
+	auditModel := models.AuditHandler(db)
+	managerID := insertTestUser(t, db, "")
+
+	// Given
+	_, err := db.Exec(`INSERT INTO audit_log (actor_id, action, entity_type, entity_id, request_id)
+		VALUES (?, 'update', 'user', ?, '=HYPERLINK("http://evil.example")')`, managerID, managerID)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/audit/export", nil)
+	rr := httptest.NewRecorder()
+
+	// When
+	auditModel.ExportAuditLog(rr, withUser(req, managerID, ""))
+
+	// Then
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"'=HYPERLINK(""http://evil.example"")"`)
+}
+
+func TestClientIP(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 192.168.0.0/16")
+
+	for name, tc := range map[string]struct {
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		"DirectClient":          {"203.0.113.7:52000", "", "203.0.113.7"},
+		"UntrustedProxyIgnored": {"203.0.113.7:52000", "198.51.100.1", "203.0.113.7"},
+		"TrustedProxy":          {"10.0.0.1:52000", "198.51.100.1", "198.51.100.1"},
+		"SpoofedFirstHop":       {"10.0.0.1:52000", "1.1.1.1, 198.51.100.1", "198.51.100.1"},
+		"ChainOfTrustedProxies": {"10.0.0.1:52000", "198.51.100.1, 192.168.1.5", "198.51.100.1"},
+		"InvalidHop":            {"10.0.0.1:52000", "unknown", "10.0.0.1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			// When
+			ip := models.ClientIP(req)
+
+			// Then
+			assert.Equal(t, tc.expected, ip)
+		})
+	}
+
+	t.Run("NoTrustedProxies", func(t *testing.T) {
+		// Given
+		t.Setenv("TRUSTED_PROXIES", "")
+		req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+		req.RemoteAddr = "10.0.0.1:52000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+		// When
+		ip := models.ClientIP(req)
+
+		// Then
+		assert.Equal(t, "10.0.0.1", ip)
+	})
+}
@@ -0,0 +1,187 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestBulkUpdate(t *testing.T) {
+	bulkModel := models.BulkHandler(nil, nil)
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodGet, "/tasks/bulk", nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		bulkModel.BulkUpdate(rr, req)
+
+		// Then
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+
+	t.Run("EmptyOperations", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(`{"atomic": true, "operations": []}`))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		bulkModel.BulkUpdate(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestValidateBulkOperations(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		// Given
+		operations := []entities.BulkOperation{
+			{Op: "delete", TaskID: "1"},
+			{Op: "retag", TaskID: "2", AddTags: []string{"Urgent"}},
+			{Op: "reassign", TaskID: "3", UserID: "7"},
+			{Op: "set_status", TaskID: "4", Status: "done"},
+		}
+
+		// When
+		err := models.ValidateBulkOperations(operations)
+
+		// Then
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		// Given
+		cases := map[string]entities.BulkOperation{
+			"UnknownOp":      {Op: "archive", TaskID: "1"},
+			"MissingTaskID":  {Op: "delete"},
+			"RetagNoTags":    {Op: "retag", TaskID: "1"},
+			"RetagEmptyTag":  {Op: "retag", TaskID: "1", Tags: []string{" "}},
+			"ReassignNoUser": {Op: "reassign", TaskID: "1"},
+			"BadStatus":      {Op: "set_status", TaskID: "1", Status: "closed"},
+		}
+
+		for name, operation := range cases {
+			// When
+			err := models.ValidateBulkOperations([]entities.BulkOperation{operation})
+
+			// Then
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestBulkUpdateStore(t *testing.T) {
+	db := setupIntegrationDB(t)
+	bulkModel := models.BulkHandler(db, nil)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	otherManagerID := insertTestUser(t, db, "")
+	otherTechnicianID := insertTestUser(t, db, otherManagerID)
+
+	bulkUpdate := func(userID string, managerID string, request entities.BulkRequest) (int, []entities.BulkResult) {
+		body, err := json.Marshal(request)
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		bulkModel.BulkUpdate(rr, withUser(req, userID, managerID))
+		var results []entities.BulkResult
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+		return rr.Code, results
+	}
+	isTrashed := func(taskID string) bool {
+		var deletedAt sql.NullString
+		assert.NoError(t, db.QueryRow("SELECT deleted_at FROM tasks WHERE id = ?", taskID).Scan(&deletedAt))
+		return deletedAt.Valid
+	}
+
+	t.Run("AtomicRollsBack", func(t *testing.T) {
+		// Given
+		ownTaskID := insertTestTask(t, db, technicianID)
+		otherTaskID := insertTestTask(t, db, otherTechnicianID)
+		laterTaskID := insertTestTask(t, db, technicianID)
+
+		// When
+		code, results := bulkUpdate(managerID, "", entities.BulkRequest{Atomic: true, Operations: []entities.BulkOperation{
+			{Op: "delete", TaskID: ownTaskID},
+			{Op: "delete", TaskID: otherTaskID},
+			{Op: "delete", TaskID: laterTaskID},
+		}})
+
+		// Then
+		assert.Equal(t, http.StatusConflict, code)
+		if assert.Len(t, results, 3) {
+			assert.Equal(t, "rolled_back", results[0].Status)
+			assert.Nil(t, results[0].Task)
+			assert.Equal(t, "error", results[1].Status)
+			assert.Equal(t, http.StatusForbidden, results[1].Code)
+			assert.Equal(t, "skipped", results[2].Status)
+		}
+		assert.False(t, isTrashed(ownTaskID))
+		assert.False(t, isTrashed(otherTaskID))
+		assert.False(t, isTrashed(laterTaskID))
+	})
+
+	t.Run("BestEffortReportsEachOperation", func(t *testing.T) {
+		// Given
+		ownTaskID := insertTestTask(t, db, technicianID)
+		otherTaskID := insertTestTask(t, db, otherTechnicianID)
+
+		// When
+		code, results := bulkUpdate(managerID, "", entities.BulkRequest{Operations: []entities.BulkOperation{
+			{Op: "delete", TaskID: ownTaskID},
+			{Op: "delete", TaskID: otherTaskID},
+			{Op: "delete", TaskID: "missing"},
+		}})
+
+		// Then
+		assert.Equal(t, http.StatusOK, code)
+		if assert.Len(t, results, 3) {
+			assert.Equal(t, "ok", results[0].Status)
+			assert.NotNil(t, results[0].Task)
+			assert.Equal(t, "error", results[1].Status)
+			assert.Equal(t, http.StatusForbidden, results[1].Code)
+			assert.Equal(t, "error", results[2].Status)
+			assert.Equal(t, http.StatusNotFound, results[2].Code)
+		}
+		assert.True(t, isTrashed(ownTaskID))
+		assert.False(t, isTrashed(otherTaskID))
+	})
+
+	t.Run("BlockedStatusChange", func(t *testing.T) {
+		// Given
+		blockedTaskID := insertTestTask(t, db, technicianID)
+		blockerID := insertTestTask(t, db, technicianID)
+		_, err := db.Exec("INSERT INTO task_dependencies (task_id, blocked_by_id) VALUES (?, ?)", blockedTaskID, blockerID)
+		assert.NoError(t, err)
+
+		// When
+		code, results := bulkUpdate(technicianID, managerID, entities.BulkRequest{Operations: []entities.BulkOperation{
+			{Op: "set_status", TaskID: blockedTaskID, Status: "done"},
+			{Op: "set_status", TaskID: blockerID, Status: "done"},
+		}})
+
+		// Then
+		assert.Equal(t, http.StatusOK, code)
+		if assert.Len(t, results, 2) {
+			assert.Equal(t, "error", results[0].Status)
+			assert.Equal(t, http.StatusConflict, results[0].Code)
+			assert.Equal(t, "ok", results[1].Status)
+		}
+		var status string
+		assert.NoError(t, db.QueryRow("SELECT status FROM tasks WHERE id = ?", blockedTaskID).Scan(&status))
+		assert.Equal(t, "open", status)
+	})
+}
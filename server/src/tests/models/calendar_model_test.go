@@ -0,0 +1,71 @@
+package models_tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestGetCalendarFeed(t *testing.T) {
+	// Given
+	calendarModel := models.CalendarHandler(nil)
+	req := httptest.NewRequest(http.MethodPost, "/calendar/abc.ics", nil)
+	rr := httptest.NewRecorder()
+
+	// When
+	calendarModel.GetFeed(rr, req, "abc")
+
+	// Then
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestTaskCalendarComponents(t *testing.T) {
+	t.Run("WithDueDate", func(t *testing.T) {
+		// Given
+		task := entities.Task{ID: "1", Summary: "Service boiler, level 2", Date: "2023-03-31", DueDate: "2023-04-03",
+			Status: "done", Priority: "high", CompletedAt: "2023-04-01 09:30:00", Version: 3}
+
+		// When
+		components := models.TaskCalendarComponents(task, "2023-04-01 09:30:00")
+
+		// Then
+		assert.Len(t, components, 2)
+		assert.Equal(t, "VEVENT", components[0].Name)
+		assert.Contains(t, components[0].Properties, services.ICalProperty{Name: "DTSTART;VALUE=DATE", Value: "20230331"})
+		assert.Contains(t, components[0].Properties, services.ICalProperty{Name: "DTEND;VALUE=DATE", Value: "20230401"})
+		assert.Contains(t, components[0].Properties, services.ICalProperty{Name: "SUMMARY", Value: `Service boiler\, level 2`})
+		assert.Contains(t, components[0].Properties, services.ICalProperty{Name: "DTSTAMP", Value: "20230401T093000Z"})
+		assert.Contains(t, components[0].Properties, services.ICalProperty{Name: "SEQUENCE", Value: "2"})
+		assert.Equal(t, "VTODO", components[1].Name)
+		assert.Contains(t, components[1].Properties, services.ICalProperty{Name: "DUE;VALUE=DATE", Value: "20230403"})
+		assert.Contains(t, components[1].Properties, services.ICalProperty{Name: "STATUS", Value: "COMPLETED"})
+		assert.Contains(t, components[1].Properties, services.ICalProperty{Name: "PRIORITY", Value: "3"})
+		assert.Contains(t, components[1].Properties, services.ICalProperty{Name: "COMPLETED", Value: "20230401T093000Z"})
+	})
+
+	t.Run("WithoutDueDate", func(t *testing.T) {
+		// Given
+		task := entities.Task{ID: "2", Summary: "Inspect roof", Date: "2023-05-01", Status: "open", Priority: "normal", Version: 1}
+
+		// When
+		components := models.TaskCalendarComponents(task, "")
+
+		// Then
+		assert.Len(t, components, 1)
+		assert.Contains(t, components[0].Properties, services.ICalProperty{Name: "DTSTAMP", Value: "20230501T000000Z"})
+	})
+}
+
+func TestETagMatches(t *testing.T) {
+	assert.True(t, models.ETagMatches(`"abc"`, `"abc"`))
+	assert.True(t, models.ETagMatches(`"xyz", W/"abc"`, `"abc"`))
+	assert.True(t, models.ETagMatches("*", `"abc"`))
+	assert.False(t, models.ETagMatches(`"xyz"`, `"abc"`))
+	assert.False(t, models.ETagMatches("", `"abc"`))
+}
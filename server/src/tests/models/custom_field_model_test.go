@@ -0,0 +1,59 @@
+package models_tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestValidateCustomFieldValues(t *testing.T) {
+	fields := map[string]entities.CustomField{
+		"trade":       {ID: "1", Name: "trade", Type: "enum", Options: []string{"electrical", "plumbing"}, Required: true},
+		"cost_centre": {ID: "2", Name: "cost_centre", Type: "number"},
+		"inspected":   {ID: "3", Name: "inspected", Type: "date"},
+		"contractor":  {ID: "4", Name: "contractor", Type: "text"},
+	}
+
+	t.Run("ValidValues", func(t *testing.T) {
+		// When
+		stored, err := models.ValidateCustomFieldValues(fields, map[string]interface{}{
+			"trade":       "plumbing",
+			"cost_centre": float64(4100),
+			"inspected":   "2023-07-05",
+			"contractor":  nil,
+		}, true)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, "plumbing", *stored["1"])
+		assert.Equal(t, "4100", *stored["2"])
+		assert.Equal(t, "2023-07-05", *stored["3"])
+		assert.Nil(t, stored["4"])
+	})
+
+	t.Run("MissingRequiredField", func(t *testing.T) {
+		_, err := models.ValidateCustomFieldValues(fields, map[string]interface{}{"contractor": "ACME"}, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("RequiredFieldNotCheckedOnUpdate", func(t *testing.T) {
+		_, err := models.ValidateCustomFieldValues(fields, map[string]interface{}{"contractor": "ACME"}, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidValues", func(t *testing.T) {
+		for name, value := range map[string]interface{}{
+			"trade":       "carpentry",
+			"cost_centre": "4100",
+			"inspected":   "05/07/2023",
+			"contractor":  float64(1),
+			"unknown":     "x",
+		} {
+			_, err := models.ValidateCustomFieldValues(fields, map[string]interface{}{"trade": "plumbing", name: value}, true)
+			assert.Error(t, err, name)
+		}
+	})
+}
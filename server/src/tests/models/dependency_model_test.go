@@ -0,0 +1,54 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestAddDependency(t *testing.T) {
+	dependencyModel := models.DependencyHandler(nil)
+
+	t.Run("SelfDependency", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPost, "/tasks/1/dependencies", bytes.NewBufferString(`{"blocked_by": "1"}`))
+		ctx := context.WithValue(req.Context(), "userID", "1")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		dependencyModel.AddDependency(rr, req.WithContext(ctx), "1")
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("MissingBlocker", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPost, "/tasks/1/dependencies", bytes.NewBufferString(`{}`))
+		ctx := context.WithValue(req.Context(), "userID", "1")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		dependencyModel.AddDependency(rr, req.WithContext(ctx), "1")
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/1/dependencies", nil)
+		rr := httptest.NewRecorder()
+
+		dependencyModel.AddDependency(rr, req, "1")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+}
@@ -0,0 +1,107 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestGetDigests(t *testing.T) {
+	// Given
+	digestModel := models.DigestHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/digests", nil)
+	ctx := context.WithValue(req.Context(), "userID", "123")
+	ctx = context.WithValue(ctx, "managerID", "456")
+	rr := httptest.NewRecorder()
+
+	// When
+	digestModel.GetDigests(rr, req.WithContext(ctx))
+
+	// Then
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestValidateDigestSettings(t *testing.T) {
+	valid := entities.DigestSettings{Frequency: "weekly", SendHour: 7, SendWeekday: 1, Timezone: "Europe/Berlin"}
+	location, err := models.ValidateDigestSettings(valid)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", location.String())
+
+	invalid := map[string]entities.DigestSettings{
+		"Frequency": {Frequency: "hourly", Timezone: "UTC"},
+		"Hour":      {Frequency: "daily", SendHour: 24, Timezone: "UTC"},
+		"Weekday":   {Frequency: "weekly", SendWeekday: 7, Timezone: "UTC"},
+		"Timezone":  {Frequency: "daily", Timezone: "Mars/Olympus"},
+	}
+	for name, settings := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := models.ValidateDigestSettings(settings)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLatestDigestTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	// Wednesday 4 January 2023, 06:30 in Berlin
+	now := time.Date(2023, 1, 4, 5, 30, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		settings entities.DigestSettings
+		expected time.Time
+	}{
+		"DailyBeforeSendHour": {entities.DigestSettings{Frequency: "daily", SendHour: 7}, time.Date(2023, 1, 3, 7, 0, 0, 0, berlin)},
+		"DailyAfterSendHour":  {entities.DigestSettings{Frequency: "daily", SendHour: 6}, time.Date(2023, 1, 4, 6, 0, 0, 0, berlin)},
+		"WeeklyEarlierDay":    {entities.DigestSettings{Frequency: "weekly", SendHour: 7, SendWeekday: 1}, time.Date(2023, 1, 2, 7, 0, 0, 0, berlin)},
+		"WeeklySameDayLater":  {entities.DigestSettings{Frequency: "weekly", SendHour: 7, SendWeekday: 3}, time.Date(2022, 12, 28, 7, 0, 0, 0, berlin)},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// When
+			latest := models.LatestDigestTime(c.settings, berlin, now)
+
+			// Then
+			assert.True(t, c.expected.Equal(latest), "expected %v, got %v", c.expected, latest)
+		})
+	}
+
+	assert.Equal(t, time.Date(2022, 12, 26, 7, 0, 0, 0, berlin), models.DigestPeriodStart("weekly", time.Date(2023, 1, 2, 7, 0, 0, 0, berlin)))
+}
+
+func TestRenderDigest(t *testing.T) {
+	// Given
+	digest := entities.Digest{
+		ManagerName: "Grace Hopper",
+		Frequency:   "daily",
+		Timezone:    "UTC",
+		PeriodStart: "2023-01-02T07:00:00Z",
+		PeriodEnd:   "2023-01-03T07:00:00Z",
+		Totals:      entities.DigestTotals{Created: 1, Overdue: 1},
+		Technicians: []entities.DigestTechnician{{
+			Name:    "Ada Lovelace",
+			Email:   "ada@example.com",
+			Created: []entities.DigestTask{{ID: "1", Summary: "Fix <pump>"}},
+			Overdue: []entities.DigestTask{{ID: "2", Summary: "Inspect roof", DueDate: "2023-01-01"}},
+		}},
+	}
+
+	// When
+	subject, text, html, err := models.RenderDigest(digest)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, "Daily digest for Mon 2 Jan 2023", subject)
+	assert.Contains(t, text, "Ada Lovelace <ada@example.com>")
+	assert.Contains(t, text, "+ Created: Fix <pump>")
+	assert.Contains(t, text, "! Overdue since 2023-01-01: Inspect roof")
+	assert.Contains(t, html, "Created: Fix &lt;pump&gt;")
+	assert.NotContains(t, html, "<pump>")
+}
@@ -0,0 +1,157 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestGetTimesheet(t *testing.T) {
+	t.Run("OtherTechnician", func(t *testing.T) {
+		// Given
+		documentModel := models.DocumentHandler(nil, nil)
+		req := httptest.NewRequest(http.MethodGet, "/users/789/timesheet.pdf", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		rr := httptest.NewRecorder()
+
+		// When
+		documentModel.GetTimesheet(rr, req.WithContext(ctx), "789")
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		// Given
+		documentModel := models.DocumentHandler(nil, nil)
+		req := httptest.NewRequest(http.MethodPost, "/users/123/timesheet.pdf", nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		documentModel.GetTimesheet(rr, req, "123")
+
+		// Then
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+}
+
+func TestTimesheetRange(t *testing.T) {
+	now := time.Date(2023, 3, 30, 15, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		query    string
+		from, to string
+		invalid  bool
+	}{
+		"CurrentWeek": {query: "", from: "2023-03-27", to: "2023-04-02"},
+		"Given":       {query: "from=2023-03-01&to=2023-03-31", from: "2023-03-01", to: "2023-03-31"},
+		"SingleDay":   {query: "from=2023-04-02&to=2023-04-02", from: "2023-04-02", to: "2023-04-02"},
+		"Reversed":    {query: "from=2023-03-31&to=2023-03-01", invalid: true},
+		"TooLong":     {query: "from=2023-01-01&to=2023-04-04", invalid: true},
+		"BadDate":     {query: "from=30.03.2023", invalid: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			query, _ := url.ParseQuery(c.query)
+
+			from, to, err := models.TimesheetRange(query, now)
+
+			if c.invalid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.from, from)
+			assert.Equal(t, c.to, to)
+		})
+	}
+}
+
+func TestUpdateBranding(t *testing.T) {
+	// Given
+	brandingModel := models.BrandingHandler(nil, nil)
+	req := httptest.NewRequest(http.MethodPut, "/branding", strings.NewReader(`{"company_name": "Acme"}`))
+	ctx := context.WithValue(req.Context(), "userID", "123")
+	ctx = context.WithValue(ctx, "managerID", "456")
+	rr := httptest.NewRecorder()
+
+	// When
+	brandingModel.UpdateBranding(rr, req.WithContext(ctx))
+
+	// Then
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestValidateBranding(t *testing.T) {
+	valid := entities.Branding{CompanyName: "Acme Facilities", AccentColor: "#1f4e79"}
+	assert.Equal(t, "", models.ValidateBranding(valid))
+
+	missingName := valid
+	missingName.CompanyName = " "
+	assert.NotEqual(t, "", models.ValidateBranding(missingName))
+
+	badColor := valid
+	badColor.AccentColor = "blue"
+	assert.NotEqual(t, "", models.ValidateBranding(badColor))
+
+	longFooter := valid
+	longFooter.Footer = strings.Repeat("x", 256)
+	assert.NotEqual(t, "", models.ValidateBranding(longFooter))
+}
+
+func TestWriteWorkOrderPDF(t *testing.T) {
+	// Given
+	branding := models.PDFBranding(entities.Branding{CompanyName: "Acme Facilities", Address: "1 Main St", Phone: "+1 555 0100", AccentColor: "#1f4e79"})
+	order := entities.WorkOrder{
+		Task: entities.Task{ID: "1", Summary: "Replace pump seal", Date: "2023-03-30", Status: "done", Priority: "high",
+			Tags: []string{"plumbing"}, CustomFields: map[string]interface{}{"trade": "plumbing"},
+			Checklist: []entities.ChecklistItem{{Text: "Isolate pump", Done: true}, {Text: "Test pressure"}}},
+		Technician: "Jane Doe",
+		Worklogs: []entities.TimeEntry{
+			{StartedAt: "2023-03-30 08:00:00", EndedAt: "2023-03-30 09:30:00", Minutes: 90, Technician: "Jane Doe"},
+			{StartedAt: "2023-03-30 10:00:00", Technician: "Jane Doe"},
+		},
+		Attachments: []entities.Attachment{{FileName: "seal.jpg", ContentType: "image/jpeg", Size: 2048, Checksum: strings.Repeat("ab", 32)}},
+		GeneratedAt: "2023-03-30 12:00:00",
+	}
+	var buf bytes.Buffer
+
+	// When
+	err := models.WriteWorkOrderPDF(&buf, branding, order)
+
+	// Then
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "%PDF-"))
+	assert.Contains(t, buf.String(), "/Title (Work Order)")
+	assert.Equal(t, services.PDFColor{R: 31.0 / 255, G: 78.0 / 255, B: 121.0 / 255}, branding.Accent)
+	assert.Equal(t, []string{"1 Main St", "+1 555 0100"}, branding.Details)
+}
+
+func TestWriteTimesheetPDF(t *testing.T) {
+	// Given
+	timesheet := entities.Timesheet{Technician: "Jane Doe", From: "2023-03-27", To: "2023-04-02", GeneratedAt: "2023-04-03 08:00:00",
+		Entries: []entities.TimeEntry{
+			{StartedAt: "2023-03-27 08:00:00", EndedAt: "2023-03-27 10:00:00", Minutes: 120, TaskSummary: "Inspect roof"},
+			{StartedAt: "2023-03-28 13:00:00", EndedAt: "2023-03-28 13:45:00", Minutes: 45, TaskSummary: "Replace filter"},
+		}}
+	var buf bytes.Buffer
+
+	// When
+	err := models.WriteTimesheetPDF(&buf, models.PDFBranding(entities.Branding{CompanyName: "Acme"}), timesheet)
+
+	// Then
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "%PDF-"))
+	assert.Contains(t, buf.String(), "/Title (Timesheet)")
+}
@@ -0,0 +1,120 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestStreamEvents(t *testing.T) {
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		// Given
+		eventModel := models.EventHandler(nil, services.NewEventBus(10), time.Hour)
+		req := httptest.NewRequest(http.MethodPost, "/events/stream", nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		eventModel.Stream(rr, req)
+
+		// Then
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+
+	t.Run("InvalidLastEventID", func(t *testing.T) {
+		// Given
+		eventModel := models.EventHandler(nil, services.NewEventBus(10), time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/events/stream", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		eventModel.Stream(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ResetWhenEventsWereMissed", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(10)
+		eventModel := models.EventHandler(nil, bus, time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/events/stream?last_event_id=1", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		rr := httptest.NewRecorder()
+
+		// When
+		eventModel.Stream(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "\nevent: reset\ndata: {}\n\n")
+		assert.True(t, rr.Flushed)
+	})
+
+	resume := func(bus *services.EventBus, lastEventID string) string {
+		eventModel := models.EventHandler(nil, bus, time.Hour)
+		req := httptest.NewRequest(http.MethodGet, "/events/stream", nil)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		rr := httptest.NewRecorder()
+		eventModel.Stream(rr, req.WithContext(ctx))
+		return rr.Body.String()
+	}
+
+	t.Run("ResumeFromOwnEvent", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(10)
+		bus.Publish("live.comment", "1", nil)
+		lastID := bus.Subscribe(0, 1).LastID
+		bus.Publish("live.comment", "1", nil)
+
+		// When
+		body := resume(bus, bus.Origin()+":"+strconv.FormatInt(lastID, 10))
+
+		// Then
+		assert.NotContains(t, body, "event: reset")
+		assert.Contains(t, body, "id: "+bus.Origin()+":"+strconv.FormatInt(lastID+1, 10)+"\n\n")
+	})
+
+	t.Run("ResetForAnotherInstance", func(t *testing.T) {
+		// Given
+		other := services.NewEventBus(10)
+		bus := services.NewEventBus(10)
+		bus.Publish("live.comment", "1", nil)
+		lastID := bus.Subscribe(0, 1).LastID
+		bus.Publish("live.comment", "1", nil)
+
+		// When
+		body := resume(bus, other.Origin()+":"+strconv.FormatInt(lastID, 10))
+
+		// Then
+		assert.Contains(t, body, "id: "+bus.Origin()+":"+strconv.FormatInt(lastID+1, 10)+"\nevent: reset\n")
+	})
+}
+
+func TestWriteServerSentEvent(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := models.WriteServerSentEvent(&buf, "42", services.Event{ID: 42, Type: "task.updated", Subject: "1", Data: []byte(`{"type":"task.updated"}`)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 42\nevent: task.updated\ndata: {\"type\":\"task.updated\"}\n\n", buf.String())
+}
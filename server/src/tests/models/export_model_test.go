@@ -0,0 +1,44 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestExportTasks(t *testing.T) {
+	exportModel := models.ExportHandler(nil)
+
+	cases := map[string]string{
+		"UnknownFormat": "/tasks/export?format=pdf",
+		"UnknownColumn": "/tasks/export?columns=id,password",
+		"InvalidFilter": "/tasks/export?from=yesterday",
+	}
+	for name, target := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			ctx := context.WithValue(req.Context(), "userID", "123")
+			ctx = context.WithValue(ctx, "managerID", "")
+			rr := httptest.NewRecorder()
+
+			// When
+			exportModel.ExportTasks(rr, req.WithContext(ctx))
+
+			// Then
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestEscapeSpreadsheetFormula(t *testing.T) {
+	assert.Equal(t, "'=HYPERLINK(\"x\")", models.EscapeSpreadsheetFormula("=HYPERLINK(\"x\")"))
+	assert.Equal(t, "'@SUM(A1)", models.EscapeSpreadsheetFormula("@SUM(A1)"))
+	assert.Equal(t, "Replace filter", models.EscapeSpreadsheetFormula("Replace filter"))
+	assert.Equal(t, "", models.EscapeSpreadsheetFormula(""))
+}
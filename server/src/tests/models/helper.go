@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,9 +38,44 @@ func cleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
 
 	tables := []string{
+		"task_dependencies",
+		"overdue_blocker_warnings",
+		"task_attachments",
+		"task_checklist_items",
+		"task_revisions",
+		"task_tags",
+		"task_custom_field_values",
+		"worklogs",
+		"task_viewers",
+		"task_comments",
 		"tasks",
-		"users",
+		"saved_view_defaults",
+		"saved_views",
+		"imports",
+		"calendar_feeds",
+		"digest_reports",
+		"digest_settings",
+		"branding",
+		"webhook_attempts",
+		"webhook_deliveries",
+		"webhooks",
 		"managers",
+		"manager_sites",
+		"users",
+		"assets",
+		"locations",
+		"custom_fields",
+		"task_templates",
+		"idempotency_keys",
+		"audit_log",
+	}
+
+	// Self-referencing rows must be unlinked before they can be deleted
+	for _, table := range []string{"assets", "locations"} {
+		_, err := db.Exec(fmt.Sprintf("UPDATE %s SET parent_id = NULL", table))
+		if err != nil {
+			t.Fatalf("Failed to unlink data in table %s: %v", table, err)
+		}
 	}
 
 	for _, table := range tables {
@@ -55,6 +91,56 @@ func cleanupTestDB(t *testing.T, db *sql.DB) {
 	}
 }
 
+// setupIntegrationDB connects to the test database for tests that need real rows. They are skipped
+// when no test database is running, and the tables are emptied once they end.
+func setupIntegrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		t.Skip("test database unavailable:", err)
+	}
+	t.Cleanup(func() { cleanupTestDB(t, db) })
+	return db
+}
+
+// insertTestUser adds a user and, when managerID is set, links them to that manager as a technician.
+// IDs are kept short to fit managers.technician_id and tasks.user_id.
+func insertTestUser(t *testing.T, db *sql.DB, managerID string) string {
+	t.Helper()
+	id := strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+	_, err := db.Exec("INSERT INTO users (id, first_name, last_name, email, password) VALUES (?, ?, ?, ?, ?)",
+		id, gofakeit.FirstName(), gofakeit.LastName(), gofakeit.Email(), "password")
+	if err != nil {
+		t.Fatal("Failed to create user in database:", err)
+	}
+	if managerID != "" {
+		_, err = db.Exec("INSERT INTO managers (id, manager_id, technician_id) VALUES (?, ?, ?)", uuid.New().String(), managerID, id)
+		if err != nil {
+			t.Fatal("Failed to link technician to manager:", err)
+		}
+	}
+	return id
+}
+
+// insertTestTask adds an open task owned by the user
+func insertTestTask(t *testing.T, db *sql.DB, userID string) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := db.Exec("INSERT INTO tasks (id, summary, date, user_id) VALUES (?, ?, ?, ?)", id, gofakeit.Sentence(4), "2023-07-05", userID)
+	if err != nil {
+		t.Fatal("Failed to create task in database:", err)
+	}
+	return id
+}
+
+// withUser authenticates the request as userID, a technician of managerID or a Manager when that is empty
+func withUser(req *http.Request, userID string, managerID string) *http.Request {
+	ctx := context.WithValue(req.Context(), "userID", userID)
+	ctx = context.WithValue(ctx, "managerID", managerID)
+	return req.WithContext(ctx)
+}
+
 func createTestRequest(t *testing.T, method, url string, body []byte) *http.Request {
 	t.Helper()
 	req, err := http.NewRequest(method, url, bytes.NewReader(body))
@@ -0,0 +1,118 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestCreateTaskIdempotencyKey(t *testing.T) {
+	taskModel := models.TaskHandler(nil, nil)
+
+	t.Run("KeyTooLong", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"summary": "Replace filter", "date": "2023-07-05"}`))
+		req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		rr := httptest.NewRecorder()
+
+		// When
+		taskModel.CreateTask(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestCreateTaskIdempotencyKeyStore(t *testing.T) {
+	db := setupIntegrationDB(t)
+	taskModel := models.TaskHandler(db, nil)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+
+	createTask := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(body))
+		req.Header.Set("Idempotency-Key", key)
+		rr := httptest.NewRecorder()
+		taskModel.CreateTask(rr, withUser(req, technicianID, managerID))
+		return rr
+	}
+	countTasks := func(summary string) int {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM tasks WHERE user_id = ? AND summary = ?", technicianID, summary).Scan(&count)
+		assert.NoError(t, err)
+		return count
+	}
+
+	t.Run("ReplaysStoredResponse", func(t *testing.T) {
+		// Given
+		body := `{"summary": "Replace filter", "date": "2023-07-05"}`
+		first := createTask("replay", body)
+		assert.Equal(t, http.StatusCreated, first.Code)
+
+		// When
+		second := createTask("replay", body)
+
+		// Then
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, 1, countTasks("Replace filter"))
+	})
+
+	t.Run("DifferentBody", func(t *testing.T) {
+		// Given
+		assert.Equal(t, http.StatusCreated, createTask("reused", `{"summary": "Replace belt", "date": "2023-07-05"}`).Code)
+
+		// When
+		rr := createTask("reused", `{"summary": "Replace pump", "date": "2023-07-05"}`)
+
+		// Then
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Equal(t, 0, countTasks("Replace pump"))
+	})
+
+	t.Run("StillInFlight", func(t *testing.T) {
+		// Given
+		body := `{"summary": "Replace valve", "date": "2023-07-05"}`
+		sum := sha256.Sum256([]byte("POST /tasks\n" + body))
+		_, err := db.Exec("INSERT INTO idempotency_keys (user_id, idem_key, request_hash, created_at) VALUES (?, ?, ?, UTC_TIMESTAMP())",
+			technicianID, "in-flight", hex.EncodeToString(sum[:]))
+		assert.NoError(t, err)
+
+		// When
+		rr := createTask("in-flight", body)
+
+		// Then
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, 0, countTasks("Replace valve"))
+	})
+
+	t.Run("ReleasedAfterServerError", func(t *testing.T) {
+		// Given
+		failed := createTask("retry", `{"summary": "Replace fan", "date": "not-a-date"}`)
+		assert.Equal(t, http.StatusInternalServerError, failed.Code)
+		var count int
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM idempotency_keys WHERE user_id = ? AND idem_key = ?", technicianID, "retry").Scan(&count))
+		assert.Equal(t, 0, count)
+
+		// When
+		rr := createTask("retry", `{"summary": "Replace fan", "date": "2023-07-05"}`)
+
+		// Then
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 1, countTasks("Replace fan"))
+	})
+}
@@ -0,0 +1,110 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestImport(t *testing.T) {
+	t.Run("NotManager", func(t *testing.T) {
+		// Given
+		importModel := models.ImportHandler(nil)
+		req := httptest.NewRequest(http.MethodPost, "/imports/tasks", strings.NewReader("summary,date\n"))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		rr := httptest.NewRecorder()
+
+		// When
+		importModel.Import(rr, req.WithContext(ctx), "tasks")
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	cases := map[string]struct {
+		kind   string
+		target string
+		body   string
+	}{
+		"UnknownKind":         {"assets", "/imports/assets", "name\nPump\n"},
+		"EmptyFile":           {"tasks", "/imports/tasks", ""},
+		"MissingColumn":       {"users", "/imports/users", "first_name,last_name,email\nAda,Lovelace,ada@example.com\n"},
+		"MissingTechnician":   {"tasks", "/imports/tasks", "summary,date\nFix pump,2023-01-02\n"},
+		"MappedColumnMissing": {"tasks", "/imports/tasks?map.summary=Title", "summary,date,user_id\n"},
+		"MalformedCSV":        {"tasks", "/imports/tasks", "\"summary,date,user_id\n"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			importModel := models.ImportHandler(nil)
+			req := httptest.NewRequest(http.MethodPost, c.target, strings.NewReader(c.body))
+			ctx := context.WithValue(req.Context(), "userID", "123")
+			ctx = context.WithValue(ctx, "managerID", "")
+			rr := httptest.NewRecorder()
+
+			// When
+			importModel.Import(rr, req.WithContext(ctx), c.kind)
+
+			// Then
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestResolveImportColumns(t *testing.T) {
+	// Given
+	header := []string{"\ufeffTitle", "date", "Technician", "tags", "field.trade", "notes"}
+	mapping := map[string]string{"summary": "Title", "technician_email": "Technician"}
+
+	// When
+	columns, err := models.ResolveImportColumns("tasks", header, mapping)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"summary":          0,
+		"date":             1,
+		"technician_email": 2,
+		"tags":             3,
+		"field.trade":      4,
+	}, columns)
+
+	_, err = models.ResolveImportColumns("tasks", header, map[string]string{"password": "notes"})
+	assert.Error(t, err)
+}
+
+func TestImportMapping(t *testing.T) {
+	query := url.Values{"map.summary": {"Title"}, "map.": {"x"}, "dry_run": {"true"}}
+	assert.Equal(t, map[string]string{"summary": "Title"}, models.ImportMapping(query))
+}
+
+func TestImportUsersErrorFileOmitsPasswords(t *testing.T) {
+	db := setupIntegrationDB(t)
+	managerID := insertTestUser(t, db, "")
+
+	// Given
+	file := "first_name,last_name,email,password\n" +
+		"Ada,Lovelace,not-an-email,Sup3rSecret\n" +
+		",Hopper,grace@example.com,An0therSecret\n" +
+		"Alan,Turing,alan@example.com,Enigma1912\n"
+
+	// When
+	report, err := models.RunImport(db, models.ImportOptions{Kind: "users", DryRun: true, ManagerID: managerID}, strings.NewReader(file))
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Rejected)
+	errorsCSV := string(report.ErrorsCSV)
+	assert.Contains(t, errorsCSV, "not-an-email")
+	for _, password := range []string{"Sup3rSecret", "An0therSecret", "Enigma1912"} {
+		assert.NotContains(t, errorsCSV, password)
+	}
+}
@@ -0,0 +1,142 @@
+package models_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestConnectLive(t *testing.T) {
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		// Given
+		liveModel := models.LiveHandler(nil, services.NewEventBus(10), models.NewLiveHub(nil))
+		req := httptest.NewRequest(http.MethodPost, "/tasks/1/live", nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		liveModel.Connect(rr, req, "1")
+
+		// Then
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+}
+
+// dialLive opens the live channel of a task as the user
+func dialLive(t *testing.T, liveModel *models.LiveModel, taskID string, userID string, managerID string) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		liveModel.Connect(w, withUser(r, userID, managerID), taskID)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal("Failed to open live channel:", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	return conn
+}
+
+// readLive skips messages until one of the given type arrives
+func readLive(t *testing.T, conn *websocket.Conn, messageType string) entities.LiveMessage {
+	t.Helper()
+	for {
+		var message entities.LiveMessage
+		err := conn.ReadJSON(&message)
+		if err != nil {
+			t.Fatalf("Expected a %s message: %v", messageType, err)
+		}
+		if message.Type == messageType {
+			return message
+		}
+	}
+}
+
+func TestLiveAccessIsCheckedAgain(t *testing.T) {
+	db := setupIntegrationDB(t)
+	bus := services.NewEventBus(10)
+	hub := models.NewLiveHub(db)
+	go hub.Run(bus, time.Hour)
+	liveModel := models.LiveHandler(db, bus, hub)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	otherTechnicianID := insertTestUser(t, db, insertTestUser(t, db, ""))
+
+	t.Run("ReassignedOutOfTeam", func(t *testing.T) {
+		// Given
+		taskID := insertTestTask(t, db, technicianID)
+		conn := dialLive(t, liveModel, taskID, managerID, "")
+		snapshot := readLive(t, conn, "snapshot")
+
+		// When
+		_, err := db.Exec("UPDATE tasks SET user_id = ? WHERE id = ?", otherTechnicianID, taskID)
+		assert.NoError(t, err)
+		task := *snapshot.Task
+		task.UserID = otherTechnicianID
+		data, err := json.Marshal(entities.TaskEvent{Type: "task.updated", Task: task, ActorID: otherTechnicianID})
+		assert.NoError(t, err)
+		bus.Publish("task.updated", taskID, data)
+
+		// Then
+		assert.Equal(t, "You no longer have access to this task", readLive(t, conn, "error").Message)
+		for {
+			var message entities.LiveMessage
+			err := conn.ReadJSON(&message)
+			if err != nil {
+				assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+				break
+			}
+			assert.NotEqual(t, "task.updated", message.Type)
+		}
+	})
+
+	t.Run("NoCommentsOnTrashedTask", func(t *testing.T) {
+		// Given
+		taskID := insertTestTask(t, db, technicianID)
+		conn := dialLive(t, liveModel, taskID, technicianID, managerID)
+		readLive(t, conn, "snapshot")
+		_, err := db.Exec("UPDATE tasks SET deleted_at = UTC_TIMESTAMP(), deleted_by = ? WHERE id = ?", managerID, taskID)
+		assert.NoError(t, err)
+
+		// When
+		assert.NoError(t, conn.WriteJSON(entities.LiveRequest{Type: "comment", Text: "On my way"}))
+
+		// Then
+		assert.Equal(t, "Task not found", readLive(t, conn, "error").Message)
+		var count int
+		assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM task_comments WHERE task_id = ?", taskID).Scan(&count))
+		assert.Equal(t, 0, count)
+	})
+}
+
+func TestLiveTaskMessage(t *testing.T) {
+	// Given
+	before := entities.Task{ID: "1", Summary: "Fix the pump", Status: "open", Priority: "medium"}
+	after := before
+	after.Status = "in_progress"
+
+	// When
+	data, err := models.LiveTaskMessage("task.updated", before, entities.TaskEvent{Type: "task.updated", Task: after, ActorID: "7"})
+
+	// Then
+	assert.NoError(t, err)
+	var message entities.LiveMessage
+	assert.NoError(t, json.Unmarshal(data, &message))
+	assert.Equal(t, "task.updated", message.Type)
+	assert.Equal(t, "7", message.ActorID)
+	assert.Equal(t, "in_progress", message.Task.Status)
+	assert.Len(t, message.Changes, 1)
+	assert.Equal(t, "open", message.Changes["status"].From)
+	assert.Equal(t, "in_progress", message.Changes["status"].To)
+}
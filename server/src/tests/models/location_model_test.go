@@ -0,0 +1,142 @@
+package models_tests
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+// createTestLocation creates a location through the API as the given Manager
+func createTestLocation(t *testing.T, locationModel *models.LocationModel, managerID string, location entities.Location) string {
+	t.Helper()
+	body, err := json.Marshal(location)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/locations", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	locationModel.CreateLocation(rr, withUser(req, managerID, ""))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to create location %s: %d %s", location.Name, rr.Code, rr.Body.String())
+	}
+	var created entities.Location
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	return created.ID
+}
+
+// insertTestTaskAt adds a task owned by the user at the location
+func insertTestTaskAt(t *testing.T, db *sql.DB, userID string, locationID string) string {
+	t.Helper()
+	id := insertTestTask(t, db, userID)
+	_, err := db.Exec("UPDATE tasks SET location_id = ? WHERE id = ?", locationID, id)
+	if err != nil {
+		t.Fatal("Failed to set task location:", err)
+	}
+	return id
+}
+
+func TestManagerSiteScope(t *testing.T) {
+	db := setupIntegrationDB(t)
+	locationModel := models.LocationHandler(db)
+	taskModel := models.TaskHandler(db, nil)
+
+	scopedManagerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, scopedManagerID)
+	unscopedManagerID := insertTestUser(t, db, "")
+	otherTechnicianID := insertTestUser(t, db, unscopedManagerID)
+
+	siteID := createTestLocation(t, locationModel, scopedManagerID, entities.Location{Name: "North", Kind: "site"})
+	buildingID := createTestLocation(t, locationModel, scopedManagerID, entities.Location{Name: "Block A", Kind: "building", ParentID: siteID})
+	otherSiteID := createTestLocation(t, locationModel, scopedManagerID, entities.Location{Name: "South", Kind: "site"})
+
+	req := httptest.NewRequest(http.MethodPut, "/managers/"+scopedManagerID+"/sites",
+		bytes.NewBufferString(`{"location_ids":["`+siteID+`"]}`))
+	rr := httptest.NewRecorder()
+	locationModel.SetManagerSites(rr, withUser(req, scopedManagerID, ""), scopedManagerID)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	siteTaskID := insertTestTaskAt(t, db, technicianID, siteID)
+	buildingTaskID := insertTestTaskAt(t, db, technicianID, buildingID)
+	otherSiteTaskID := insertTestTaskAt(t, db, technicianID, otherSiteID)
+	unlocatedTaskID := insertTestTask(t, db, technicianID)
+	unscopedTaskID := insertTestTaskAt(t, db, otherTechnicianID, otherSiteID)
+
+	getTask := func(userID string, taskID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/"+taskID, nil)
+		rr := httptest.NewRecorder()
+		taskModel.GetTask(rr, withUser(req, userID, ""), taskID)
+		return rr.Code
+	}
+
+	t.Run("OnlySitesCanBeAssigned", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPut, "/managers/"+scopedManagerID+"/sites",
+			bytes.NewBufferString(`{"location_ids":["`+buildingID+`"]}`))
+		rr := httptest.NewRecorder()
+
+		// When
+		locationModel.SetManagerSites(rr, withUser(req, scopedManagerID, ""), scopedManagerID)
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("SubtreeOfParentSite", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodGet, "/locations?parent_id="+siteID, nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		locationModel.GetLocations(rr, withUser(req, scopedManagerID, ""))
+
+		// Then
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var locations []entities.Location
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &locations))
+		var ids []string
+		for _, location := range locations {
+			ids = append(ids, location.ID)
+		}
+		assert.ElementsMatch(t, []string{siteID, buildingID}, ids)
+	})
+
+	t.Run("ParentSiteTaskIsVisible", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, getTask(scopedManagerID, siteTaskID))
+	})
+
+	t.Run("ChildLocationTaskIsVisible", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, getTask(scopedManagerID, buildingTaskID))
+	})
+
+	t.Run("TaskWithoutLocationIsVisible", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, getTask(scopedManagerID, unlocatedTaskID))
+	})
+
+	t.Run("OtherSiteTaskIsHidden", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, getTask(scopedManagerID, otherSiteTaskID))
+	})
+
+	t.Run("OtherSiteTaskCannotBeDeleted", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodDelete, "/tasks/"+otherSiteTaskID, nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		taskModel.DeleteTask(rr, withUser(req, scopedManagerID, ""), otherSiteTaskID)
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("ManagerWithoutSitesSeesAllTasks", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, getTask(unscopedManagerID, unscopedTaskID))
+	})
+}
@@ -0,0 +1,62 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestTaskChanges(t *testing.T) {
+	t.Run("ChangedFields", func(t *testing.T) {
+		// Given
+		before := entities.Task{ID: "1", Summary: "Replace filter", Date: "2023-07-05", Status: "open", DueDate: "2023-07-10"}
+		after := before
+		after.Summary = "Replace filter and belt"
+		after.DueDate = ""
+
+		// When
+		changes, err := models.TaskChanges(before, after)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]entities.FieldChange{
+			"summary":  {From: "Replace filter", To: "Replace filter and belt"},
+			"due_date": {From: "2023-07-10", To: nil},
+		}, changes)
+	})
+
+	t.Run("IgnoresTrashFields", func(t *testing.T) {
+		before := entities.Task{ID: "1", Summary: "Replace filter"}
+		after := before
+		after.DeletedAt = "2023-07-05 10:00:00"
+
+		changes, err := models.TaskChanges(before, after)
+
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
+func TestRevertTask(t *testing.T) {
+	revisionModel := models.RevisionHandler(nil, nil)
+
+	t.Run("ManagerForbidden", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPost, "/tasks/1/revisions/1/revert", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		revisionModel.RevertTask(rr, req.WithContext(ctx), "1", "1")
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
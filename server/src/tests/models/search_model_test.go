@@ -0,0 +1,91 @@
+package models_tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestSearch(t *testing.T) {
+	searchModel := models.SearchHandler(nil, nil)
+
+	t.Run("MissingQuery", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodGet, "/search?q=%20-%20", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		searchModel.Search(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodGet, "/search?q=pump&status=closed", nil)
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		searchModel.Search(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestHighlightSummary(t *testing.T) {
+	// When
+	highlighted := models.HighlightSummary("Pump <B> leaks; replace pump seal", []string{"pump", "seal"})
+
+	// Then
+	assert.Equal(t, "<mark>Pump</mark> &lt;B&gt; leaks; replace <mark>pump</mark> <mark>seal</mark>", highlighted)
+}
+
+func TestSearchIndexFiltersBeforePaging(t *testing.T) {
+	db := setupIntegrationDB(t)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	firstID := insertTestTask(t, db, technicianID)
+	secondID := insertTestTask(t, db, technicianID)
+	_, err := db.Exec("UPDATE tasks SET summary = 'Replace pump seal' WHERE id IN (?, ?)", firstID, secondID)
+	assert.NoError(t, err)
+
+	// More than a batch of better matches that the technician cannot see
+	documents := map[string]string{firstID: "Replace pump seal", secondID: "Replace pump seal"}
+	for i := 0; i < 1500; i++ {
+		documents[fmt.Sprintf("hidden-%d", i)] = "pump pump pump"
+	}
+	index := services.NewSearchIndex()
+	index.Rebuild(documents)
+	searchModel := models.SearchHandler(db, index)
+
+	// Given
+	req := httptest.NewRequest(http.MethodGet, "/search?q=pump&limit=1&offset=1", nil)
+	rr := httptest.NewRecorder()
+
+	// When
+	searchModel.Search(rr, withUser(req, technicianID, managerID))
+
+	// Then
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var results entities.SearchResults
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+	assert.Equal(t, 2, results.Total)
+	if assert.Len(t, results.Results, 1) {
+		assert.Contains(t, []string{firstID, secondID}, results.Results[0].Task.ID)
+	}
+}
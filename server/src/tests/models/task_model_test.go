@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/christianotieno/tasks-traker-app/server/src/models"
 )
 
@@ -174,3 +176,88 @@ func TestUpdateTask(t *testing.T) {
 		}
 	})
 }
+
+func TestTaskPreconditions(t *testing.T) {
+	db := setupIntegrationDB(t)
+	tm := models.TaskHandler(db, nil)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	taskID := insertTestTask(t, db, technicianID)
+
+	getTask := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks/"+taskID, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		tm.GetTask(rr, withUser(req, technicianID, managerID), taskID)
+		return rr
+	}
+	etag := getTask("").Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	t.Run("NotModified", func(t *testing.T) {
+		for _, header := range []string{etag, "W/" + etag, `"stale", ` + etag} {
+			// When
+			rr := getTask(header)
+
+			// Then
+			assert.Equal(t, http.StatusNotModified, rr.Code, header)
+			assert.Empty(t, rr.Body.String())
+		}
+	})
+
+	t.Run("StaleIfMatch", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/"+taskID, strings.NewReader(`{"summary": "Replace filter"}`))
+		req.Header.Set("If-Match", etag)
+		rr := httptest.NewRecorder()
+		tm.UpdateTask(rr, withUser(req, technicianID, managerID), taskID)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req = httptest.NewRequest(http.MethodPatch, "/tasks/"+taskID, strings.NewReader(`{"summary": "Replace belt"}`))
+		req.Header.Set("If-Match", etag)
+		rr = httptest.NewRecorder()
+
+		// When
+		tm.UpdateTask(rr, withUser(req, technicianID, managerID), taskID)
+
+		// Then
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+		var summary string
+		assert.NoError(t, db.QueryRow("SELECT summary FROM tasks WHERE id = ?", taskID).Scan(&summary))
+		assert.Equal(t, "Replace filter", summary)
+	})
+
+	t.Run("ModifiedSinceCachedCopy", func(t *testing.T) {
+		// When
+		rr := getTask(etag)
+
+		// Then
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestCreateTaskRecordsFirstRevision(t *testing.T) {
+	db := setupIntegrationDB(t)
+	tm := models.TaskHandler(db, nil)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+
+	// Given
+	req := httptest.NewRequest(http.MethodPost, "/tasks",
+		strings.NewReader(`{"summary": "Replace filter", "date": "2023-07-05", "tags": ["hvac"], "checklist": [{"text": "Shut off unit"}]}`))
+	rr := httptest.NewRecorder()
+
+	// When
+	tm.CreateTask(rr, withUser(req, technicianID, managerID))
+
+	// Then
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var source string
+	err := db.QueryRow(`SELECT r.source FROM task_revisions r JOIN tasks t ON t.id = r.task_id
+		WHERE t.user_id = ? AND r.revision = 1`, technicianID).Scan(&source)
+	assert.NoError(t, err)
+	assert.Equal(t, "create", source)
+}
@@ -0,0 +1,93 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestCreateTemplate(t *testing.T) {
+	templateModel := models.TemplateHandler(nil)
+
+	t.Run("TechnicianForbidden", func(t *testing.T) {
+		// Given
+		req := httptest.NewRequest(http.MethodPost, "/templates", bytes.NewBufferString(`{"name": "Filter change"}`))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		rr := httptest.NewRecorder()
+
+		// When
+		templateModel.CreateTemplate(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("InvalidTemplates", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"MissingName":       `{"summary": "Replace filter"}`,
+			"InvalidPriority":   `{"name": "Filter change", "priority": "asap"}`,
+			"NegativeEstimate":  `{"name": "Filter change", "estimated_minutes": -5}`,
+			"EmptyChecklistRow": `{"name": "Filter change", "checklist": ["Isolate unit", ""]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Given
+				req := httptest.NewRequest(http.MethodPost, "/templates", bytes.NewBufferString(body))
+				ctx := context.WithValue(req.Context(), "userID", "123")
+				ctx = context.WithValue(ctx, "managerID", "")
+				rr := httptest.NewRecorder()
+
+				// When
+				templateModel.CreateTemplate(rr, req.WithContext(ctx))
+
+				// Then
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+			})
+		}
+	})
+}
+
+func TestGetTemplateStatsIsScopedToTeam(t *testing.T) {
+	db := setupIntegrationDB(t)
+	templateModel := models.TemplateHandler(db)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	otherManagerID := insertTestUser(t, db, "")
+	otherTechnicianID := insertTestUser(t, db, otherManagerID)
+
+	templateID := uuid.New().String()
+	_, err := db.Exec("INSERT INTO task_templates (id, name, summary) VALUES (?, ?, ?)", templateID, "Filter change", "Replace filter")
+	assert.NoError(t, err)
+	for _, userID := range []string{technicianID, otherTechnicianID, otherTechnicianID} {
+		taskID := insertTestTask(t, db, userID)
+		_, err := db.Exec("UPDATE tasks SET template_id = ? WHERE id = ?", templateID, taskID)
+		assert.NoError(t, err)
+		_, err = db.Exec("INSERT INTO worklogs (id, task_id, user_id, started_at, ended_at, duration_minutes) VALUES (?, ?, ?, ?, ?, ?)",
+			uuid.New().String(), taskID, userID, "2023-07-05 08:00:00", "2023-07-05 09:00:00", 60)
+		assert.NoError(t, err)
+	}
+
+	// Given
+	req := httptest.NewRequest(http.MethodGet, "/templates/stats", nil)
+	rr := httptest.NewRecorder()
+
+	// When
+	templateModel.GetTemplateStats(rr, withUser(req, managerID, ""))
+
+	// Then
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var stats []entities.TemplateStats
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Uses)
+	assert.Equal(t, float64(60), stats[0].AvgLoggedMinutes)
+}
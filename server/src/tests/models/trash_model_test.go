@@ -0,0 +1,165 @@
+package models_tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// trashTestTask moves the task to the trash as if it had been deleted the given time ago
+func trashTestTask(t *testing.T, db *sql.DB, taskID string, deletedBy string, ago time.Duration) {
+	t.Helper()
+	deletedAt := time.Now().UTC().Add(-ago).Format("2006-01-02 15:04:05")
+	_, err := db.Exec("UPDATE tasks SET deleted_at = ?, deleted_by = ? WHERE id = ?", deletedAt, deletedBy, taskID)
+	if err != nil {
+		t.Fatal("Failed to trash task:", err)
+	}
+}
+
+func TestRestoreTask(t *testing.T) {
+	db := setupIntegrationDB(t)
+	trashModel := models.TrashHandler(db, nil)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+	otherManagerID := insertTestUser(t, db, "")
+
+	restore := func(userID string, taskID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/restore", nil)
+		rr := httptest.NewRecorder()
+		trashModel.RestoreTask(rr, withUser(req, userID, ""), taskID)
+		return rr.Code
+	}
+
+	t.Run("OtherManager", func(t *testing.T) {
+		// Given
+		taskID := insertTestTask(t, db, technicianID)
+		trashTestTask(t, db, taskID, managerID, time.Hour)
+
+		// When
+		code := restore(otherManagerID, taskID)
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("NotInTrash", func(t *testing.T) {
+		// Given
+		taskID := insertTestTask(t, db, technicianID)
+
+		// When
+		code := restore(managerID, taskID)
+
+		// Then
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Restored", func(t *testing.T) {
+		// Given
+		taskID := insertTestTask(t, db, technicianID)
+		trashTestTask(t, db, taskID, managerID, time.Hour)
+
+		// When
+		code := restore(managerID, taskID)
+
+		// Then
+		assert.Equal(t, http.StatusOK, code)
+		var deletedAt sql.NullString
+		assert.NoError(t, db.QueryRow("SELECT deleted_at FROM tasks WHERE id = ?", taskID).Scan(&deletedAt))
+		assert.False(t, deletedAt.Valid)
+	})
+}
+
+func TestGetTrashIsScopedToSites(t *testing.T) {
+	db := setupIntegrationDB(t)
+	trashModel := models.TrashHandler(db, nil)
+	locationModel := models.LocationHandler(db)
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+
+	// Given
+	siteID := createTestLocation(t, locationModel, managerID, entities.Location{Name: "North", Kind: "site"})
+	otherSiteID := createTestLocation(t, locationModel, managerID, entities.Location{Name: "South", Kind: "site"})
+	req := httptest.NewRequest(http.MethodPut, "/managers/"+managerID+"/sites",
+		strings.NewReader(`{"location_ids":["`+siteID+`"]}`))
+	rr := httptest.NewRecorder()
+	locationModel.SetManagerSites(rr, withUser(req, managerID, ""), managerID)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	siteTaskID := insertTestTaskAt(t, db, technicianID, siteID)
+	otherSiteTaskID := insertTestTaskAt(t, db, technicianID, otherSiteID)
+	trashTestTask(t, db, siteTaskID, managerID, time.Hour)
+	trashTestTask(t, db, otherSiteTaskID, managerID, time.Hour)
+
+	// When
+	req = httptest.NewRequest(http.MethodGet, "/trash", nil)
+	rr = httptest.NewRecorder()
+	trashModel.GetTrash(rr, withUser(req, managerID, ""))
+
+	// Then
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var tasks []entities.Task
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tasks))
+	var ids []string
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []string{siteTaskID}, ids)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	db := setupIntegrationDB(t)
+	store, err := services.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	ctx := context.Background()
+	managerID := insertTestUser(t, db, "")
+	technicianID := insertTestUser(t, db, managerID)
+
+	// Given
+	expiredID := insertTestTask(t, db, technicianID)
+	recentID := insertTestTask(t, db, technicianID)
+	openID := insertTestTask(t, db, technicianID)
+	trashTestTask(t, db, expiredID, managerID, 31*24*time.Hour)
+	trashTestTask(t, db, recentID, managerID, 24*time.Hour)
+
+	keys := map[string]string{}
+	for _, taskID := range []string{expiredID, recentID} {
+		key := "tasks/" + taskID + "/" + uuid.New().String()
+		assert.NoError(t, store.Put(ctx, key, strings.NewReader("photo"), 5, "image/png"))
+		_, err := db.Exec(`INSERT INTO task_attachments (id, task_id, file_name, content_type, size, checksum, storage_key, uploaded_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, uuid.New().String(), taskID, "photo.png", "image/png", 5, strings.Repeat("0", 64), key, technicianID)
+		assert.NoError(t, err)
+		keys[taskID] = key
+	}
+
+	// When
+	purged, err := models.PurgeTrash(ctx, db, store, 30)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM tasks WHERE id IN (?, ?, ?)", expiredID, recentID, openID).Scan(&count))
+	assert.Equal(t, 2, count)
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM task_attachments WHERE task_id = ?", expiredID).Scan(&count))
+	assert.Equal(t, 0, count)
+
+	_, err = store.Get(ctx, keys[expiredID])
+	assert.ErrorIs(t, err, services.ErrBlobNotFound)
+	blob, err := store.Get(ctx, keys[recentID])
+	if assert.NoError(t, err) {
+		assert.NoError(t, blob.Close())
+	}
+}
@@ -0,0 +1,116 @@
+package models_tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestCreateView(t *testing.T) {
+	viewModel := models.ViewHandler(nil)
+
+	t.Run("UnknownFilter", func(t *testing.T) {
+		// Given
+		body := `{"name": "Critical", "filters": {"severity": "high"}}`
+		req := httptest.NewRequest(http.MethodPost, "/views", bytes.NewBufferString(body))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		viewModel.CreateView(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("MissingName", func(t *testing.T) {
+		// Given
+		body := `{"filters": {"status": "open"}}`
+		req := httptest.NewRequest(http.MethodPost, "/views", bytes.NewBufferString(body))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		viewModel.CreateView(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestValidateViewFilters(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		// Given
+		filters := entities.ViewFilters{
+			"status":      {"open,in_progress"},
+			"priority":    {"urgent"},
+			"tag":         {"electrical", "outdoor"},
+			"from":        {"week_start"},
+			"to":          {"today+7"},
+			"user_id":     {"me"},
+			"field.trade": {"plumbing"},
+		}
+
+		// When
+		err := models.ValidateViewFilters(filters)
+
+		// Then
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cases := map[string]entities.ViewFilters{
+			"UnknownParam": {"sort": {"date"}},
+			"BadPriority":  {"priority": {"critical"}},
+			"BadDate":      {"from": {"next_week"}},
+		}
+
+		for name, filters := range cases {
+			// When
+			err := models.ValidateViewFilters(filters)
+
+			// Then
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestResolveRelativeDate(t *testing.T) {
+	// Given a Wednesday
+	now := time.Date(2024, time.January, 31, 15, 4, 5, 0, time.UTC)
+
+	cases := map[string]string{
+		"today":       "2024-01-31",
+		"yesterday":   "2024-01-30",
+		"tomorrow":    "2024-02-01",
+		"today-7":     "2024-01-24",
+		"today+1":     "2024-02-01",
+		"week_start":  "2024-01-29",
+		"week_end":    "2024-02-04",
+		"month_start": "2024-01-01",
+		"month_end":   "2024-01-31",
+		"2023-12-25":  "2023-12-25",
+	}
+
+	for value, expected := range cases {
+		// When
+		date, ok := models.ResolveRelativeDate(value, now)
+
+		// Then
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, date, value)
+	}
+
+	_, ok := models.ResolveRelativeDate("today+x", now)
+	assert.False(t, ok)
+}
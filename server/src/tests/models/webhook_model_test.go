@@ -0,0 +1,87 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/entities"
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestCreateWebhook(t *testing.T) {
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		// Given
+		webhookModel := models.WebhookHandler(nil)
+		req := httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+		rr := httptest.NewRecorder()
+
+		// When
+		webhookModel.CreateWebhook(rr, req)
+
+		// Then
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+
+	t.Run("Technician", func(t *testing.T) {
+		// Given
+		webhookModel := models.WebhookHandler(nil)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "https://example.com/hook"}`))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "456")
+		rr := httptest.NewRecorder()
+
+		// When
+		webhookModel.CreateWebhook(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("InvalidWebhook", func(t *testing.T) {
+		// Given
+		webhookModel := models.WebhookHandler(nil)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "ftp://example.com", "event_types": ["task.created"]}`))
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		rr := httptest.NewRecorder()
+
+		// When
+		webhookModel.CreateWebhook(rr, req.WithContext(ctx))
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestValidateWebhook(t *testing.T) {
+	valid := entities.Webhook{URL: "https://erp.example.com/hooks/tasks", EventTypes: []string{"task.created", "task.deleted"}}
+	assert.NoError(t, models.ValidateWebhook(valid))
+
+	invalid := map[string]entities.Webhook{
+		"RelativeURL":    {URL: "/hooks", EventTypes: []string{"task.created"}},
+		"Scheme":         {URL: "mailto:ops@example.com", EventTypes: []string{"task.created"}},
+		"LongURL":        {URL: "https://example.com/" + strings.Repeat("a", 2048), EventTypes: []string{"task.created"}},
+		"NoEventTypes":   {URL: "https://example.com"},
+		"UnknownEvent":   {URL: "https://example.com", EventTypes: []string{"user.created"}},
+		"DuplicateEvent": {URL: "https://example.com", EventTypes: []string{"task.updated", "task.updated"}},
+	}
+	for name, webhook := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, models.ValidateWebhook(webhook))
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, models.WebhookBackoff(1))
+	assert.Equal(t, time.Minute, models.WebhookBackoff(2))
+	assert.Equal(t, 4*time.Minute, models.WebhookBackoff(4))
+	assert.Equal(t, 6*time.Hour, models.WebhookBackoff(12))
+	assert.Equal(t, 6*time.Hour, models.WebhookBackoff(100))
+}
@@ -0,0 +1,77 @@
+package models_tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/models"
+)
+
+func TestStartTimer(t *testing.T) {
+	t.Run("InvalidRequestMethod", func(t *testing.T) {
+		// Given
+		db := setupTestDB(t)
+		wm := &models.WorklogModel{Db: db}
+		req, err := http.NewRequest("GET", "/tasks/123/timer/start", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+
+		// When
+		wm.StartTimer(rr, req, "123")
+
+		// Then
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status code %d, but got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+	})
+
+	t.Run("ManagersCannotLogTime", func(t *testing.T) {
+		// Given
+		db := setupTestDB(t)
+		wm := &models.WorklogModel{Db: db}
+		req, err := http.NewRequest("POST", "/tasks/123/timer/start", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		req = req.WithContext(ctx)
+
+		// When
+		wm.StartTimer(rr, req, "123")
+
+		// Then
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status code %d, but got %d", http.StatusForbidden, rr.Code)
+		}
+	})
+}
+
+func TestGetWorklogSummary(t *testing.T) {
+	t.Run("InvalidGroupBy", func(t *testing.T) {
+		// Given
+		db := setupTestDB(t)
+		wm := &models.WorklogModel{Db: db}
+		req, err := http.NewRequest("GET", "/worklogs/summary?group_by=week", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		ctx := context.WithValue(req.Context(), "userID", "123")
+		ctx = context.WithValue(ctx, "managerID", "")
+		req = req.WithContext(ctx)
+
+		// When
+		wm.GetWorklogSummary(rr, req)
+
+		// Then
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, but got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible server such as MinIO
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") ||
+		r.Header.Get("X-Amz-Date") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testBlobStore(t *testing.T, store services.BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+	content := "before and after photos"
+
+	// Given
+	err := store.Put(ctx, "tasks/1/a", strings.NewReader(content), int64(len(content)), "text/plain")
+	assert.NoError(t, err)
+
+	// When
+	blob, err := store.Get(ctx, "tasks/1/a")
+
+	// Then
+	assert.NoError(t, err)
+	body, err := io.ReadAll(blob)
+	assert.NoError(t, err)
+	assert.NoError(t, blob.Close())
+	assert.Equal(t, content, string(body))
+
+	assert.NoError(t, store.Delete(ctx, "tasks/1/a"))
+	_, err = store.Get(ctx, "tasks/1/a")
+	assert.ErrorIs(t, err, services.ErrBlobNotFound)
+	assert.NoError(t, store.Delete(ctx, "tasks/1/a"))
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := services.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+
+	testBlobStore(t, store)
+
+	t.Run("RejectsPathTraversal", func(t *testing.T) {
+		err := store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+		assert.Error(t, err)
+	})
+}
+
+func TestS3BlobStore(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	defer server.Close()
+
+	testBlobStore(t, services.NewS3BlobStore(server.URL, "bucket", "us-east-1", "access", "secret"))
+
+	t.Run("UnsignedRequestsAreRejected", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/bucket/tasks/1/a")
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("WrongAccessKeyIsRejected", func(t *testing.T) {
+		store := services.NewS3BlobStore(server.URL, "bucket", "us-east-1", "other", "secret")
+		_, err := store.Get(context.Background(), "tasks/1/a")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, services.ErrBlobNotFound)
+	})
+}
@@ -0,0 +1,184 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestEventBus(t *testing.T) {
+	t.Run("Publish", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(10)
+		subscription := bus.Subscribe(0, 4)
+		defer subscription.Close()
+
+		// When
+		bus.Publish("task.created", "1", []byte(`{}`))
+		bus.Publish("task.updated", "1", []byte(`{}`))
+
+		// Then
+		first, second := <-subscription.Events, <-subscription.Events
+		assert.Equal(t, "task.created", first.Type)
+		assert.Equal(t, "1", first.Subject)
+		assert.Equal(t, first.ID+1, second.ID)
+		assert.Empty(t, subscription.Missed)
+		assert.True(t, subscription.Complete)
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(10)
+		bus.Publish("task.created", "1", nil)
+		resumeFrom := bus.Subscribe(0, 1).LastID
+		bus.Publish("task.updated", "1", nil)
+		bus.Publish("task.deleted", "1", nil)
+
+		// When
+		subscription := bus.Subscribe(resumeFrom, 1)
+
+		// Then
+		assert.True(t, subscription.Complete)
+		assert.Len(t, subscription.Missed, 2)
+		assert.Equal(t, "task.updated", subscription.Missed[0].Type)
+		assert.Equal(t, resumeFrom+2, subscription.LastID)
+	})
+
+	t.Run("ResumeTooOld", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(2)
+		bus.Publish("task.created", "1", nil)
+		resumeFrom := bus.Subscribe(0, 1).LastID
+		for i := 0; i < 3; i++ {
+			bus.Publish("task.updated", "1", nil)
+		}
+
+		// When
+		subscription := bus.Subscribe(resumeFrom, 1)
+
+		// Then
+		assert.False(t, subscription.Complete)
+		assert.Empty(t, subscription.Missed)
+	})
+
+	t.Run("ResumeAfterRestart", func(t *testing.T) {
+		// Given
+		previous := services.NewEventBus(10)
+		previous.Publish("task.created", "1", nil)
+		resumeFrom := previous.Subscribe(0, 1).LastID
+		time.Sleep(time.Millisecond)
+
+		// When
+		subscription := services.NewEventBus(10).Subscribe(resumeFrom, 1)
+
+		// Then
+		assert.False(t, subscription.Complete)
+	})
+
+	t.Run("ResumeFromUnknownID", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(10)
+		bus.Publish("task.created", "1", nil)
+		latest := bus.Subscribe(0, 1).LastID
+
+		// When
+		subscription := bus.Subscribe(latest+1000, 1)
+
+		// Then
+		assert.False(t, subscription.Complete)
+		assert.Empty(t, subscription.Missed)
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		// Given
+		bus := services.NewEventBus(10)
+		slow := bus.Subscribe(0, 1)
+		fast := bus.Subscribe(0, 4)
+
+		// When
+		bus.Publish("task.created", "1", nil)
+		bus.Publish("task.updated", "1", nil)
+
+		// Then
+		<-slow.Events
+		_, open := <-slow.Events
+		assert.False(t, open)
+		assert.Len(t, fast.Events, 2)
+		slow.Close()
+		fast.Close()
+	})
+
+	t.Run("Relay", func(t *testing.T) {
+		// Given
+		topic := &memoryTopic{}
+		first, second := services.NewEventBus(10), services.NewEventBus(10)
+		first.Relay(topic.join())
+		second.Relay(topic.join())
+		local := first.Subscribe(0, 4)
+		remote := second.Subscribe(0, 4)
+		defer local.Close()
+		defer remote.Close()
+
+		// When
+		first.Publish("live.comment", "1", []byte(`{"type":"comment"}`))
+
+		// Then
+		select {
+		case event := <-remote.Events:
+			assert.Equal(t, "live.comment", event.Type)
+			assert.Equal(t, "1", event.Subject)
+			assert.Equal(t, first.Origin(), event.Origin)
+			assert.Equal(t, `{"type":"comment"}`, string(event.Data))
+		case <-time.After(time.Second):
+			t.Fatal("event was not relayed")
+		}
+		assert.Equal(t, "live.comment", (<-local.Events).Type)
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, local.Events, "the bus must ignore its own events coming back from the relay")
+	})
+
+	t.Run("NilBus", func(t *testing.T) {
+		var bus *services.EventBus
+		assert.NotPanics(t, func() { bus.Publish("task.created", "1", nil) })
+	})
+}
+
+// memoryTopic relays events to every bus that joined it, including the sender, as a broker would
+type memoryTopic struct {
+	members []chan services.Event
+}
+
+func (m *memoryTopic) join() *memoryRelay {
+	inbox := make(chan services.Event, 16)
+	m.members = append(m.members, inbox)
+	return &memoryRelay{topic: m, inbox: inbox}
+}
+
+type memoryRelay struct {
+	topic *memoryTopic
+	inbox chan services.Event
+}
+
+func (r *memoryRelay) Send(_ context.Context, event services.Event) error {
+	for _, member := range r.topic.members {
+		member <- event
+	}
+	return nil
+}
+
+func (r *memoryRelay) Receive(_ context.Context) (services.Event, error) {
+	event, ok := <-r.inbox
+	if !ok {
+		return services.Event{}, errors.New("relay closed")
+	}
+	return event, nil
+}
+
+func (r *memoryRelay) Close() error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package services_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestWriteICalendar(t *testing.T) {
+	// Given
+	summary := strings.Repeat("Replace the pump seal é ", 8)
+	components := []services.ICalComponent{{Name: "VEVENT", Properties: []services.ICalProperty{
+		{Name: "UID", Value: "task-1@tasks-tracker"},
+		{Name: "SUMMARY", Value: services.ICalText(summary)},
+	}}}
+	var buf bytes.Buffer
+
+	// When
+	err := services.WriteICalendar(&buf, "Tasks", components)
+
+	// Then
+	assert.NoError(t, err)
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(output, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, utf8.ValidString(line))
+	}
+	unfolded := strings.ReplaceAll(output, "\r\n ", "")
+	assert.Contains(t, unfolded, "\r\nSUMMARY:"+summary+"\r\n")
+}
+
+func TestICalText(t *testing.T) {
+	assert.Equal(t, `Pumps\, valves\; seals\nline two \\ done`, services.ICalText("Pumps, valves; seals\nline two \\ done"))
+}
@@ -0,0 +1,299 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+// fakeKafkaReader hands out its messages, then blocks until the context is done
+type fakeKafkaReader struct {
+	messages  []kafka.Message
+	committed []kafka.Message
+	commitErr []error
+}
+
+func (r *fakeKafkaReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) == 0 {
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *fakeKafkaReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	if len(r.commitErr) > 0 {
+		err := r.commitErr[0]
+		r.commitErr = r.commitErr[1:]
+		return err
+	}
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+type fakeKafkaWriter struct {
+	written []kafka.Message
+	errs    []error
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if len(w.errs) > 0 {
+		err := w.errs[0]
+		w.errs = w.errs[1:]
+		return err
+	}
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func TestKafkaConsumer(t *testing.T) {
+	msg := kafka.Message{Topic: "task-events", Partition: 2, Offset: 41, Key: []byte("1"), Value: []byte(`{"type":"x"}`),
+		Headers: []kafka.Header{{Key: "trace", Value: []byte("abc")}}}
+
+	t.Run("CommitAfterHandling", func(t *testing.T) {
+		// Given
+		reader, deadLetters := &fakeKafkaReader{}, &fakeKafkaWriter{}
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 3, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error { return nil }}
+
+		// When
+		err := consumer.Process(context.Background(), msg)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Len(t, reader.committed, 1)
+		assert.Empty(t, deadLetters.written)
+	})
+
+	t.Run("RetryUntilHandled", func(t *testing.T) {
+		// Given
+		reader, deadLetters := &fakeKafkaReader{}, &fakeKafkaWriter{}
+		calls := 0
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 3, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error {
+				calls++
+				if calls < 3 {
+					return errors.New("database is down")
+				}
+				return nil
+			}}
+
+		// When
+		err := consumer.Process(context.Background(), msg)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Len(t, reader.committed, 1)
+		assert.Empty(t, deadLetters.written)
+	})
+
+	t.Run("DeadLetterAfterMaxAttempts", func(t *testing.T) {
+		// Given
+		reader, deadLetters := &fakeKafkaReader{}, &fakeKafkaWriter{}
+		calls := 0
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 3, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error {
+				calls++
+				return errors.New("database is down")
+			}}
+
+		// When
+		err := consumer.Process(context.Background(), msg)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Len(t, reader.committed, 1)
+		assert.Len(t, deadLetters.written, 1)
+		deadLetter := deadLetters.written[0]
+		assert.Equal(t, msg.Value, deadLetter.Value)
+		assert.Equal(t, msg.Key, deadLetter.Key)
+		assert.Equal(t, "database is down", services.DeadLetterHeader(deadLetter, services.DeadLetterError))
+		assert.Equal(t, "3", services.DeadLetterHeader(deadLetter, services.DeadLetterAttempts))
+		assert.Equal(t, "task-events", services.DeadLetterHeader(deadLetter, services.DeadLetterTopic))
+		assert.Equal(t, "2", services.DeadLetterHeader(deadLetter, services.DeadLetterPartition))
+		assert.Equal(t, "41", services.DeadLetterHeader(deadLetter, services.DeadLetterOffset))
+		assert.Equal(t, "abc", services.DeadLetterHeader(deadLetter, "trace"))
+	})
+
+	t.Run("PermanentFailure", func(t *testing.T) {
+		// Given
+		reader, deadLetters := &fakeKafkaReader{}, &fakeKafkaWriter{}
+		calls := 0
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 3, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error {
+				calls++
+				return services.Permanent(errors.New("message has no type"))
+			}}
+
+		// When
+		err := consumer.Process(context.Background(), msg)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Len(t, deadLetters.written, 1)
+		assert.Equal(t, "1", services.DeadLetterHeader(deadLetters.written[0], services.DeadLetterAttempts))
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		// Given
+		reader, deadLetters := &fakeKafkaReader{}, &fakeKafkaWriter{}
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 3, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error { panic("nil map") }}
+
+		// When
+		err := consumer.Process(context.Background(), msg)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Len(t, reader.committed, 1)
+		assert.Equal(t, "panic: nil map", services.DeadLetterHeader(deadLetters.written[0], services.DeadLetterError))
+	})
+
+	t.Run("NoCommitBeforeDeadLetterIsWritten", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{}
+		deadLetters := &fakeKafkaWriter{errs: []error{errors.New("broker unavailable")}}
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 1, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error { return errors.New("failed") }}
+
+		// When
+		err := consumer.Process(context.Background(), msg)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Len(t, deadLetters.written, 1)
+		assert.Len(t, reader.committed, 1)
+	})
+
+	t.Run("StopWhileDeadLetterCannotBeWritten", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{}
+		deadLetters := &fakeKafkaWriter{errs: []error{errors.New("broker unavailable"), errors.New("broker unavailable")}}
+		ctx, cancel := context.WithCancel(context.Background())
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: deadLetters, MaxAttempts: 1, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error {
+				cancel()
+				return errors.New("failed")
+			}}
+
+		// When
+		err := consumer.Process(ctx, msg)
+
+		// Then
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, reader.committed, "the message must be read again after a restart")
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{messages: []kafka.Message{msg, msg}}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		consumer := &services.KafkaConsumer{Reader: reader, DeadLetters: &fakeKafkaWriter{}, MaxAttempts: 1, Backoff: time.Millisecond,
+			Handler: func(context.Context, kafka.Message) error { return nil }}
+
+		// When
+		err := consumer.Run(ctx)
+
+		// Then
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Len(t, reader.committed, 2)
+	})
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	original := kafka.Message{Topic: "task-events", Partition: 0, Offset: 7, Key: []byte("1"), Value: []byte(`{"type":"x"}`),
+		Headers: []kafka.Header{{Key: "trace", Value: []byte("abc")}}}
+	deadLetter := services.DeadLetterMessage(original, errors.New("bug"), 5, time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC))
+	deadLetter.Topic, deadLetter.Offset = "task-events-dlq", 3
+
+	t.Run("Replay", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{messages: []kafka.Message{deadLetter}}
+		writer := &fakeKafkaWriter{}
+
+		// When
+		replayed, err := services.ReplayDeadLetters(context.Background(), reader, writer, services.ReplayOptions{Idle: 10 * time.Millisecond})
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, 1, replayed)
+		assert.Len(t, reader.committed, 1)
+		assert.Len(t, writer.written, 1)
+		msg := writer.written[0]
+		assert.Equal(t, "task-events", msg.Topic)
+		assert.Equal(t, original.Key, msg.Key)
+		assert.Equal(t, original.Value, msg.Value)
+		assert.Equal(t, "abc", services.DeadLetterHeader(msg, "trace"))
+		assert.Equal(t, "1", services.DeadLetterHeader(msg, services.DeadLetterReplays))
+		assert.Empty(t, services.DeadLetterHeader(msg, services.DeadLetterError))
+	})
+
+	t.Run("ReplayCountSurvivesAnotherFailure", func(t *testing.T) {
+		// Given
+		replay, err := services.ReplayMessage(deadLetter)
+		assert.NoError(t, err)
+
+		// When
+		again := services.DeadLetterMessage(replay, errors.New("still broken"), 5, time.Now())
+
+		// Then
+		assert.Equal(t, "1", services.DeadLetterHeader(again, services.DeadLetterReplays))
+		assert.Equal(t, "still broken", services.DeadLetterHeader(again, services.DeadLetterError))
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{messages: []kafka.Message{deadLetter, deadLetter}}
+		writer := &fakeKafkaWriter{}
+		var reported []kafka.Message
+
+		// When
+		listed, err := services.ReplayDeadLetters(context.Background(), reader, writer, services.ReplayOptions{Idle: 10 * time.Millisecond,
+			DryRun: true, Report: func(msg kafka.Message) { reported = append(reported, msg) }})
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, 2, listed)
+		assert.Len(t, reported, 2)
+		assert.Empty(t, writer.written)
+		assert.Empty(t, reader.committed)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{messages: []kafka.Message{deadLetter, deadLetter}}
+		writer := &fakeKafkaWriter{}
+
+		// When
+		replayed, err := services.ReplayDeadLetters(context.Background(), reader, writer, services.ReplayOptions{Idle: 10 * time.Millisecond, Limit: 1})
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, 1, replayed)
+		assert.Len(t, reader.messages, 1)
+	})
+
+	t.Run("MissingOrigin", func(t *testing.T) {
+		// Given
+		reader := &fakeKafkaReader{messages: []kafka.Message{{Value: []byte("{}")}}}
+
+		// When
+		_, err := services.ReplayDeadLetters(context.Background(), reader, &fakeKafkaWriter{}, services.ReplayOptions{Idle: 10 * time.Millisecond})
+
+		// Then
+		assert.Error(t, err)
+		assert.Empty(t, reader.committed)
+	})
+}
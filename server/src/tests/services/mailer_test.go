@@ -0,0 +1,163 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestBuildMailMessage(t *testing.T) {
+	// Given
+	message := services.MailMessage{
+		To:      "grace@example.com",
+		Subject: "Daily digest\r\nBcc: someone@example.com",
+		Text:    "Plain body",
+		HTML:    "<p>HTML body</p>",
+	}
+
+	// When
+	raw, err := services.BuildMailMessage("tasks@example.com", message, time.Date(2023, 1, 2, 7, 0, 0, 0, time.UTC))
+
+	// Then
+	assert.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	assert.Equal(t, "grace@example.com", parsed.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		body, err := io.ReadAll(part)
+		assert.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"Plain body", "<p>HTML body</p>"}, bodies)
+}
+
+// silentSMTPServer accepts connections and never answers, like a stuck mail server
+func silentSMTPServer(t *testing.T) (string, int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+	address := listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port
+}
+
+// fakeSMTPServer accepts one message and passes its data on
+func fakeSMTPServer(t *testing.T) (string, int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.Fields(line + " x")[0]); command {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				_ = text.PrintfLine("250 OK")
+			case "DATA":
+				_ = text.PrintfLine("354 Go ahead")
+				data, _ := text.ReadDotBytes()
+				received <- string(data)
+				_ = text.PrintfLine("250 Queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 Bye")
+				return
+			default:
+				_ = text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+	address := listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port, received
+}
+
+func TestSMTPMailerSends(t *testing.T) {
+	// Given
+	host, port, received := fakeSMTPServer(t)
+	mailer := services.NewSMTPMailer(host, port, "", "", "tasks@example.com", 5*time.Second)
+
+	// When
+	err := mailer.Send(context.Background(), services.MailMessage{To: "grace@example.com", Subject: "Daily digest", Text: "Plain body"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Contains(t, <-received, "Subject: Daily digest")
+}
+
+func TestSMTPMailerGivesUp(t *testing.T) {
+	message := services.MailMessage{To: "grace@example.com", Subject: "Daily digest", Text: "Plain body"}
+
+	t.Run("Timeout", func(t *testing.T) {
+		// Given
+		host, port := silentSMTPServer(t)
+		mailer := services.NewSMTPMailer(host, port, "", "", "tasks@example.com", 100*time.Millisecond)
+
+		// When
+		started := time.Now()
+		err := mailer.Send(context.Background(), message)
+
+		// Then
+		assert.Error(t, err)
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		// Given
+		host, port := silentSMTPServer(t)
+		mailer := services.NewSMTPMailer(host, port, "", "", "tasks@example.com", time.Minute)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		// When
+		started := time.Now()
+		err := mailer.Send(ctx, message)
+
+		// Then
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+}
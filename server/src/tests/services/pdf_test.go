@@ -0,0 +1,107 @@
+package services_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestPDFDocument(t *testing.T) {
+	// Given
+	var logo bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	assert.NoError(t, png.Encode(&logo, img))
+	pdfImage, err := services.NewPDFImage(logo.Bytes())
+	assert.NoError(t, err)
+
+	doc := services.NewPDFDocument("Work Order (draft)")
+	doc.AddPage()
+	doc.Text(50, 50, services.PDFHelveticaBold, 12, services.PDFBlack, "Pump room – level 2")
+	doc.Image(pdfImage, 400, 30, 40, 20)
+	doc.AddPage()
+	doc.Line(50, 100, 500, 100, 1, services.PDFGray)
+	var buf bytes.Buffer
+
+	// When
+	err = doc.Write(&buf)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 4, pdfImage.Width)
+	assert.Equal(t, 2, pdfImage.Height)
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "%PDF-1."))
+	assert.True(t, strings.HasSuffix(output, "%%EOF\n"))
+	assert.Contains(t, output, "/Count 2")
+	assert.Contains(t, output, `/Title (Work Order \(draft\))`)
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(output)
+	assert.Len(t, startxref, 2)
+	offset, _ := strconv.Atoi(startxref[1])
+	assert.True(t, strings.HasPrefix(output[offset:], "xref\n0 "))
+	for number, entry := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(output, -1) {
+		position, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(output[position:], strconv.Itoa(number+1)+" 0 obj\n"))
+	}
+}
+
+func TestNewPDFImage(t *testing.T) {
+	_, err := services.NewPDFImage([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+func TestPDFWrapText(t *testing.T) {
+	// Given
+	text := "Replace the mechanical seal of the circulation pump\nCheck pressure"
+	width := services.PDFTextWidth(services.PDFHelvetica, 10, "Replace the mechanical")
+
+	// When
+	lines := services.PDFWrapText(services.PDFHelvetica, 10, text, width)
+
+	// Then
+	assert.Equal(t, []string{"Replace the mechanical", "seal of the circulation", "pump", "Check pressure"}, lines)
+	for _, line := range services.PDFWrapText(services.PDFHelvetica, 10, strings.Repeat("x", 80), 50) {
+		assert.LessOrEqual(t, services.PDFTextWidth(services.PDFHelvetica, 10, line), 50.0)
+	}
+	assert.Greater(t, services.PDFTextWidth(services.PDFHelveticaBold, 10, "Total"), services.PDFTextWidth(services.PDFHelvetica, 10, "Total"))
+}
+
+func TestParsePDFColor(t *testing.T) {
+	c, err := services.ParsePDFColor("#ff8000")
+	assert.NoError(t, err)
+	assert.Equal(t, services.PDFColor{R: 1, G: 128.0 / 255, B: 0}, c)
+
+	for _, value := range []string{"", "ff8000", "#ff80", "#gg8000"} {
+		_, err := services.ParsePDFColor(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestPDFReport(t *testing.T) {
+	// Given
+	report := services.NewPDFReport("Timesheet", services.PDFBranding{CompanyName: "Acme Facilities", Footer: "Thank you", Accent: services.PDFBlack})
+	rows := make([]services.PDFRow, 120)
+	for i := range rows {
+		rows[i] = services.PDFRow{Cells: []string{strconv.Itoa(i), "Inspection"}}
+	}
+	report.Table([]services.PDFColumn{{Title: "No", Width: 0.2, AlignRight: true}, {Title: "Task", Width: 0.8}}, rows)
+	report.Signatures([]string{"Technician", "Manager"})
+	var buf bytes.Buffer
+
+	// When
+	err := report.Write(&buf)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Regexp(t, `/Count [3-9]`, buf.String())
+}
@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestSearchIndex(t *testing.T) {
+	index := services.NewSearchIndex()
+	index.Rebuild(map[string]string{
+		"1": "Replace the air filter in the pump room",
+		"2": "Pump maintenance",
+		"3": "Inspect fire extinguishers",
+		"4": "Check pump pressure and pump seals",
+	})
+
+	t.Run("RanksMatches", func(t *testing.T) {
+		// When
+		hits := index.Search("PUMP", 0)
+
+		// Then the short summary ranks above the longer ones mentioning the pump
+		ids := []string{}
+		for _, hit := range hits {
+			ids = append(ids, hit.ID)
+		}
+		assert.Equal(t, []string{"2", "4", "1"}, ids)
+	})
+
+	t.Run("AnyTermMatches", func(t *testing.T) {
+		// When
+		hits := index.Search("filter extinguishers", 0)
+
+		// Then
+		assert.Len(t, hits, 2)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		// When
+		hits := index.Search("pump", 1)
+
+		// Then
+		assert.Len(t, hits, 1)
+		assert.Equal(t, "2", hits[0].ID)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		// When
+		hits := index.Search("boiler", 0)
+
+		// Then
+		assert.Empty(t, hits)
+	})
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"fix", "hvac", "unit", "3b"}, services.Tokenize("Fix HVAC-unit #3b!"))
+}
@@ -0,0 +1,131 @@
+package services_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// Given
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1672646400.{"type":"task.created"}`))
+
+	// When
+	signature := services.SignWebhook("secret", 1672646400, []byte(`{"type":"task.created"}`))
+
+	// Then
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+	assert.NotEqual(t, signature, services.SignWebhook("other", 1672646400, []byte(`{"type":"task.created"}`)))
+	assert.NotEqual(t, signature, services.SignWebhook("secret", 1672646401, []byte(`{"type":"task.created"}`)))
+}
+
+func TestWebhookSender(t *testing.T) {
+	now := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	// The test receivers listen on loopback, which senders only reach when it is allowed
+	loopback := []*net.IPNet{{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)}}
+
+	t.Run("SignedRequest", func(t *testing.T) {
+		// Given
+		var received *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+		request := services.WebhookRequest{URL: server.URL, Secret: "secret", Event: "task.updated", DeliveryID: "d1",
+			Payload: []byte(`{"type":"task.updated"}`)}
+
+		// When
+		response, err := services.NewWebhookSender(time.Second, loopback).Send(context.Background(), request, now)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "ok", response.Body)
+		assert.Equal(t, http.MethodPost, received.Method)
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		assert.Equal(t, "task.updated", received.Header.Get("X-Webhook-Event"))
+		assert.Equal(t, "d1", received.Header.Get("X-Webhook-Delivery"))
+		assert.Equal(t, "1672646400", received.Header.Get("X-Webhook-Timestamp"))
+		assert.Equal(t, services.SignWebhook("secret", 1672646400, body), received.Header.Get("X-Webhook-Signature"))
+		assert.Equal(t, `{"type":"task.updated"}`, string(body))
+	})
+
+	t.Run("ErrorStatus", func(t *testing.T) {
+		// Given
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		// When
+		response, err := services.NewWebhookSender(time.Second, loopback).Send(context.Background(), services.WebhookRequest{URL: server.URL}, now)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.Equal(t, "maintenance\n", response.Body)
+	})
+
+	t.Run("NoRedirects", func(t *testing.T) {
+		// Given
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		}))
+		defer server.Close()
+
+		// When
+		response, err := services.NewWebhookSender(time.Second, loopback).Send(context.Background(), services.WebhookRequest{URL: server.URL}, now)
+
+		// Then
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusFound, response.StatusCode)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		// Given
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		// When
+		_, err := services.NewWebhookSender(time.Second, loopback).Send(context.Background(), services.WebhookRequest{URL: server.URL}, now)
+
+		// Then
+		assert.Error(t, err)
+	})
+
+	t.Run("InternalAddressesBlocked", func(t *testing.T) {
+		// Given
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer server.Close()
+		_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+		sender := services.NewWebhookSender(time.Second, nil)
+
+		for _, url := range []string{server.URL, "http://localhost:" + port, "http://10.0.0.1/hook", "http://169.254.169.254/latest",
+			"http://[::1]:" + port, "http://0.0.0.0:" + port, "http://100.64.0.1/hook"} {
+			// When
+			_, err := sender.Send(context.Background(), services.WebhookRequest{URL: url}, now)
+
+			// Then
+			assert.ErrorIs(t, err, services.ErrWebhookAddressBlocked, url)
+		}
+		assert.False(t, called)
+	})
+}
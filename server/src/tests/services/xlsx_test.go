@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/christianotieno/tasks-traker-app/server/src/services"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	// Given
+	var buf bytes.Buffer
+	writer, err := services.NewXLSXWriter(&buf, "Tasks")
+	assert.NoError(t, err)
+
+	// When
+	assert.NoError(t, writer.WriteRow([]services.XLSXCell{{Value: "summary"}, {Value: "minutes"}}))
+	assert.NoError(t, writer.WriteRow([]services.XLSXCell{{Value: "Fix <pump> & seal"}, {Value: "90", Number: true}}))
+	assert.NoError(t, writer.Close())
+
+	// Then
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(reader)
+		parts[file.Name] = string(content)
+	}
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts["xl/workbook.xml"], `name="Tasks"`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Fix &lt;pump&gt; &amp; seal</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>90</v></c>`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	assert.Equal(t, "A", services.XLSXColumnName(0))
+	assert.Equal(t, "Z", services.XLSXColumnName(25))
+	assert.Equal(t, "AA", services.XLSXColumnName(26))
+	assert.Equal(t, "BA", services.XLSXColumnName(52))
+}